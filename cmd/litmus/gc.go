@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/gc"
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+)
+
+// gcUsage describes the gc command
+const gcUsage = "delete the objects leaked by expired or inactive runs & their volumes"
+
+// runGC lists the objects leaked by the runs of litmus & deletes them unless
+// this is a dry run
+func runGC(args []string) (err error) {
+	flags := flag.NewFlagSet("gc", flag.ContinueOnError)
+	ttl := flags.Duration("ttl", gc.DefaultTTL, "age after which the objects of a run are deleted")
+	kinds := flags.String("kinds", strings.Join(gc.DefaultKinds, ","), "comma separated kinds of objects to search for the run labels")
+	inactive := flags.Bool("inactive", false, "delete the objects of runs that are no longer active before the ttl")
+	unlabelledVolumes := flags.Bool("unlabelled-volumes", false, "delete the released volumes that do not carry the run labels")
+	dryRun := flags.Bool("dry-run", false, "list the objects without deleting them")
+	kubeconfig := flags.String("kubeconfig", "", "path of the kubeconfig file")
+	context := flags.String("context", "", "kubeconfig context to use")
+	err = flags.Parse(args)
+	if err == flag.ErrHelp {
+		return nil
+	}
+	if err != nil {
+		return
+	}
+
+	k := kubectl.New()
+	if len(*kubeconfig) != 0 {
+		k = k.KubeConfig(*kubeconfig)
+	}
+	if len(*context) != 0 {
+		k = k.Context(*context)
+	}
+
+	c := gc.NewCollector(k)
+	c.TTL = *ttl
+	c.Kinds = strings.Split(*kinds, ",")
+	c.Inactive = *inactive
+	c.UnlabelledVolumes = *unlabelledVolumes
+
+	candidates, err := c.Find()
+	if err != nil {
+		return
+	}
+	if len(candidates) == 0 {
+		fmt.Println("no leaked objects found")
+		return
+	}
+
+	printCandidates(os.Stdout, candidates)
+	if *dryRun {
+		return
+	}
+
+	err = c.Delete(candidates)
+	if err != nil {
+		return
+	}
+	fmt.Printf("deleted '%d' object(s)\n", len(candidates))
+	return
+}
+
+// printCandidates prints the candidates as a table
+func printCandidates(out io.Writer, candidates []gc.Candidate) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tNAMESPACE\tRUN\tTEST\tAGE\tREASON")
+	for _, c := range candidates {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.Kind, c.Name, c.Namespace, c.RunID, c.Test, c.Age.Round(time.Second), c.Reason)
+	}
+	w.Flush()
+}
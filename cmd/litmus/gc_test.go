@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/gc"
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/sim"
+)
+
+// leaked is an object of an expired run
+const leaked = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: old-config
+  labels:
+    litmus.io/run-id: old-run
+    litmus.io/test: ha-on-minio
+`
+
+func TestRunGC(t *testing.T) {
+	tests := map[string]struct {
+		args      []string
+		isErr     bool
+		isDeleted bool
+	}{
+		"gc - +ve test case - dry run lists without delete": {
+			args: []string{"--dry-run", "--kinds=configmap"},
+		},
+		"gc - +ve test case - expired objects are deleted": {
+			args:      []string{"--kinds=configmap"},
+			isDeleted: true,
+		},
+		"gc - +ve test case - help": {
+			args: []string{"-h"},
+		},
+		"gc - -ve test case - invalid ttl": {
+			args:  []string{"--ttl=soon"},
+			isErr: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := sim.NewCluster("node-1")
+			if err := c.Apply([]byte(leaked), "litmus"); err != nil {
+				t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+			}
+			kubectl.SetDefaultExecutor(c)
+			defer kubectl.SetDefaultExecutor(nil)
+
+			err := runGC(mock.args)
+			if (err != nil) != mock.isErr {
+				t.Fatalf("failed to run gc '%v': expected error '%t': actual '%v'", mock.args, mock.isErr, err)
+			}
+			if _, found := c.Get("ConfigMap", "litmus", "old-config"); found == mock.isDeleted {
+				t.Fatalf("failed to run gc '%v': expected deleted '%t': actual found '%t'", mock.args, mock.isDeleted, found)
+			}
+		})
+	}
+}
+
+func TestPrintCandidates(t *testing.T) {
+	candidates := []gc.Candidate{
+		{
+			TrackedObject: kubectl.TrackedObject{Kind: "configmap", Name: "old-config", Namespace: "litmus", RunID: "old-run"},
+			Test:          "ha-on-minio",
+			Age:           26*time.Hour + 1500*time.Millisecond,
+			Reason:        gc.ExpiredReason,
+		},
+		{
+			TrackedObject: kubectl.TrackedObject{Kind: "persistentvolume", Name: "pvc-1a2b"},
+			Age:           time.Hour,
+			Reason:        gc.ReleasedReason,
+		},
+	}
+
+	expected := "" +
+		"KIND              NAME        NAMESPACE  RUN      TEST         AGE      REASON\n" +
+		"configmap         old-config  litmus     old-run  ha-on-minio  26h0m2s  expired\n" +
+		"persistentvolume  pvc-1a2b                                     1h0m0s   released\n"
+
+	var out bytes.Buffer
+	printCandidates(&out, candidates)
+	if out.String() != expected {
+		t.Fatalf("failed to print candidates: expected '%s': actual '%s'", expected, out.String())
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+)
+
+// lintUsage describes the lint command
+const lintUsage = "validate the install files embedded in the config maps of the manifests"
+
+// runLint validates the install files embedded in the config maps of every
+// yaml manifest found in the provided paths; tests is linted by default
+func runLint(args []string) (err error) {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: litmus lint [paths...]\n")
+		flags.PrintDefaults()
+	}
+	err = flags.Parse(args)
+	if err == flag.ErrHelp {
+		return nil
+	}
+	if err != nil {
+		return
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"tests"}
+	}
+
+	problems := 0
+	for _, p := range paths {
+		err = filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !isManifest(path) {
+				return nil
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			errs, err := meta.LintManifest(data)
+			if err != nil {
+				return fmt.Errorf("failed to lint '%s': %s", path, err)
+			}
+			for _, e := range errs {
+				fmt.Printf("%s:%d: config map '%s' key '%s': %s\n", path, e.Line, e.ConfigMap, e.Key, e.Message)
+			}
+			problems += len(errs)
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	if problems != 0 {
+		err = fmt.Errorf("found '%d' problem(s) in install files", problems)
+	}
+	return
+}
+
+// isManifest flags if the file is a yaml manifest
+func isManifest(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
@@ -8,8 +8,7 @@ Feature: Test MySQL resiliency on Kubernetes PV
   Scenario: launch MySQL on Kubernetes PV
     Given I have a kubernetes cluster with volume operator installed
     When I launch mysql application on volume
-    Then wait for "300s"
-    And verify mysql application is launched successfully on volume
+    Then verify mysql application is launched successfully on volume within "300s"
 
   Scenario: Kubernetes volume replicas should run on unique nodes
     Given mysql application is launched successfully on volume
@@ -18,12 +17,10 @@ Feature: Test MySQL resiliency on Kubernetes PV
 
   Scenario: MySQL application should run when one volume replica is deleted
     Given I delete a volume replica
-    Then verify mysql application is running
-    And wait for "60s"
-    And verify all volume replicas are running
+    Then verify mysql application keeps running for "60s"
+    And verify all volume replicas are running within "60s"
 
   Scenario: MySQL application should run when other volume replica is deleted
     Given I delete another volume replica
-    Then verify mysql application is running
-    And wait for "60s"
-    And verify all volume replicas are running
+    Then verify mysql application keeps running for "60s"
+    And verify all volume replicas are running within "60s"
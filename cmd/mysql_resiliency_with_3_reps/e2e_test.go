@@ -19,6 +19,7 @@ package main
 import (
 	"fmt"
 
+	"github.com/AmitKumarDas/elitmus/pkg/hook"
 	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
 	"github.com/AmitKumarDas/elitmus/pkg/meta"
 	"github.com/AmitKumarDas/elitmus/pkg/time"
@@ -107,10 +108,6 @@ func (e2e *MySQLResiliencyWith3Reps) withVolumeVerifier(f *gherkin.Feature) {
 	e2e.volVerifier = v
 }
 
-func (e2e *MySQLResiliencyWith3Reps) tearDown(f *gherkin.Feature) {
-	kubectl.New().Run([]string{"delete", "-f", string(ApplicationKF)})
-}
-
 func (e2e *MySQLResiliencyWith3Reps) iHaveAKubernetesClusterWithVolumeOperatorInstalled() (err error) {
 	kubeVerifier := verify.NewKubernetesVerify()
 	// checks if kubernetes cluster is available & is connected
@@ -164,6 +161,10 @@ func (e2e *MySQLResiliencyWith3Reps) verifyMysqlApplicationIsRunning() (err erro
 	return
 }
 
+func (e2e *MySQLResiliencyWith3Reps) verifyMysqlApplicationKeepsRunningFor(duration string) (err error) {
+	return verify.ConsistentlyStep(duration, e2e.verifyMysqlApplicationIsRunning)
+}
+
 func (e2e *MySQLResiliencyWith3Reps) verifyAllVolumeReplicasAreRunning() (err error) {
 	if e2e.volVerifier == nil {
 		err = fmt.Errorf("nil volume verifier: possible error '%s'", e2e.errors[VolumeVerifyFileEI])
@@ -181,6 +182,10 @@ func (e2e *MySQLResiliencyWith3Reps) verifyAllVolumeReplicasAreRunning() (err er
 	return
 }
 
+func (e2e *MySQLResiliencyWith3Reps) verifyAllVolumeReplicasAreRunningWithin(timeout string) (err error) {
+	return verify.EventuallyStep(timeout, e2e.verifyAllVolumeReplicasAreRunning)
+}
+
 func (e2e *MySQLResiliencyWith3Reps) verifyMysqlApplicationIsLaunchedSuccessfullyOnVolume() (err error) {
 	// check if application is running
 	err = e2e.verifyMysqlApplicationIsRunning()
@@ -192,6 +197,10 @@ func (e2e *MySQLResiliencyWith3Reps) verifyMysqlApplicationIsLaunchedSuccessfull
 	return e2e.verifyAllVolumeReplicasAreRunning()
 }
 
+func (e2e *MySQLResiliencyWith3Reps) verifyMysqlApplicationIsLaunchedSuccessfullyOnVolumeWithin(timeout string) (err error) {
+	return verify.EventuallyStep(timeout, e2e.verifyMysqlApplicationIsLaunchedSuccessfullyOnVolume)
+}
+
 func (e2e *MySQLResiliencyWith3Reps) mysqlApplicationIsLaunchedSuccessfullyOnVolume() (err error) {
 	return e2e.verifyMysqlApplicationIsLaunchedSuccessfullyOnVolume()
 }
@@ -245,21 +254,33 @@ func FeatureContext(s *godog.Suite) {
 		errors: map[errorIdentity]error{},
 	}
 
+	// track the feature, scenario & step being run
+	hook.Scope(s)
+	// run the feature in its own namespace if set in the environment; this
+	// needs to be registered before the verify files are loaded
+	hook.EphemeralNamespace(s)
+	// gather the diagnostics of a failed step
+	hook.Diagnostics(s)
+
 	s.BeforeFeature(e2e.withOperatorVerifier)
 	s.BeforeFeature(e2e.withApplicationVerifier)
 	s.BeforeFeature(e2e.withVolumeVerifier)
 
-	s.AfterFeature(e2e.tearDown)
+	// delete what was applied & restore the nodes after the feature run
+	hook.Teardown(s)
 
 	// this associates the specs with corresponding methods of mysqlResiliencyWith3Reps
 	s.Step(`^I have a kubernetes cluster with volume operator installed$`, e2e.iHaveAKubernetesClusterWithVolumeOperatorInstalled)
 	s.Step(`^I launch mysql application on volume$`, e2e.iLaunchMysqlApplicationOnVolume)
 	s.Step(`^wait for "([^"]*)"$`, e2e.waitFor)
 	s.Step(`^verify mysql application is launched successfully on volume$`, e2e.verifyMysqlApplicationIsLaunchedSuccessfullyOnVolume)
+	s.Step(`^verify mysql application is launched successfully on volume within "([^"]*)"$`, e2e.verifyMysqlApplicationIsLaunchedSuccessfullyOnVolumeWithin)
 	s.Step(`^mysql application is launched successfully on volume$`, e2e.mysqlApplicationIsLaunchedSuccessfullyOnVolume)
 	s.Step(`^verify each volume replica gets a unique node$`, e2e.verifyEachVolumeReplicaGetsAUniqueNode)
 	s.Step(`^verify mysql application is running$`, e2e.verifyMysqlApplicationIsRunning)
+	s.Step(`^verify mysql application keeps running for "([^"]*)"$`, e2e.verifyMysqlApplicationKeepsRunningFor)
 	s.Step(`^verify all volume replicas are running$`, e2e.verifyAllVolumeReplicasAreRunning)
+	s.Step(`^verify all volume replicas are running within "([^"]*)"$`, e2e.verifyAllVolumeReplicasAreRunningWithin)
 	s.Step(`^I delete a volume replica$`, e2e.iDeleteAVolumeReplica)
 	s.Step(`^I delete another volume replica$`, e2e.iDeleteAnotherVolumeReplica)
 	s.Step(`^verify there are three replicas of volume deployment$`, e2e.verifyThereAreThreeReplicasOfVolumeDeployment)
@@ -0,0 +1,366 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+	"github.com/AmitKumarDas/elitmus/pkg/verify"
+)
+
+const (
+	// DefaultArtifactsDir is the directory where the diagnostics bundles are
+	// written if no directory is configured
+	DefaultArtifactsDir = "/tmp/litmus/artifacts"
+	// MaxConfigMapSize is the maximum size of a bundle that can be pushed into
+	// a config map. Kubernetes limits a config map to 1MiB.
+	MaxConfigMapSize = 1000 * 1024
+	// BundleLabel is set against the config maps that hold a diagnostics
+	// bundle
+	BundleLabel = "litmus.io/diagnostics"
+)
+
+// Bundle is the outcome of a diagnostics collection
+type Bundle struct {
+	// Path of the tarball
+	Path string
+	// ConfigMap is the name of the config map where the tarball was pushed.
+	// This is empty if the tarball was not pushed.
+	ConfigMap string
+	// Errors are the failures observed while gathering the diagnostics. These
+	// are recorded in the tarball as well.
+	Errors []string
+}
+
+// Collector gathers the diagnostics of every component of the loaded
+// installations into a timestamped tarball i.e. describe output, current &
+// previous pod logs, namespace events, node conditions & yaml dumps
+type Collector struct {
+	// dir is the artifacts directory where the tarballs are written
+	dir string
+	// configMap is the name prefix of the config maps where the tarballs are
+	// pushed. Nothing is pushed if this is empty.
+	configMap string
+	// now returns the current time
+	now func() time.Time
+}
+
+// NewCollector returns a new collector that writes the tarballs into the
+// provided directory & pushes them into config maps prefixed with the
+// provided name, if any
+func NewCollector(dir, configMap string) *Collector {
+	if len(strings.TrimSpace(dir)) == 0 {
+		dir = DefaultArtifactsDir
+	}
+
+	return &Collector{
+		dir:       dir,
+		configMap: strings.TrimSpace(configMap),
+		now:       time.Now,
+	}
+}
+
+// NewCollectorFromEnv returns a new collector that is configured from the
+// environment
+func NewCollectorFromEnv() *Collector {
+	return NewCollector(util.DiagnosticsDirENV(), util.DiagnosticsConfigMapENV())
+}
+
+// file is a file of the bundle
+type file struct {
+	name string
+	data []byte
+}
+
+// gatherer accumulates the files of a bundle. Failures to gather a file are
+// tracked instead of stopping the collection.
+type gatherer struct {
+	files  []file
+	errors []string
+}
+
+// add adds a file with the provided content
+func (g *gatherer) add(name, data string) {
+	g.files = append(g.files, file{name: name, data: []byte(data)})
+}
+
+// fail tracks a failure to gather the file with the provided name
+func (g *gatherer) fail(name string, err error) {
+	g.errors = append(g.errors, fmt.Sprintf("%s: %s", name, err))
+}
+
+// run adds the output of the kubectl command as a file
+func (g *gatherer) run(name string, k kubectl.KubeRunner, args ...string) {
+	op, err := k.Run(args)
+	if err != nil {
+		g.fail(name, err)
+		return
+	}
+	g.add(name, op)
+}
+
+// Collect gathers the diagnostics & writes them into a tarball. The provided
+// reason e.g. the failed step & its error is recorded in the tarball.
+func (c *Collector) Collect(reason string) (b Bundle, err error) {
+	stamp := c.now().UTC().Format("20060102t150405z")
+	base := "litmus-diagnostics-" + stamp
+
+	g := &gatherer{}
+	g.add("reason.txt", reason+"\n")
+
+	namespaces := map[string]bool{}
+	installations := meta.Loaded()
+	var files []string
+	for f := range installations {
+		files = append(files, string(f))
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		install := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		for i, comp := range installations[meta.InstallFile(f)].Components {
+			namespace := kubectl.ResolveConfig(kubectl.Config{Namespace: comp.Namespace}).Namespace
+			namespaces[namespace] = true
+			g.component(fmt.Sprintf("components/%s/%d-%s", install, i, componentName(comp)), comp, namespace)
+		}
+	}
+
+	var sorted []string
+	for n := range namespaces {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+	for _, n := range sorted {
+		g.events(n)
+	}
+
+	g.nodes()
+
+	if len(g.errors) != 0 {
+		g.add("errors.txt", strings.Join(g.errors, "\n")+"\n")
+	}
+	b.Errors = g.errors
+
+	data, err := tarball(base, g.files, c.now())
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(c.dir, 0755)
+	if err != nil {
+		return
+	}
+
+	b.Path = filepath.Join(c.dir, base+".tar.gz")
+	err = ioutil.WriteFile(b.Path, data, 0644)
+	if err != nil {
+		return
+	}
+
+	if len(c.configMap) == 0 {
+		return
+	}
+
+	name := c.configMap + "-" + stamp
+	err = push(name, filepath.Base(b.Path), data)
+	if err != nil {
+		err = fmt.Errorf("failed to push diagnostics '%s' to config map '%s': %s", b.Path, name, err)
+		return
+	}
+	b.ConfigMap = name
+	return
+}
+
+// componentName returns the name used to refer to the component in the
+// bundle
+func componentName(c meta.Component) string {
+	for _, n := range []string{c.Alias, c.Name, c.Kind} {
+		if n = strings.TrimSpace(n); len(n) != 0 {
+			return n
+		}
+	}
+	return "component"
+}
+
+// component gathers the describe output, yaml dump & logs of the objects
+// that make up the component
+func (g *gatherer) component(dir string, c meta.Component, namespace string) {
+	names, err := verify.GetComponentObjectNames(c)
+	if err != nil {
+		g.fail(dir, err)
+		return
+	}
+
+	k := kubectl.New().Namespace(namespace)
+	for _, n := range names {
+		prefix := fmt.Sprintf("%s/%s-%s", dir, c.Kind, n)
+		g.run(prefix+".describe.txt", k, "describe", c.Kind, n)
+		g.run(prefix+".yaml", k, "get", c.Kind, n, "-o", "yaml")
+
+		if !isPodKind(c.Kind) {
+			continue
+		}
+
+		logs, err := kubectl.GetPodLogs(k, n, c.Container, 0, false)
+		if err != nil {
+			g.fail(prefix+".log", err)
+		} else {
+			g.add(prefix+".log", logs.Output)
+		}
+
+		// a pod whose containers never restarted has no previous logs
+		logs, err = kubectl.GetPodLogs(k, n, c.Container, 0, true)
+		if err == nil {
+			g.add(prefix+".previous.log", logs.Output)
+		}
+	}
+}
+
+// isPodKind flags if the kind refers to pods. This is unlike util.IsPod that
+// considers the workloads of pods as well.
+func isPodKind(kind string) bool {
+	switch kind {
+	case "po", "pod", "pods":
+		return true
+	default:
+		return false
+	}
+}
+
+// events gathers the events of the namespace
+func (g *gatherer) events(namespace string) {
+	name := fmt.Sprintf("events/%s.txt", namespace)
+	events, err := kubectl.GetEvents(kubectl.New().Namespace(namespace), "", 0)
+	if err != nil {
+		g.fail(name, err)
+		return
+	}
+
+	var lines []string
+	for _, e := range events {
+		lines = append(lines, fmt.Sprintf("%s %s", e.Time().UTC().Format(time.RFC3339), e))
+	}
+	g.add(name, strings.Join(lines, "\n")+"\n")
+}
+
+// nodes gathers the conditions, describe output & yaml dump of the nodes
+func (g *gatherer) nodes() {
+	k := kubectl.New().ClusterScoped()
+	nodes, err := k.Client().ListNodes()
+	if err != nil {
+		g.fail("nodes/conditions.txt", err)
+	} else {
+		var lines []string
+		for _, n := range nodes {
+			for _, c := range n.Status.Conditions {
+				lines = append(lines, strings.TrimSpace(fmt.Sprintf("%s %s=%s %s %s", n.Metadata.Name, c.Type, c.Status, c.Reason, c.Message)))
+			}
+			if n.Spec.Unschedulable {
+				lines = append(lines, fmt.Sprintf("%s SchedulingDisabled", n.Metadata.Name))
+			}
+		}
+		g.add("nodes/conditions.txt", strings.Join(lines, "\n")+"\n")
+	}
+
+	g.run("nodes/describe.txt", k, "describe", "nodes")
+	g.run("nodes/nodes.yaml", k, "get", "nodes", "-o", "yaml")
+}
+
+// tarball returns the gzipped tarball of the files placed under the provided
+// base directory
+func tarball(base string, files []file, modTime time.Time) (data []byte, err error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, f := range files {
+		err = tw.WriteHeader(&tar.Header{
+			Name:    base + "/" + f.name,
+			Mode:    0644,
+			Size:    int64(len(f.data)),
+			ModTime: modTime,
+		})
+		if err != nil {
+			return
+		}
+		_, err = tw.Write(f.data)
+		if err != nil {
+			return
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return
+	}
+	err = gz.Close()
+	if err != nil {
+		return
+	}
+	return buf.Bytes(), nil
+}
+
+// push applies a config map with the provided name that holds the tarball.
+// The config map is retained beyond the run.
+//
+// NOTE:
+//
+//	The config map is applied to the base namespace even if an ephemeral
+//
+// namespace replaces it, since the latter is deleted at the end of the run
+func push(name, key string, data []byte) (err error) {
+	if len(data) > MaxConfigMapSize {
+		err = fmt.Errorf("tarball of '%d' bytes exceeds the config map limit of '%d' bytes", len(data), MaxConfigMapSize)
+		return
+	}
+
+	_, namespace := kubectl.EphemeralNamespace()
+	if len(namespace) == 0 {
+		namespace = kubectl.ResolveConfig(kubectl.Config{}).Namespace
+	}
+
+	cm, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    map[string]string{BundleLabel: "true", kubectl.RetainLabel: "true"},
+		},
+		"binaryData": map[string]string{key: base64.StdEncoding.EncodeToString(data)},
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = kubectl.New().PinNamespace(namespace).StdinRun([]string{"apply", "-f", "-"}, cm)
+	return
+}
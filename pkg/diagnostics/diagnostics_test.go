@@ -0,0 +1,213 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/sim"
+)
+
+const web = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: nginx
+        image: nginx
+`
+
+const install = `
+components:
+- kind: pod
+  labels: app=web
+  alias: web-pod
+- kind: deployment
+  name: web
+  alias: web-deploy
+- kind: service
+  name: web
+  alias: web-service
+`
+
+// untar returns the contents of the files of the gzipped tarball keyed by
+// their names
+func untar(t *testing.T, data []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to read tarball: expected 'no error': actual '%s'", err)
+	}
+
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read tarball: expected 'no error': actual '%s'", err)
+		}
+		b, _ := ioutil.ReadAll(tr)
+		files[h.Name] = string(b)
+	}
+	return files
+}
+
+func TestCollect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diagnostics")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := sim.NewCluster("node-1")
+	if err = c.Apply([]byte(web), "litmus"); err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+	kubectl.SetDefaultExecutor(c)
+	defer kubectl.SetDefaultExecutor(nil)
+
+	pods, err := kubectl.GetRunningPods(kubectl.New().Labels("app=web"))
+	if err != nil || len(pods) != 1 {
+		t.Fatalf("failed to get running pods: expected '1 pod': actual '%v' '%v'", pods, err)
+	}
+	c.SetLogs("litmus", pods[0], "serving on :80\n")
+
+	file := filepath.Join(dir, "web.yaml")
+	if err = ioutil.WriteFile(file, []byte(install), 0644); err != nil {
+		t.Fatalf("failed to write install file: expected 'no error': actual '%s'", err)
+	}
+	if _, err = meta.Load(meta.InstallFile(file)); err != nil {
+		t.Fatalf("failed to load install file: expected 'no error': actual '%s'", err)
+	}
+
+	collector := NewCollector(filepath.Join(dir, "artifacts"), "litmus-diagnostics")
+	collector.now = func() time.Time { return time.Date(2018, time.June, 1, 10, 0, 0, 0, time.UTC) }
+
+	b, err := collector.Collect("step 'verify minio is redeployed' failed: boom")
+	if err != nil {
+		t.Fatalf("failed to collect: expected 'no error': actual '%s'", err)
+	}
+
+	expected := filepath.Join(dir, "artifacts", "litmus-diagnostics-20180601t100000z.tar.gz")
+	if b.Path != expected {
+		t.Fatalf("failed to collect: expected path '%s': actual '%s'", expected, b.Path)
+	}
+
+	// the service is missing & hence its describe & yaml are reported as
+	// errors
+	if len(b.Errors) != 2 || !strings.Contains(b.Errors[0], "components/web/2-web-service") {
+		t.Fatalf("failed to collect: expected 'missing service error': actual '%v'", b.Errors)
+	}
+
+	data, err := ioutil.ReadFile(b.Path)
+	if err != nil {
+		t.Fatalf("failed to read tarball: expected 'no error': actual '%s'", err)
+	}
+	files := untar(t, data)
+
+	base := "litmus-diagnostics-20180601t100000z/"
+	pod := base + "components/web/0-web-pod/pod-" + pods[0]
+	contains := map[string]string{
+		base + "reason.txt":   "verify minio is redeployed",
+		pod + ".describe.txt": "Node:         node-1",
+		pod + ".yaml":         "name: " + pods[0],
+		pod + ".log":          "serving on :80",
+		base + "components/web/1-web-deploy/deployment-web.yaml": "replicas: 1",
+		base + "events/litmus.txt":                               "Normal Scheduled pod/" + pods[0],
+		base + "nodes/conditions.txt":                            "node-1 Ready=True",
+		base + "nodes/describe.txt":                              "Name:         node-1",
+		base + "nodes/nodes.yaml":                                "name: node-1",
+		base + "errors.txt":                                      "web-service",
+	}
+	for name, expected := range contains {
+		if !strings.Contains(files[name], expected) {
+			t.Fatalf("failed to collect '%s': expected '%s': actual '%s'", name, expected, files[name])
+		}
+	}
+	if _, ok := files[pod+".previous.log"]; ok {
+		t.Fatalf("failed to collect: expected 'no previous logs': actual '%s'", files[pod+".previous.log"])
+	}
+
+	// the tarball is pushed to a config map
+	if b.ConfigMap != "litmus-diagnostics-20180601t100000z" {
+		t.Fatalf("failed to push: expected config map 'litmus-diagnostics-20180601t100000z': actual '%s'", b.ConfigMap)
+	}
+	cm, found := c.Get("ConfigMap", "litmus", b.ConfigMap)
+	if !found {
+		t.Fatalf("failed to push: expected 'config map': actual 'not found'")
+	}
+	pushed, _ := cm["binaryData"].(map[string]interface{})["litmus-diagnostics-20180601t100000z.tar.gz"].(string)
+	if pushed != base64.StdEncoding.EncodeToString(data) {
+		t.Fatalf("failed to push: expected 'tarball in config map': actual '%d' bytes", len(pushed))
+	}
+}
+
+func TestPushLimit(t *testing.T) {
+	err := push("litmus-diagnostics", "bundle.tar.gz", make([]byte, MaxConfigMapSize+1))
+	if err == nil || !strings.Contains(err.Error(), "exceeds the config map limit") {
+		t.Fatalf("failed to push: expected 'size limit error': actual '%v'", err)
+	}
+}
+
+func TestPushWithEphemeralNamespace(t *testing.T) {
+	c := sim.NewCluster("node-1")
+	kubectl.SetDefaultExecutor(c)
+	defer kubectl.SetDefaultExecutor(nil)
+
+	name, err := kubectl.CreateEphemeralNamespace(kubectl.New(), "litmus")
+	if err != nil {
+		t.Fatalf("failed to create ephemeral namespace: expected 'no error': actual '%s'", err)
+	}
+	defer kubectl.DeleteEphemeralNamespace(kubectl.New())
+
+	if err = push("litmus-diagnostics", "bundle.tar.gz", []byte("bundle")); err != nil {
+		t.Fatalf("failed to push: expected 'no error': actual '%s'", err)
+	}
+	if _, found := c.Get("ConfigMap", name, "litmus-diagnostics"); found {
+		t.Fatalf("failed to push: expected 'no config map in ephemeral namespace': actual 'config map in '%s''", name)
+	}
+
+	// the config map outlives the ephemeral namespace
+	if err = kubectl.DeleteEphemeralNamespace(kubectl.New()); err != nil {
+		t.Fatalf("failed to delete ephemeral namespace: expected 'no error': actual '%s'", err)
+	}
+	if _, found := c.Get("ConfigMap", "litmus", "litmus-diagnostics"); !found {
+		t.Fatalf("failed to push: expected 'config map in base namespace': actual 'not found'")
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// AuditOutputLimit is the default maximum number of bytes of stdout &
+	// stderr that are recorded in an audit record
+	AuditOutputLimit = 4096
+)
+
+// AuditScope identifies the part of a test run that triggered an execution
+type AuditScope struct {
+	// Feature that is being run
+	Feature string `json:"feature,omitempty"`
+	// Scenario that is being run
+	Scenario string `json:"scenario,omitempty"`
+	// Step that is being run
+	Step string `json:"step,omitempty"`
+}
+
+var (
+	// auditScope is the scope that gets recorded against every execution
+	auditScope AuditScope
+	// auditScopeMutex guards auditScope
+	auditScopeMutex sync.RWMutex
+)
+
+// SetAuditScope sets the scope that gets recorded against every execution
+// from now on
+func SetAuditScope(scope AuditScope) {
+	auditScopeMutex.Lock()
+	defer auditScopeMutex.Unlock()
+
+	auditScope = scope
+}
+
+// CurrentAuditScope returns the scope that gets recorded against every
+// execution
+func CurrentAuditScope() AuditScope {
+	auditScopeMutex.RLock()
+	defer auditScopeMutex.RUnlock()
+
+	return auditScope
+}
+
+// AuditRecord is the audit information of a single execution
+type AuditRecord struct {
+	// Time when the execution started
+	Time time.Time `json:"time"`
+	// AuditScope is the part of the test run that triggered this execution
+	AuditScope
+	// Args provided to the command
+	Args []string `json:"args"`
+	// StdinDigest is the sha256 digest of the stdin provided to the command
+	StdinDigest string `json:"stdinDigest,omitempty"`
+	// ExitCode of the command
+	ExitCode int `json:"exitCode"`
+	// DurationMs is the time taken by the command in milliseconds
+	DurationMs int64 `json:"durationMs"`
+	// Stdout is the possibly truncated output of the command
+	Stdout string `json:"stdout,omitempty"`
+	// Stderr is the possibly truncated error output of the command
+	Stderr string `json:"stderr,omitempty"`
+	// Err is the error message of a failed command
+	Err string `json:"err,omitempty"`
+}
+
+// AuditExec is an executor that appends an audit record of every execution
+// done by the wrapped executor to an audit log. The audit log has one record
+// per line in json format.
+type AuditExec struct {
+	// executor does the actual execution
+	executor AllExecutor
+	// path of the audit log
+	path string
+	// outputLimit is the maximum number of bytes of stdout & stderr that are
+	// recorded
+	outputLimit int
+	// mutex serializes writes to the audit log
+	mutex sync.Mutex
+}
+
+// NewAuditExec returns a new instance of AuditExec
+func NewAuditExec(executor AllExecutor, path string) *AuditExec {
+	return &AuditExec{
+		executor:    executor,
+		path:        path,
+		outputLimit: AuditOutputLimit,
+	}
+}
+
+// Execute executes the command via the wrapped executor & audits it
+func (e *AuditExec) Execute(args []string) (output string, err error) {
+	return e.ExecuteContext(context.Background(), args)
+}
+
+// StdinExecute executes the command via the wrapped executor & audits it
+func (e *AuditExec) StdinExecute(args []string, stdin []byte) (output string, err error) {
+	return e.StdinExecuteContext(context.Background(), args, stdin)
+}
+
+// ExecuteContext executes the command via the wrapped executor & audits it
+func (e *AuditExec) ExecuteContext(ctx context.Context, args []string) (output string, err error) {
+	start := time.Now()
+	output, err = e.executor.ExecuteContext(ctx, args)
+	e.audit(start, args, nil, output, err)
+	return
+}
+
+// StdinExecuteContext executes the command via the wrapped executor & audits
+// it
+func (e *AuditExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (output string, err error) {
+	start := time.Now()
+	output, err = e.executor.StdinExecuteContext(ctx, args, stdin)
+	e.audit(start, args, stdin, output, err)
+	return
+}
+
+// audit appends the audit record of an execution to the audit log
+//
+// NOTE:
+//  Failure to audit does not fail the execution. It is reported to stderr.
+func (e *AuditExec) audit(start time.Time, args []string, stdin []byte, output string, err error) {
+	r := AuditRecord{
+		Time:       start.UTC(),
+		AuditScope: CurrentAuditScope(),
+		Args:       args,
+		DurationMs: int64(time.Since(start) / time.Millisecond),
+		Stdout:     truncate(output, e.outputLimit),
+	}
+
+	if stdin != nil {
+		r.StdinDigest = fmt.Sprintf("sha256:%x", sha256.Sum256(stdin))
+	}
+
+	if err != nil {
+		r.ExitCode = UnknownExitCode
+		r.Err = err.Error()
+		if ee, ok := AsExecError(err); ok {
+			r.ExitCode = ee.ExitCode
+			r.Stdout = truncate(ee.Stdout, e.outputLimit)
+			r.Stderr = truncate(ee.Stderr, e.outputLimit)
+			r.Err = fmt.Sprint(ee.Err)
+		}
+	}
+
+	data, mErr := json.Marshal(r)
+	if mErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to audit cmd '%v': %s\n", args, mErr)
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	f, oErr := os.OpenFile(e.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if oErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to audit cmd '%v': %s\n", args, oErr)
+		return
+	}
+	defer f.Close()
+
+	if _, wErr := f.Write(append(data, '\n')); wErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to audit cmd '%v': %s\n", args, wErr)
+	}
+}
+
+// truncate limits the provided string to the provided number of bytes
+func truncate(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+
+	return fmt.Sprintf("%s...(truncated %d bytes)", s[:limit], len(s)-limit)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditExec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetAuditScope(AuditScope{Feature: "my feature", Scenario: "my scenario", Step: "my step"})
+	defer SetAuditScope(AuditScope{})
+
+	path := filepath.Join(dir, "audit.jsonl")
+	a := NewAuditExec(NewShellExec("sh"), path)
+	a.outputLimit = 5
+
+	a.Execute([]string{"-c", "echo hello world"})
+	a.StdinExecute([]string{"-c", "cat 1>&2; exit 4"}, []byte("oops"))
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open audit log: %s", err)
+	}
+	defer f.Close()
+
+	var records []AuditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("failed to decode audit record '%s': %s", scanner.Text(), err)
+		}
+		records = append(records, r)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("failed to audit: expected '2' records: actual '%d'", len(records))
+	}
+
+	if records[0].Feature != "my feature" || records[0].Scenario != "my scenario" || records[0].Step != "my step" {
+		t.Fatalf("failed to audit: expected scope to be recorded: actual '%#v'", records[0].AuditScope)
+	}
+
+	if records[0].ExitCode != 0 || !strings.HasPrefix(records[0].Stdout, "hello...(truncated") {
+		t.Fatalf("failed to audit: expected truncated output & zero exit code: actual '%#v'", records[0])
+	}
+
+	if len(records[0].StdinDigest) != 0 {
+		t.Fatalf("failed to audit: expected no stdin digest: actual '%s'", records[0].StdinDigest)
+	}
+
+	if records[1].ExitCode != 4 || records[1].Stderr != "oops" || !strings.HasPrefix(records[1].StdinDigest, "sha256:") {
+		t.Fatalf("failed to audit: expected exit code, stderr & stdin digest: actual '%#v'", records[1])
+	}
+}
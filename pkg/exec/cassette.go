@@ -0,0 +1,407 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Interaction is a single recorded execution of a command
+type Interaction struct {
+	// Args provided to the command
+	Args []string `json:"args"`
+	// Stdin provided to the command
+	Stdin string `json:"stdin,omitempty"`
+	// Stdout is the output of the command
+	Stdout string `json:"stdout,omitempty"`
+	// Stderr is the error output of the command
+	Stderr string `json:"stderr,omitempty"`
+	// ExitCode of the command
+	ExitCode int `json:"exitCode"`
+	// Err is the error message of a failed command
+	Err string `json:"err,omitempty"`
+}
+
+// newInteraction builds an interaction from the result of an execution
+func newInteraction(args []string, stdin []byte, output string, err error) Interaction {
+	i := Interaction{
+		Args:   args,
+		Stdin:  string(stdin),
+		Stdout: output,
+	}
+
+	if err == nil {
+		return i
+	}
+
+	i.ExitCode = UnknownExitCode
+	i.Err = err.Error()
+	if e, ok := AsExecError(err); ok {
+		i.Stdout = e.Stdout
+		i.Stderr = e.Stderr
+		i.ExitCode = e.ExitCode
+		i.Err = fmt.Sprint(e.Err)
+	}
+	return i
+}
+
+// result returns the output or error that was recorded in this interaction
+func (i Interaction) result() (output string, err error) {
+	if len(i.Err) == 0 {
+		output = i.Stdout
+		return
+	}
+
+	var cause error
+	switch i.Err {
+	case context.DeadlineExceeded.Error():
+		cause = context.DeadlineExceeded
+	case context.Canceled.Error():
+		cause = context.Canceled
+	default:
+		cause = errors.New(i.Err)
+	}
+
+	err = &ExecError{
+		Args:     i.Args,
+		ExitCode: i.ExitCode,
+		Stdout:   i.Stdout,
+		Stderr:   i.Stderr,
+		Err:      cause,
+	}
+	return
+}
+
+// Substitution replaces a value that differs between runs e.g. a generated id
+// with a placeholder in the recorded interactions. This lets a cassette be
+// replayed by a run that generates different values.
+type Substitution struct {
+	// Placeholder is recorded in place of the value
+	Placeholder string
+	// Value returns the current value. It is resolved at every execution
+	// since it may change during a run; an empty value is not substituted.
+	Value func() string
+}
+
+// substitutions are applied in the order they are set
+type substitutions []Substitution
+
+// normalize replaces the current values with their placeholders
+func (s substitutions) normalize(text string) string {
+	for _, sub := range s {
+		if v := sub.Value(); len(v) != 0 {
+			text = strings.Replace(text, v, sub.Placeholder, -1)
+		}
+	}
+	return text
+}
+
+// denormalize replaces the placeholders with their current values
+func (s substitutions) denormalize(text string) string {
+	for _, sub := range s {
+		if v := sub.Value(); len(v) != 0 {
+			text = strings.Replace(text, sub.Placeholder, v, -1)
+		}
+	}
+	return text
+}
+
+// normalizeArgs returns a copy of the args with the current values replaced
+// by their placeholders
+func (s substitutions) normalizeArgs(args []string) []string {
+	if len(s) == 0 || args == nil {
+		return args
+	}
+	normalized := make([]string, len(args))
+	for i, a := range args {
+		normalized[i] = s.normalize(a)
+	}
+	return normalized
+}
+
+// substitute returns a copy of this interaction with every recorded text
+// converted by the provided function
+func (i Interaction) substitute(convert func(string) string) Interaction {
+	if i.Args != nil {
+		args := make([]string, len(i.Args))
+		for idx, a := range i.Args {
+			args[idx] = convert(a)
+		}
+		i.Args = args
+	}
+	i.Stdin = convert(i.Stdin)
+	i.Stdout = convert(i.Stdout)
+	i.Stderr = convert(i.Stderr)
+	i.Err = convert(i.Err)
+	return i
+}
+
+// matches flags if this interaction was recorded for the provided args & stdin
+func (i Interaction) matches(args []string, stdin []byte) bool {
+	if len(i.Args) == 0 && len(args) == 0 {
+		return i.Stdin == string(stdin)
+	}
+
+	return reflect.DeepEqual(i.Args, args) && i.Stdin == string(stdin)
+}
+
+// LoadCassette loads the interactions recorded in the provided cassette file.
+// A cassette file has one interaction per line in json format.
+func LoadCassette(path string) (interactions []Interaction, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// recorded outputs can be much larger than the default token size
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var i Interaction
+		err = json.Unmarshal(scanner.Bytes(), &i)
+		if err != nil {
+			err = fmt.Errorf("failed to load cassette '%s': invalid interaction at line '%d': %s", path, line, err)
+			return
+		}
+		interactions = append(interactions, i)
+	}
+
+	err = scanner.Err()
+	return
+}
+
+// RecordExec is an executor that records every execution done by the wrapped
+// executor into a cassette file. These recordings can be replayed later via
+// ReplayExec.
+//
+// NOTE:
+//  Interactions are appended to the cassette file. Remove an existing cassette
+// file to start a fresh recording.
+type RecordExec struct {
+	// executor does the actual execution
+	executor AllExecutor
+	// path of the cassette file
+	path string
+	// substitutions are applied to the interactions before recording
+	substitutions substitutions
+	// mutex serializes writes to the cassette file
+	mutex sync.Mutex
+}
+
+// NewRecordExec returns a new instance of RecordExec
+func NewRecordExec(executor AllExecutor, path string) *RecordExec {
+	return &RecordExec{
+		executor: executor,
+		path:     path,
+	}
+}
+
+// Substitute records the placeholders of the provided substitutions in place
+// of their values & returns this instance
+func (e *RecordExec) Substitute(subs ...Substitution) *RecordExec {
+	e.substitutions = subs
+	return e
+}
+
+// Execute executes the command via the wrapped executor & records the result
+func (e *RecordExec) Execute(args []string) (output string, err error) {
+	return e.ExecuteContext(context.Background(), args)
+}
+
+// StdinExecute executes the command via the wrapped executor & records the
+// result
+func (e *RecordExec) StdinExecute(args []string, stdin []byte) (output string, err error) {
+	return e.StdinExecuteContext(context.Background(), args, stdin)
+}
+
+// ExecuteContext executes the command via the wrapped executor & records the
+// result
+func (e *RecordExec) ExecuteContext(ctx context.Context, args []string) (output string, err error) {
+	output, err = e.executor.ExecuteContext(ctx, args)
+	return output, e.record(newInteraction(args, nil, output, err), err)
+}
+
+// StdinExecuteContext executes the command via the wrapped executor & records
+// the result
+func (e *RecordExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (output string, err error) {
+	output, err = e.executor.StdinExecuteContext(ctx, args, stdin)
+	return output, e.record(newInteraction(args, stdin, output, err), err)
+}
+
+// record appends the interaction to the cassette file. It returns the
+// execution error if any or else the error during recording.
+func (e *RecordExec) record(i Interaction, execErr error) error {
+	data, err := json.Marshal(i.substitute(e.substitutions.normalize))
+	if err != nil {
+		return fmt.Errorf("failed to record interaction '%v': %s", i.Args, err)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	f, err := os.OpenFile(e.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to record interaction '%v': %s", i.Args, err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to record interaction '%v': %s", i.Args, err)
+	}
+
+	return execErr
+}
+
+// ReplayMode determines how recorded interactions are matched against the
+// executions during a replay
+type ReplayMode string
+
+const (
+	// ReplayInOrderMode serves the recorded interactions in the order they
+	// were recorded. An execution that does not match the next interaction
+	// results in an error.
+	ReplayInOrderMode ReplayMode = "replay"
+	// ReplayByArgsMode serves the first unused recorded interaction that
+	// matches the execution's args & stdin irrespective of its order
+	ReplayByArgsMode ReplayMode = "replay-by-args"
+)
+
+// ReplayExec is an executor that serves previously recorded interactions
+// instead of executing any command. It fails on any execution that was not
+// recorded.
+type ReplayExec struct {
+	// mode decides how interactions are matched against executions
+	mode ReplayMode
+	// interactions that were recorded
+	interactions []Interaction
+	// used flags the interactions that have been served
+	used []bool
+	// next is the index of the next interaction to be served in order
+	next int
+	// substitutions are applied to the executions before matching & to the
+	// served interactions
+	substitutions substitutions
+	// mutex serializes the access to interactions
+	mutex sync.Mutex
+}
+
+// NewReplayExec returns a new instance of ReplayExec
+func NewReplayExec(interactions []Interaction, mode ReplayMode) *ReplayExec {
+	return &ReplayExec{
+		mode:         mode,
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}
+}
+
+// Substitute serves the recorded placeholders of the provided substitutions
+// as their current values & returns this instance
+func (e *ReplayExec) Substitute(subs ...Substitution) *ReplayExec {
+	e.substitutions = subs
+	return e
+}
+
+// Execute serves the recorded interaction for the provided args
+func (e *ReplayExec) Execute(args []string) (output string, err error) {
+	return e.replay(args, nil)
+}
+
+// StdinExecute serves the recorded interaction for the provided args & stdin
+func (e *ReplayExec) StdinExecute(args []string, stdin []byte) (output string, err error) {
+	return e.replay(args, stdin)
+}
+
+// ExecuteContext serves the recorded interaction for the provided args
+func (e *ReplayExec) ExecuteContext(ctx context.Context, args []string) (output string, err error) {
+	return e.replay(args, nil)
+}
+
+// StdinExecuteContext serves the recorded interaction for the provided args &
+// stdin
+func (e *ReplayExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (output string, err error) {
+	return e.replay(args, stdin)
+}
+
+// Unused returns the recorded interactions that were never served
+func (e *ReplayExec) Unused() (unused []Interaction) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for idx, i := range e.interactions {
+		if !e.used[idx] {
+			unused = append(unused, i)
+		}
+	}
+	return
+}
+
+// replay serves the recorded interaction that matches the provided args &
+// stdin
+func (e *ReplayExec) replay(args []string, stdin []byte) (output string, err error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	actual := args
+	args = e.substitutions.normalizeArgs(args)
+	if stdin != nil {
+		stdin = []byte(e.substitutions.normalize(string(stdin)))
+	}
+
+	if e.mode == ReplayByArgsMode {
+		for idx, i := range e.interactions {
+			if !e.used[idx] && i.matches(args, stdin) {
+				e.used[idx] = true
+				return i.substitute(e.substitutions.denormalize).result()
+			}
+		}
+
+		err = fmt.Errorf("unexpected call during replay: no recorded interaction matches args '%v'", actual)
+		return
+	}
+
+	if e.next >= len(e.interactions) {
+		err = fmt.Errorf("unexpected call during replay: all '%d' recorded interactions are used: args '%v'", len(e.interactions), actual)
+		return
+	}
+
+	i := e.interactions[e.next]
+	if !i.matches(args, stdin) {
+		err = fmt.Errorf("unexpected call during replay: expected args '%v' at position '%d': actual args '%v'", i.Args, e.next, args)
+		return
+	}
+
+	e.used[e.next] = true
+	e.next++
+	return i.substitute(e.substitutions.denormalize).result()
+}
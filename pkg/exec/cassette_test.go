@@ -0,0 +1,156 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cassette := filepath.Join(dir, "cassette.jsonl")
+	recorder := NewRecordExec(NewShellExec("sh"), cassette)
+
+	// record a successful & a failed execution
+	op, err := recorder.Execute([]string{"-c", "echo hello"})
+	if err != nil || op != "hello" {
+		t.Fatalf("failed to record: expected output 'hello': actual output '%s': error '%v'", op, err)
+	}
+
+	_, err = recorder.StdinExecute([]string{"-c", "cat 1>&2; exit 2"}, []byte("oops"))
+	if err == nil {
+		t.Fatalf("failed to record: expected 'error': actual 'no error'")
+	}
+
+	interactions, err := LoadCassette(cassette)
+	if err != nil {
+		t.Fatalf("failed to load cassette: %s", err)
+	}
+
+	if len(interactions) != 2 {
+		t.Fatalf("failed to load cassette: expected '2' interactions: actual '%d'", len(interactions))
+	}
+
+	tests := map[string]struct {
+		mode ReplayMode
+	}{
+		"replay - in order": {
+			mode: ReplayInOrderMode,
+		},
+		"replay - by args": {
+			mode: ReplayByArgsMode,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			replayer := NewReplayExec(interactions, mock.mode)
+
+			op, err := replayer.Execute([]string{"-c", "echo hello"})
+			if err != nil || op != "hello" {
+				t.Fatalf("failed to replay: expected output 'hello': actual output '%s': error '%v'", op, err)
+			}
+
+			_, err = replayer.StdinExecute([]string{"-c", "cat 1>&2; exit 2"}, []byte("oops"))
+			e, ok := AsExecError(err)
+			if !ok {
+				t.Fatalf("failed to replay: expected 'exec error': actual '%#v'", err)
+			}
+
+			if e.ExitCode != 2 || e.Stderr != "oops" {
+				t.Fatalf("failed to replay: expected exit code '2' & stderr 'oops': actual '%d' & '%s'", e.ExitCode, e.Stderr)
+			}
+
+			if len(replayer.Unused()) != 0 {
+				t.Fatalf("failed to replay: expected no unused interactions: actual '%v'", replayer.Unused())
+			}
+
+			// any further call was never recorded
+			_, err = replayer.Execute([]string{"-c", "echo hello"})
+			if err == nil {
+				t.Fatalf("failed to replay: expected 'error' for an unexpected call: actual 'no error'")
+			}
+		})
+	}
+}
+
+func TestReplayInOrderMismatch(t *testing.T) {
+	replayer := NewReplayExec([]Interaction{
+		{Args: []string{"get", "pods"}, Stdout: "first"},
+		{Args: []string{"get", "nodes"}, Stdout: "second"},
+	}, ReplayInOrderMode)
+
+	_, err := replayer.Execute([]string{"get", "nodes"})
+	if err == nil {
+		t.Fatalf("failed to replay in order: expected 'error' for out of order call: actual 'no error'")
+	}
+
+	replayer = NewReplayExec([]Interaction{
+		{Args: []string{"get", "pods"}, Stdout: "first"},
+		{Args: []string{"get", "nodes"}, Stdout: "second"},
+	}, ReplayByArgsMode)
+
+	op, err := replayer.Execute([]string{"get", "nodes"})
+	if err != nil || op != "second" {
+		t.Fatalf("failed to replay by args: expected output 'second': actual output '%s': error '%v'", op, err)
+	}
+
+	if len(replayer.Unused()) != 1 {
+		t.Fatalf("failed to replay by args: expected '1' unused interaction: actual '%d'", len(replayer.Unused()))
+	}
+}
+
+func TestRecordReplaySubstitutions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	namespace := "litmus-1a2b3c4d"
+	subs := []Substitution{{Placeholder: "<namespace>", Value: func() string { return namespace }}}
+
+	cassette := filepath.Join(dir, "cassette.jsonl")
+	recorder := NewRecordExec(NewShellExec("sh"), cassette).Substitute(subs...)
+	if _, err = recorder.Execute([]string{"-c", "echo namespace/" + namespace + " created"}); err != nil {
+		t.Fatalf("failed to record: expected 'no error': actual '%s'", err)
+	}
+
+	interactions, err := LoadCassette(cassette)
+	if err != nil || len(interactions) != 1 {
+		t.Fatalf("failed to load cassette: expected '1' interaction: actual '%d': error '%v'", len(interactions), err)
+	}
+	if i := interactions[0]; i.Args[1] != "echo namespace/<namespace> created" || i.Stdout != "namespace/<namespace> created" {
+		t.Fatalf("failed to record: expected 'placeholders': actual '%+v'", i)
+	}
+
+	// a later run replays the cassette with a namespace of its own
+	namespace = "litmus-5e6f7a8b"
+	replayer := NewReplayExec(interactions, ReplayInOrderMode).Substitute(subs...)
+	op, err := replayer.Execute([]string{"-c", "echo namespace/" + namespace + " created"})
+	if err != nil || op != "namespace/"+namespace+" created" {
+		t.Fatalf("failed to replay: expected output 'namespace/%s created': actual output '%s': error '%v'", namespace, op, err)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// MutatingFunc flags if an execution with the provided args will change the
+// state of the target system
+type MutatingFunc func(args []string) bool
+
+// StubFunc returns the output of a read only execution with the provided args
+// that is stubbed
+type StubFunc func(args []string) string
+
+// DryRunExec is an executor that does not execute any mutating command.
+// Mutating commands are printed as a plan instead. Read only commands are
+// either passed through to the wrapped executor or stubbed.
+type DryRunExec struct {
+	// executor executes the read only commands; nil implies read only
+	// commands are stubbed with empty output
+	executor AllExecutor
+	// binary is the name of the command used while printing the plan
+	binary string
+	// isMutating classifies the mutating commands
+	isMutating MutatingFunc
+	// stub returns the output of the stubbed read only commands; nil implies
+	// empty output
+	stub StubFunc
+	// out is where the plan gets printed
+	out io.Writer
+	// plan is the list of mutating commands that were skipped
+	plan []string
+	// mutex guards the plan
+	mutex sync.Mutex
+}
+
+// NewDryRunExec returns a new instance of DryRunExec. Read only commands are
+// stubbed if the provided executor is nil.
+func NewDryRunExec(executor AllExecutor, binary string, isMutating MutatingFunc, out io.Writer) *DryRunExec {
+	return &DryRunExec{
+		executor:   executor,
+		binary:     binary,
+		isMutating: isMutating,
+		out:        out,
+	}
+}
+
+// Stub sets the output of the stubbed read only commands & returns this
+// instance. This is useful when the stubbed output is decoded e.g. json.
+func (e *DryRunExec) Stub(stub StubFunc) *DryRunExec {
+	e.stub = stub
+	return e
+}
+
+// stubbed returns the output of the stubbed read only command
+func (e *DryRunExec) stubbed(args []string) string {
+	if e.stub == nil {
+		return ""
+	}
+	return e.stub(args)
+}
+
+// Execute plans the command if it is mutating or else executes it
+func (e *DryRunExec) Execute(args []string) (output string, err error) {
+	return e.ExecuteContext(context.Background(), args)
+}
+
+// StdinExecute plans the command if it is mutating or else executes it
+func (e *DryRunExec) StdinExecute(args []string, stdin []byte) (output string, err error) {
+	return e.StdinExecuteContext(context.Background(), args, stdin)
+}
+
+// ExecuteContext plans the command if it is mutating or else executes it
+func (e *DryRunExec) ExecuteContext(ctx context.Context, args []string) (output string, err error) {
+	if e.isMutating(args) {
+		e.record(args, nil)
+		return
+	}
+
+	if e.executor == nil {
+		return e.stubbed(args), nil
+	}
+	return e.executor.ExecuteContext(ctx, args)
+}
+
+// StdinExecuteContext plans the command if it is mutating or else executes it
+func (e *DryRunExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (output string, err error) {
+	if e.isMutating(args) {
+		e.record(args, stdin)
+		return
+	}
+
+	if e.executor == nil {
+		return e.stubbed(args), nil
+	}
+	return e.executor.StdinExecuteContext(ctx, args, stdin)
+}
+
+// Plan returns the mutating commands that were planned so far
+func (e *DryRunExec) Plan() []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return append([]string{}, e.plan...)
+}
+
+// record adds the mutating command to the plan & prints it
+func (e *DryRunExec) record(args []string, stdin []byte) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	cmd := strings.TrimSpace(e.binary + " " + strings.Join(args, " "))
+	e.plan = append(e.plan, cmd)
+
+	if e.out == nil {
+		return
+	}
+
+	fmt.Fprintf(e.out, "[dry-run] step %d: %s\n", len(e.plan), cmd)
+	if len(stdin) != 0 {
+		for _, line := range strings.Split(strings.TrimRight(string(stdin), "\n"), "\n") {
+			fmt.Fprintf(e.out, "[dry-run]   | %s\n", line)
+		}
+	}
+}
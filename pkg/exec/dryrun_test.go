@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func isMutatingMock(args []string) bool {
+	return len(args) != 0 && (args[0] == "apply" || args[0] == "delete")
+}
+
+func TestDryRunExec(t *testing.T) {
+	tests := map[string]struct {
+		executor AllExecutor
+		output   string
+	}{
+		"dry run - read only commands are passed through": {
+			executor: NewShellExec("echo"),
+			output:   "get pods",
+		},
+		"dry run - read only commands are stubbed": {
+			executor: nil,
+			output:   "",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			d := NewDryRunExec(mock.executor, "kubectl", isMutatingMock, &out)
+
+			op, err := d.Execute([]string{"get", "pods"})
+			if err != nil {
+				t.Fatalf("failed to dry run: expected 'no error': actual '%s'", err)
+			}
+
+			if op != mock.output {
+				t.Fatalf("failed to dry run: expected output '%s': actual output '%s'", mock.output, op)
+			}
+
+			_, err = d.Execute([]string{"delete", "pods", "my-pod"})
+			if err != nil {
+				t.Fatalf("failed to dry run: expected 'no error': actual '%s'", err)
+			}
+
+			_, err = d.StdinExecute([]string{"apply", "-f", "-"}, []byte("kind: Pod\n"))
+			if err != nil {
+				t.Fatalf("failed to dry run: expected 'no error': actual '%s'", err)
+			}
+
+			plan := d.Plan()
+			if len(plan) != 2 || plan[0] != "kubectl delete pods my-pod" || plan[1] != "kubectl apply -f -" {
+				t.Fatalf("failed to dry run: expected plan of delete & apply: actual '%v'", plan)
+			}
+
+			if !strings.Contains(out.String(), "[dry-run] step 2: kubectl apply -f -") || !strings.Contains(out.String(), "| kind: Pod") {
+				t.Fatalf("failed to dry run: expected printed plan: actual '%s'", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	osexec "os/exec"
+	"syscall"
+	"time"
+)
+
+const (
+	// UnknownExitCode is set as the exit code of a command that could not be
+	// started or that did not exit on its own e.g. it was killed
+	UnknownExitCode = -1
+)
+
+// ExecError represents a failed execution of a command. It captures the
+// details required to classify the failure.
+type ExecError struct {
+	// Args is the command that was executed along with its arguments
+	Args []string
+	// ExitCode of the executed command
+	ExitCode int
+	// Stdout is the standard output of the executed command
+	Stdout string
+	// Stderr is the standard error of the executed command
+	Stderr string
+	// Duration is the time taken by the command before it failed
+	Duration time.Duration
+	// Err is the underlying error that caused the failure
+	Err error
+}
+
+// Error returns the string representation of this error
+func (e *ExecError) Error() string {
+	return fmt.Sprintf("failed to run cmd '%s': %s: %s", e.Args, fmt.Sprint(e.Err), e.Stderr)
+}
+
+// IsTimedOut flags if the command was killed since it did not complete before
+// its deadline
+func (e *ExecError) IsTimedOut() bool {
+	return e.Err == context.DeadlineExceeded
+}
+
+// IsCancelled flags if the command was killed since its context got
+// cancelled
+func (e *ExecError) IsCancelled() bool {
+	return e.Err == context.Canceled
+}
+
+// AsExecError returns the provided error as an ExecError if possible
+func AsExecError(err error) (e *ExecError, ok bool) {
+	e, ok = err.(*ExecError)
+	return
+}
+
+// exitCode extracts the exit code from the provided command execution error
+func exitCode(err error) int {
+	exitErr, ok := err.(*osexec.ExitError)
+	if !ok {
+		return UnknownExitCode
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || status.Signaled() {
+		return UnknownExitCode
+	}
+
+	return status.ExitStatus()
+}
@@ -18,9 +18,10 @@ package exec
 
 import (
 	"bytes"
-	"fmt"
+	"context"
 	osexec "os/exec"
 	"strings"
+	"time"
 )
 
 // Executor acts as a contract for various execution based logic
@@ -33,10 +34,24 @@ type StdinExecutor interface {
 	StdinExecute(args []string, stdin []byte) (output string, err error)
 }
 
+// ContextExecutor acts as a contract for execution logic that can be
+// cancelled or timed out via the provided context
+type ContextExecutor interface {
+	ExecuteContext(ctx context.Context, args []string) (output string, err error)
+}
+
+// StdinContextExecutor acts as a contract for stdin based execution logic
+// that can be cancelled or timed out via the provided context
+type StdinContextExecutor interface {
+	StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (output string, err error)
+}
+
 // AllExecutor provides contracts for various execution approaches
 type AllExecutor interface {
 	Executor
 	StdinExecutor
+	ContextExecutor
+	StdinContextExecutor
 }
 
 // ShellExec is a shell based struct that implements Executor interface
@@ -55,48 +70,104 @@ func NewShellExec(binary string) *ShellExec {
 // Execute executes the shell command with the provided args and returns the
 // output or error
 func (e *ShellExec) Execute(args []string) (output string, err error) {
+	return e.ExecuteContext(context.Background(), args)
+}
+
+// StdinExecute executes the shell command with the provided args and stdin &
+// returns the output or error
+func (e *ShellExec) StdinExecute(args []string, stdin []byte) (output string, err error) {
+	return e.StdinExecuteContext(context.Background(), args, stdin)
+}
+
+// ExecuteContext executes the shell command with the provided args and returns
+// the output or error. The command along with any processes started by it is
+// killed if the context gets cancelled or times out before its completion.
+func (e *ShellExec) ExecuteContext(ctx context.Context, args []string) (output string, err error) {
+	return e.run(ctx, args, nil)
+}
+
+// StdinExecuteContext executes the shell command with the provided args and
+// stdin & returns the output or error. The command along with any processes
+// started by it is killed if the context gets cancelled or times out before
+// its completion.
+func (e *ShellExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (output string, err error) {
+	return e.run(ctx, args, stdin)
+}
+
+// run executes the shell command till its completion or till the context is
+// done whichever happens first
+func (e *ShellExec) run(ctx context.Context, args []string, stdin []byte) (output string, err error) {
 	var out bytes.Buffer
 	var stderr bytes.Buffer
 
 	cmd := osexec.Command(e.binary, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewBuffer(stdin)
+	}
 	cmd.Stdout = &out
 	cmd.Stderr = &stderr
 
-	err = cmd.Run()
+	err = runContext(ctx, cmd, &out, &stderr)
 	if err != nil {
-		err = fmt.Errorf("failed to run cmd '%s': %s: %s", cmd.Args, fmt.Sprint(err), stderr.String())
 		return
 	}
 
-	// This removes the beginning & trailing single quotes from the output
-	// It has been observed that kubectl execution results in such single quotes
-	output = strings.Trim(out.String(), "'")
-	// Remove any spaces
-	output = strings.TrimSpace(output)
+	// Remove the trailing newline & any surrounding spaces. The output is
+	// otherwise returned as is.
+	output = strings.TrimSpace(out.String())
 	return
 }
 
-// StdinExecute executes the shell command with the provided args and stdin &
-// returns the output or error
-func (e *ShellExec) StdinExecute(args []string, stdin []byte) (output string, err error) {
-	var out bytes.Buffer
-	var stderr bytes.Buffer
+// runContext starts the provided command & waits for its completion. The
+// command's process tree is killed if the context is done before the command
+// completes.
+//
+// NOTE:
+//  A failure is returned as an ExecError along with the output written so
+// far. The output of a killed command is often the only clue to why it hung.
+func runContext(ctx context.Context, cmd *osexec.Cmd, stdout, stderr *bytes.Buffer) (err error) {
+	start := time.Now()
+	failed := func(cause error) error {
+		return &ExecError{
+			Args:     cmd.Args,
+			ExitCode: exitCode(cause),
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
+			Duration: time.Since(start),
+			Err:      cause,
+		}
+	}
 
-	cmd := osexec.Command(e.binary, args...)
-	cmd.Stdin = bytes.NewBuffer(stdin)
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
+	// fail fast if the context is already done
+	if err = ctx.Err(); err != nil {
+		return failed(err)
+	}
 
-	err = cmd.Run()
+	// run the command in its own process group so that all the processes
+	// started by this command can be killed together
+	SetProcessGroup(cmd)
+
+	err = cmd.Start()
 	if err != nil {
-		err = fmt.Errorf("failed to run cmd '%s': %s: %s", cmd.Args, fmt.Sprint(err), stderr.String())
-		return
+		return failed(err)
 	}
 
-	// This removes the beginning & trailing single quotes from the output
-	// It has been observed that kubectl execution results in such single quotes
-	output = strings.Trim(out.String(), "'")
-	// Remove any spaces
-	output = strings.TrimSpace(output)
-	return
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err = <-done:
+		if err != nil {
+			return failed(err)
+		}
+		return
+	case <-ctx.Done():
+		KillProcessGroup(cmd)
+		// wait for the killed process to release its resources & for its
+		// partial output to be copied
+		<-done
+		return failed(ctx.Err())
+	}
 }
@@ -0,0 +1,166 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExecuteContext(t *testing.T) {
+	tests := map[string]struct {
+		args    []string
+		timeout time.Duration
+		output  string
+		isErr   bool
+	}{
+		"execute context - positive test case - completes before timeout": {
+			args:    []string{"-c", "echo hello"},
+			timeout: 5 * time.Second,
+			output:  "hello",
+			isErr:   false,
+		},
+		"execute context - positive test case - single quotes are retained": {
+			args:    []string{"-c", `echo "'hello'"`},
+			timeout: 5 * time.Second,
+			output:  "'hello'",
+			isErr:   false,
+		},
+		"execute context - negative test case - exceeds timeout": {
+			args:    []string{"-c", "sleep 30"},
+			timeout: 100 * time.Millisecond,
+			isErr:   true,
+		},
+		"execute context - negative test case - child process exceeds timeout": {
+			// the child keeps the output pipe open; hence the entire process
+			// tree needs to be killed for this call to return
+			args:    []string{"-c", "sleep 30 & wait"},
+			timeout: 100 * time.Millisecond,
+			isErr:   true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), mock.timeout)
+			defer cancel()
+
+			start := time.Now()
+			op, err := NewShellExec("sh").ExecuteContext(ctx, mock.args)
+
+			if err != nil && !mock.isErr {
+				t.Fatalf("failed to execute context: expected 'no error': actual '%s'", err)
+			}
+
+			if err == nil && mock.isErr {
+				t.Fatalf("failed to execute context: expected 'error': actual 'no error'")
+			}
+
+			if op != mock.output {
+				t.Fatalf("failed to execute context: expected output '%s': actual output '%s'", mock.output, op)
+			}
+
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Fatalf("failed to execute context: expected completion within timeout: actual '%s'", elapsed)
+			}
+		})
+	}
+}
+
+func TestStdinExecuteContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// a cancelled context should not start the command at all
+	cancel()
+
+	_, err := NewShellExec("cat").StdinExecuteContext(ctx, []string{}, []byte("hello"))
+	if err == nil {
+		t.Fatalf("failed to execute stdin context: expected 'error': actual 'no error'")
+	}
+
+	op, err := NewShellExec("cat").StdinExecuteContext(context.Background(), []string{}, []byte("hello"))
+	if err != nil {
+		t.Fatalf("failed to execute stdin context: expected 'no error': actual '%s'", err)
+	}
+
+	if op != "hello" {
+		t.Fatalf("failed to execute stdin context: expected output 'hello': actual output '%s'", op)
+	}
+}
+
+func TestExecError(t *testing.T) {
+	tests := map[string]struct {
+		args      []string
+		timeout   time.Duration
+		exitCode  int
+		stdout    string
+		stderr    string
+		isTimeout bool
+	}{
+		"exec error - non zero exit code": {
+			args:     []string{"-c", "echo out; echo err 1>&2; exit 3"},
+			timeout:  5 * time.Second,
+			exitCode: 3,
+			stdout:   "out\n",
+			stderr:   "err\n",
+		},
+		"exec error - killed after timeout": {
+			args:      []string{"-c", "sleep 30"},
+			timeout:   100 * time.Millisecond,
+			exitCode:  UnknownExitCode,
+			isTimeout: true,
+		},
+		"exec error - killed after timeout retains partial output": {
+			args:      []string{"-c", "echo out; echo err 1>&2; sleep 30"},
+			timeout:   500 * time.Millisecond,
+			exitCode:  UnknownExitCode,
+			stdout:    "out\n",
+			stderr:    "err\n",
+			isTimeout: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), mock.timeout)
+			defer cancel()
+
+			_, err := NewShellExec("sh").ExecuteContext(ctx, mock.args)
+
+			e, ok := AsExecError(err)
+			if !ok {
+				t.Fatalf("failed to verify exec error: expected 'exec error': actual '%#v'", err)
+			}
+
+			if e.ExitCode != mock.exitCode {
+				t.Fatalf("failed to verify exec error: expected exit code '%d': actual '%d'", mock.exitCode, e.ExitCode)
+			}
+
+			if e.Stdout != mock.stdout || e.Stderr != mock.stderr {
+				t.Fatalf("failed to verify exec error: expected output '%s' '%s': actual '%s' '%s'", mock.stdout, mock.stderr, e.Stdout, e.Stderr)
+			}
+
+			if e.IsTimedOut() != mock.isTimeout {
+				t.Fatalf("failed to verify exec error: expected timed out '%t': actual '%t'", mock.isTimeout, e.IsTimedOut())
+			}
+
+			if len(e.Args) != len(mock.args)+1 || e.Args[0] != "sh" {
+				t.Fatalf("failed to verify exec error: expected args '%v': actual '%v'", mock.args, e.Args)
+			}
+		})
+	}
+}
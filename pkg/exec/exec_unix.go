@@ -0,0 +1,41 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	osexec "os/exec"
+	"syscall"
+)
+
+// SetProcessGroup makes the command the leader of a new process group
+func SetProcessGroup(cmd *osexec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// KillProcessGroup kills the command along with all the processes that
+// belong to its process group
+func KillProcessGroup(cmd *osexec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	// a negative pid signals the entire process group
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy decides how a failed execution is retried
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a command is executed
+	// including the first attempt
+	MaxAttempts int
+	// MaxElapsed is the maximum time spent across all the attempts; zero
+	// implies no limit
+	MaxElapsed time.Duration
+	// InitialInterval is the wait before the first retry
+	InitialInterval time.Duration
+	// MaxInterval caps the wait between two attempts
+	MaxInterval time.Duration
+	// Multiplier increases the wait after every retry
+	Multiplier float64
+	// Jitter randomizes the wait by this fraction e.g. 0.2 implies +/- 20%
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns a retry policy with exponential backoff that
+// gives up after the provided number of attempts or elapsed time
+func DefaultRetryPolicy(maxAttempts int, maxElapsed time.Duration) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     maxAttempts,
+		MaxElapsed:      maxElapsed,
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+	}
+}
+
+// RetriableFunc flags if the command with the provided args is worth a retry
+// after it failed with the provided error
+type RetriableFunc func(args []string, err error) bool
+
+// SettledFunc flags if the error of a retried command with the provided args
+// shows that an earlier attempt has made its change already e.g. a create
+// that fails since the object exists
+type SettledFunc func(args []string, err error) bool
+
+// RetryExec is an executor that retries the executions of the wrapped
+// executor if they fail with a retriable error
+type RetryExec struct {
+	// executor does the actual execution
+	executor AllExecutor
+	// policy decides the number of attempts & the wait between them
+	policy RetryPolicy
+	// isRetriable classifies the errors that should be retried
+	isRetriable RetriableFunc
+	// isSettled classifies the errors of a retry that imply success
+	isSettled SettledFunc
+	// sleep waits for the provided duration or till the context is done
+	sleep func(ctx context.Context, d time.Duration) error
+	// now returns the current time
+	now func() time.Time
+}
+
+// NewRetryExec returns a new instance of RetryExec
+func NewRetryExec(executor AllExecutor, policy RetryPolicy, isRetriable RetriableFunc) *RetryExec {
+	return &RetryExec{
+		executor:    executor,
+		policy:      policy,
+		isRetriable: isRetriable,
+		sleep:       sleep,
+		now:         time.Now,
+	}
+}
+
+// Settled sets the function that flags if the error of a retry implies that
+// an earlier attempt succeeded. Such a retry is reported as a success.
+func (e *RetryExec) Settled(isSettled SettledFunc) *RetryExec {
+	e.isSettled = isSettled
+	return e
+}
+
+// Execute executes the command & retries it on retriable errors
+func (e *RetryExec) Execute(args []string) (output string, err error) {
+	return e.ExecuteContext(context.Background(), args)
+}
+
+// StdinExecute executes the command with stdin & retries it on retriable
+// errors
+func (e *RetryExec) StdinExecute(args []string, stdin []byte) (output string, err error) {
+	return e.StdinExecuteContext(context.Background(), args, stdin)
+}
+
+// ExecuteContext executes the command & retries it on retriable errors till
+// the context is done
+func (e *RetryExec) ExecuteContext(ctx context.Context, args []string) (output string, err error) {
+	return e.retry(ctx, args, func() (string, error) {
+		return e.executor.ExecuteContext(ctx, args)
+	})
+}
+
+// StdinExecuteContext executes the command with stdin & retries it on
+// retriable errors till the context is done
+func (e *RetryExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (output string, err error) {
+	return e.retry(ctx, args, func() (string, error) {
+		return e.executor.StdinExecuteContext(ctx, args, stdin)
+	})
+}
+
+// retry invokes the provided execution till it succeeds, fails with a non
+// retriable error or the retry policy gives up. The last execution's result
+// is returned.
+//
+// NOTE:
+//  The error of a retry is dropped if it is settled by an earlier attempt
+func (e *RetryExec) retry(ctx context.Context, args []string, execute func() (string, error)) (output string, err error) {
+	start := e.now()
+	interval := e.policy.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		output, err = execute()
+		if err != nil && attempt > 1 && e.isSettled != nil && e.isSettled(args, err) {
+			log.Printf("cmd '%v' was settled by an earlier attempt: attempt '%d' failed: %s", args, attempt, err)
+			return output, nil
+		}
+		if err == nil || e.isRetriable == nil || !e.isRetriable(args, err) {
+			return
+		}
+
+		if attempt >= e.policy.MaxAttempts {
+			log.Printf("giving up cmd '%v' after '%d' attempt(s): %s", args, attempt, err)
+			return
+		}
+
+		wait := e.policy.jitter(interval)
+		if e.policy.MaxElapsed > 0 && e.now().Sub(start)+wait > e.policy.MaxElapsed {
+			log.Printf("giving up cmd '%v' after '%s': %s", args, e.now().Sub(start), err)
+			return
+		}
+
+		log.Printf("retrying cmd '%v' in '%s': attempt '%d' of '%d' failed: %s", args, wait, attempt, e.policy.MaxAttempts, err)
+		if ctxErr := e.sleep(ctx, wait); ctxErr != nil {
+			return
+		}
+
+		interval = e.policy.next(interval)
+	}
+}
+
+// next returns the wait that follows the provided wait
+func (p RetryPolicy) next(interval time.Duration) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	next := time.Duration(float64(interval) * multiplier)
+	if p.MaxInterval > 0 && next > p.MaxInterval {
+		next = p.MaxInterval
+	}
+	return next
+}
+
+// jitter randomizes the provided wait as per the policy's jitter fraction
+func (p RetryPolicy) jitter(interval time.Duration) time.Duration {
+	if p.Jitter <= 0 || interval <= 0 {
+		return interval
+	}
+
+	delta := p.Jitter * float64(interval)
+	return time.Duration(float64(interval) - delta + (rand.Float64() * 2 * delta))
+}
+
+// sleep waits for the provided duration or till the context is done whichever
+// happens first
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedExec returns the scripted errors one after the other & succeeds
+// once the script is exhausted
+type scriptedExec struct {
+	errs  []error
+	calls int
+}
+
+func (e *scriptedExec) Execute(args []string) (string, error) {
+	return e.ExecuteContext(context.Background(), args)
+}
+
+func (e *scriptedExec) StdinExecute(args []string, stdin []byte) (string, error) {
+	return e.ExecuteContext(context.Background(), args)
+}
+
+func (e *scriptedExec) ExecuteContext(ctx context.Context, args []string) (string, error) {
+	e.calls++
+	if e.calls <= len(e.errs) {
+		return "", e.errs[e.calls-1]
+	}
+	return "done", nil
+}
+
+func (e *scriptedExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (string, error) {
+	return e.ExecuteContext(ctx, args)
+}
+
+func isTransientMock(args []string, err error) bool {
+	return strings.Contains(err.Error(), "connection refused")
+}
+
+func isSettledMock(args []string, err error) bool {
+	return args[0] == "create" && strings.Contains(err.Error(), "already exists")
+}
+
+func TestRetryExec(t *testing.T) {
+	transient := fmt.Errorf("connection refused")
+	permanent := fmt.Errorf("not found")
+	exists := fmt.Errorf("already exists")
+
+	tests := map[string]struct {
+		args          []string
+		errs          []error
+		policy        RetryPolicy
+		expectedCalls int
+		isErr         bool
+	}{
+		"retry - positive test case - succeeds after transient errors": {
+			errs:          []error{transient, transient},
+			policy:        RetryPolicy{MaxAttempts: 5, InitialInterval: time.Second, Multiplier: 2},
+			expectedCalls: 3,
+			isErr:         false,
+		},
+		"retry - negative test case - non transient error is not retried": {
+			errs:          []error{permanent},
+			policy:        RetryPolicy{MaxAttempts: 5, InitialInterval: time.Second, Multiplier: 2},
+			expectedCalls: 1,
+			isErr:         true,
+		},
+		"retry - negative test case - gives up after max attempts": {
+			errs:          []error{transient, transient, transient, transient},
+			policy:        RetryPolicy{MaxAttempts: 3, InitialInterval: time.Second, Multiplier: 2},
+			expectedCalls: 3,
+			isErr:         true,
+		},
+		"retry - positive test case - retry settled by earlier attempt": {
+			args:          []string{"create", "namespace", "litmus"},
+			errs:          []error{transient, exists},
+			policy:        RetryPolicy{MaxAttempts: 5, InitialInterval: time.Second, Multiplier: 2},
+			expectedCalls: 2,
+			isErr:         false,
+		},
+		"retry - negative test case - first attempt is not settled": {
+			args:          []string{"create", "namespace", "litmus"},
+			errs:          []error{exists},
+			policy:        RetryPolicy{MaxAttempts: 5, InitialInterval: time.Second, Multiplier: 2},
+			expectedCalls: 1,
+			isErr:         true,
+		},
+		"retry - negative test case - retry of other command is not settled": {
+			args:          []string{"apply", "-f", "-"},
+			errs:          []error{transient, exists},
+			policy:        RetryPolicy{MaxAttempts: 5, InitialInterval: time.Second, Multiplier: 2},
+			expectedCalls: 2,
+			isErr:         true,
+		},
+		"retry - negative test case - gives up after max elapsed": {
+			errs:          []error{transient, transient, transient, transient},
+			policy:        RetryPolicy{MaxAttempts: 10, MaxElapsed: 4 * time.Second, InitialInterval: time.Second, Multiplier: 2},
+			expectedCalls: 3,
+			isErr:         true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			scripted := &scriptedExec{errs: mock.errs}
+			r := NewRetryExec(scripted, mock.policy, isTransientMock).Settled(isSettledMock)
+
+			// fake clock that advances only when slept
+			now := time.Now()
+			r.now = func() time.Time { return now }
+			r.sleep = func(ctx context.Context, d time.Duration) error {
+				now = now.Add(d)
+				return nil
+			}
+
+			args := mock.args
+			if len(args) == 0 {
+				args = []string{"get", "pods"}
+			}
+			op, err := r.Execute(args)
+			if err != nil && !mock.isErr {
+				t.Fatalf("failed to retry: expected 'no error': actual '%s'", err)
+			}
+
+			if err == nil && mock.isErr {
+				t.Fatalf("failed to retry: expected 'error': actual output '%s'", op)
+			}
+
+			if scripted.calls != mock.expectedCalls {
+				t.Fatalf("failed to retry: expected '%d' calls: actual '%d' calls", mock.expectedCalls, scripted.calls)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialInterval: time.Second, MaxInterval: 5 * time.Second, Multiplier: 2, Jitter: 0.5}
+
+	interval := p.InitialInterval
+	for _, expected := range []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		interval = p.next(interval)
+		if interval != expected {
+			t.Fatalf("failed to backoff: expected '%s': actual '%s'", expected, interval)
+		}
+
+		j := p.jitter(interval)
+		if j < interval/2 || j > interval+interval/2 {
+			t.Fatalf("failed to jitter: expected within +/- 50%% of '%s': actual '%s'", interval, j)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package fetch
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
 	"github.com/AmitKumarDas/elitmus/pkg/meta"
@@ -40,27 +41,38 @@ type Fetcher interface {
 // KubeResourceFetch provides methods that provides methods to fetch relevant
 // kubernetes resource properties
 type KubeResourceFetch struct {
+	// template is the install file that is rendered with the values set in
+	// the scenario before each fetch
+	template *meta.Template
 	// installation is the set of components that is installed on kubernetes
 	installation *meta.Installation
 	// kubectlFactory instance enables fetching new instance of KubeAllRunner
 	kubectlFactory kubectl.KubeFactory
 }
 
-// NewKubeResourceFetch provides a new instance of KubeResourceFetch
+// NewKubeResourceFetch provides a new instance of KubeResourceFetch. The
+// install file is rendered once a property is fetched; see meta.Template.
 func NewKubeResourceFetch(file meta.InstallFile) (*KubeResourceFetch, error) {
-	i, err := meta.Load(file)
+	t, err := meta.NewTemplate(file)
 	if err != nil {
 		return nil, err
 	}
 
 	return &KubeResourceFetch{
-		installation:   i,
+		template:       t,
 		kubectlFactory: kubectl.NewKubeFactory(),
 	}, nil
 }
 
 // Fetch fetches a specific property of the resource identified by the alias
 func (f *KubeResourceFetch) Fetch(alias string, property Property) (data []string, err error) {
+	if f.template != nil {
+		f.installation, err = f.template.Installation()
+		if err != nil {
+			return
+		}
+	}
+
 	switch property {
 	case ServiceIPProperty:
 		return f.fetchServiceIP(alias)
@@ -91,3 +103,19 @@ func (f *KubeResourceFetch) fetchServiceIP(alias string) (data []string, err err
 	data = append(data, ip)
 	return
 }
+
+// FetchValue fetches a specific property of the resource identified by the
+// alias & sets it as the value of the key. The install files rendered
+// hereafter in the scenario refer to this value as {{ .Values.<key> }}.
+//
+// NOTE:
+//  Multiple values of the property are joined by a comma
+func FetchValue(f Fetcher, alias string, property Property, key string) (err error) {
+	data, err := f.Fetch(alias, property)
+	if err != nil {
+		return
+	}
+
+	meta.SetValue(key, strings.Join(data, ","))
+	return
+}
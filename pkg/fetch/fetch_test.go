@@ -162,3 +162,54 @@ func TestFetchServiceIP(t *testing.T) {
 		})
 	}
 }
+
+// mockFetcher returns the provided data or error for any alias & property
+type mockFetcher struct {
+	data []string
+	err  error
+}
+
+func (m *mockFetcher) Fetch(alias string, property Property) ([]string, error) {
+	return m.data, m.err
+}
+
+func TestFetchValue(t *testing.T) {
+	defer meta.ResetValues()
+
+	tests := map[string]struct {
+		fetcher  Fetcher
+		expected string
+		isErr    bool
+	}{
+		"fetch value - positive test case - single value": {
+			fetcher:  &mockFetcher{data: []string{"10.0.0.1"}},
+			expected: "10.0.0.1",
+		},
+		"fetch value - positive test case - multiple values": {
+			fetcher:  &mockFetcher{data: []string{"10.0.0.1", "10.0.0.2"}},
+			expected: "10.0.0.1,10.0.0.2",
+		},
+		"fetch value - negative test case - fetch fails": {
+			fetcher: &mockFetcher{err: fmt.Errorf("no service ip")},
+			isErr:   true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			meta.ResetValues()
+
+			err := FetchValue(mock.fetcher, "coolservice", ServiceIPProperty, "ip")
+			if err != nil && !mock.isErr {
+				t.Fatalf("failed to fetch value: expected 'no error': actual '%s'", err)
+			}
+			if err == nil && mock.isErr {
+				t.Fatalf("failed to fetch value: expected 'error': actual 'no error'")
+			}
+
+			if actual := meta.CurrentVars().Values["ip"]; actual != mock.expected {
+				t.Fatalf("failed to fetch value: expected '%s': actual '%s'", mock.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+)
+
+const (
+	// DefaultTTL is the age after which the objects of a run are collected
+	// even if the run is active
+	DefaultTTL = 24 * time.Hour
+	// persistentVolumeKind is the kind used to list & delete persistent
+	// volumes
+	persistentVolumeKind = "persistentvolume"
+	// volumeReleased is the phase of a volume whose claim has been deleted
+	volumeReleased = "Released"
+)
+
+// DefaultKinds are the kinds of objects that are searched for the run labels
+// if no kinds are provided
+var DefaultKinds = []string{
+	"namespace",
+	"secret",
+	"configmap",
+	"storageclass",
+	"persistentvolumeclaim",
+	"serviceaccount",
+	"clusterrole",
+	"clusterrolebinding",
+	"role",
+	"rolebinding",
+	"service",
+	"daemonset",
+	"pod",
+	"deployment",
+	"statefulset",
+	"job",
+}
+
+// Reason is why an object is collected
+type Reason string
+
+const (
+	// ExpiredReason is set against the objects of a run older than the TTL
+	ExpiredReason Reason = "expired"
+	// InactiveReason is set against the objects of a run that is no longer
+	// active
+	InactiveReason Reason = "inactive"
+	// ReleasedReason is set against the persistent volumes that are left
+	// released by their deleted claims & that carry the run labels or are
+	// collected irrespective of these labels
+	ReleasedReason Reason = "released"
+)
+
+// Candidate is an object that is collected
+type Candidate struct {
+	kubectl.TrackedObject
+	// Test is the name of the test that created the object
+	Test string
+	// Age of the run that created the object. This is the age of the object
+	// itself for released volumes.
+	Age time.Duration
+	// Reason why the object is collected
+	Reason Reason
+}
+
+// String returns the object, run & reason of this candidate
+func (c Candidate) String() string {
+	return fmt.Sprintf("%s: run '%s' of test '%s' aged '%s': %s", c.TrackedObject, c.RunID, c.Test, c.Age, c.Reason)
+}
+
+// run is a run of litmus whose objects were found
+type run struct {
+	// start is the creation time of the oldest object of this run
+	start time.Time
+	// objects of this run
+	objects []Candidate
+}
+
+// Collector finds & deletes the objects leaked by the runs of litmus i.e.
+// the objects that carry the run labels of runs older than the TTL or of runs
+// that are no longer active. The persistent volumes that are retained beyond
+// the collected claims are collected along with these claims. The volumes
+// left released by their deleted claims are collected if they carry the run
+// labels.
+//
+// NOTE:
+//  A run is active while a pod that has not terminated either has the run id
+// as its uid or carries the run id label. Hence a run of litmus as a job is
+// active if its pod sets the run id from its uid. A run outside the cluster
+// is never active & hence the runs that are no longer active are collected
+// only if opted in.
+type Collector struct {
+	// k is the kubectl instance used to find & delete the objects
+	k *kubectl.Kubectl
+	// TTL is the age after which the objects of a run are collected
+	TTL time.Duration
+	// Kinds are the kinds of objects that are searched for the run labels
+	Kinds []string
+	// Inactive flags if the objects of runs that are no longer active are
+	// collected before the TTL
+	Inactive bool
+	// UnlabelledVolumes flags if the released persistent volumes that do not
+	// carry the run labels are collected as well e.g. the volumes of the
+	// claims that were labelled & deleted
+	UnlabelledVolumes bool
+	// now returns the current time
+	now func() time.Time
+}
+
+// NewCollector returns a new collector that uses the provided kubectl
+// instance. It collects the default kinds of objects of runs older than the
+// default TTL.
+func NewCollector(k *kubectl.Kubectl) *Collector {
+	return &Collector{
+		k:     k.ClusterScoped().Labels(""),
+		TTL:   DefaultTTL,
+		Kinds: DefaultKinds,
+		now:   time.Now,
+	}
+}
+
+// Find returns the objects that are collected sorted by their run, kind,
+// namespace & name
+func (c *Collector) Find() (candidates []Candidate, err error) {
+	var active map[string]bool
+	if c.Inactive {
+		active, err = c.activeRuns()
+		if err != nil {
+			return
+		}
+	}
+
+	runs := map[string]*run{}
+	for _, kind := range c.Kinds {
+		var objs kubectl.ObjectList
+		err = get(c.k.Labels(kubectl.RunIDLabel), &objs, kind, "--all-namespaces")
+		if err != nil {
+			err = fmt.Errorf("failed to find labelled '%s' objects: %s", kind, err)
+			return
+		}
+
+		for _, o := range objs.Items {
+			if o.Metadata.Labels[kubectl.RetainLabel] == "true" {
+				continue
+			}
+
+			id := o.Metadata.Labels[kubectl.RunIDLabel]
+			r, ok := runs[id]
+			if !ok {
+				r = &run{start: o.Metadata.CreationTimestamp}
+				runs[id] = r
+			}
+			if o.Metadata.CreationTimestamp.Before(r.start) {
+				r.start = o.Metadata.CreationTimestamp
+			}
+			r.objects = append(r.objects, Candidate{
+				TrackedObject: kubectl.TrackedObject{Kind: kind, Name: o.Metadata.Name, Namespace: o.Metadata.Namespace, RunID: id},
+				Test:          o.Metadata.Labels[kubectl.TestLabel],
+			})
+		}
+	}
+
+	now := c.now()
+	for id, r := range runs {
+		age := now.Sub(r.start)
+		reason := ExpiredReason
+		if age <= c.TTL {
+			if !c.Inactive || active[id] {
+				continue
+			}
+			reason = InactiveReason
+		}
+
+		for _, o := range r.objects {
+			o.Age, o.Reason = age, reason
+			candidates = append(candidates, o)
+		}
+	}
+
+	volumes, err := c.volumes(candidates)
+	if err != nil {
+		return
+	}
+	candidates = append(candidates, volumes...)
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.RunID != b.RunID {
+			return a.RunID < b.RunID
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return
+}
+
+// activeRuns returns the ids of the runs that are active
+func (c *Collector) activeRuns() (active map[string]bool, err error) {
+	var pods kubectl.PodList
+	err = get(c.k, &pods, "pods", "--all-namespaces")
+	if err != nil {
+		err = fmt.Errorf("failed to find active runs: %s", err)
+		return
+	}
+
+	active = map[string]bool{}
+	for _, p := range pods.Items {
+		if p.Status.Phase == kubectl.PodSucceeded || p.Status.Phase == kubectl.PodFailed {
+			continue
+		}
+		if len(p.Metadata.UID) != 0 {
+			active[p.Metadata.UID] = true
+		}
+		if id := p.Metadata.Labels[kubectl.RunIDLabel]; len(id) != 0 {
+			active[id] = true
+		}
+	}
+	return
+}
+
+// volumes returns the persistent volumes that are retained beyond the claims
+// of the provided candidates as well as the volumes left released by their
+// deleted claims. The latter are returned only if they carry the run labels
+// or if the unlabelled volumes are collected.
+func (c *Collector) volumes(candidates []Candidate) (volumes []Candidate, err error) {
+	var pvs kubectl.PersistentVolumeList
+	err = get(c.k, &pvs, persistentVolumeKind)
+	if err != nil {
+		err = fmt.Errorf("failed to find persistent volumes: %s", err)
+		return
+	}
+
+	claims := map[string]Candidate{}
+	for _, o := range candidates {
+		if o.Kind == "persistentvolumeclaim" {
+			claims[o.Namespace+"/"+o.Name] = o
+		}
+	}
+
+	now := c.now()
+	for _, pv := range pvs.Items {
+		if pv.Metadata.Labels[kubectl.RetainLabel] == "true" {
+			continue
+		}
+		volume := kubectl.TrackedObject{Kind: persistentVolumeKind, Name: pv.Metadata.Name}
+
+		if ref := pv.Spec.ClaimRef; ref != nil && pv.Spec.PersistentVolumeReclaimPolicy != "Delete" {
+			if claim, ok := claims[ref.Namespace+"/"+ref.Name]; ok {
+				volume.RunID = claim.RunID
+				volumes = append(volumes, Candidate{TrackedObject: volume, Test: claim.Test, Age: claim.Age, Reason: claim.Reason})
+				continue
+			}
+		}
+
+		id := pv.Metadata.Labels[kubectl.RunIDLabel]
+		if pv.Status.Phase != volumeReleased || (len(id) == 0 && !c.UnlabelledVolumes) {
+			continue
+		}
+		volume.RunID = id
+		volumes = append(volumes, Candidate{
+			TrackedObject: volume,
+			Test:          pv.Metadata.Labels[kubectl.TestLabel],
+			Age:           now.Sub(pv.Metadata.CreationTimestamp),
+			Reason:        ReleasedReason,
+		})
+	}
+	return
+}
+
+// get runs the kubectl get & decodes its json output into obj
+func get(k kubectl.KubeRunner, obj interface{}, args ...string) (err error) {
+	op, err := k.Run(append(append([]string{"get"}, args...), "-o", "json"))
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal([]byte(op), obj)
+	if err != nil {
+		err = fmt.Errorf("failed to decode output of 'kubectl get %v': %s", args, err)
+	}
+	return
+}
+
+// Delete deletes the candidates in the reverse order of their dependencies &
+// waits for each deletion to complete. The candidates that could not be
+// deleted are reported in the error.
+func (c *Collector) Delete(candidates []Candidate) error {
+	tracker := kubectl.NewResourceTracker()
+	for _, o := range candidates {
+		tracker.Track(o.TrackedObject)
+	}
+	return tracker.Teardown(c.k, kubectl.DefaultTeardownTimeout)
+}
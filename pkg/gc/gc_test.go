@@ -0,0 +1,261 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/sim"
+)
+
+// leaked are the objects of prior runs of which the run labelled 'old-run'
+// is still active
+const leaked = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-high-avail-minio
+  labels:
+    litmus.io/run-id: old-run
+spec:
+  containers:
+  - name: litmus
+    image: openebs/litmus
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: old-config
+  labels:
+    litmus.io/run-id: old-run
+    litmus.io/test: ha-on-minio
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: gone-config
+  labels:
+    litmus.io/run-id: gone-run
+    litmus.io/test: deploy-minio
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kept-config
+  labels:
+    litmus.io/run-id: gone-run
+    litmus.io/retain: "true"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared-config
+---
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  name: retained
+provisioner: openebs.io/provisioner-iscsi
+reclaimPolicy: Retain
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: gone-pvc
+  labels:
+    litmus.io/run-id: gone-run
+    litmus.io/test: deploy-minio
+spec:
+  storageClassName: retained
+  resources:
+    requests:
+      storage: 1G
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: minio-pvc
+spec:
+  storageClassName: retained
+  resources:
+    requests:
+      storage: 1G
+`
+
+const app = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+`
+
+// found returns the reasons of the candidates keyed by their kind & name
+func found(candidates []Candidate) map[string]Reason {
+	reasons := map[string]Reason{}
+	for _, c := range candidates {
+		reasons[c.Kind+"/"+c.Name] = c.Reason
+	}
+	return reasons
+}
+
+func TestCollector(t *testing.T) {
+	c := sim.NewCluster("node-1")
+	if err := c.Apply([]byte(leaked), "litmus"); err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+	kubectl.SetDefaultExecutor(c)
+	defer kubectl.SetDefaultExecutor(nil)
+	defer exec.SetAuditScope(exec.AuditScope{})
+
+	pvc, _ := c.Get("PersistentVolumeClaim", "litmus", "minio-pvc")
+	pv := pvc["spec"].(map[string]interface{})["volumeName"].(string)
+	pvc, _ = c.Get("PersistentVolumeClaim", "litmus", "gone-pvc")
+	gonePV := pvc["spec"].(map[string]interface{})["volumeName"].(string)
+	k := kubectl.New().Namespace("litmus")
+	if _, err := k.Run([]string{"delete", "pvc", "minio-pvc"}); err != nil {
+		t.Fatalf("failed to delete claim: expected 'no error': actual '%s'", err)
+	}
+
+	// the objects applied by this run are labelled with its run id & test
+	dir, err := ioutil.TempDir("", "gc")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.yaml")
+	if err = ioutil.WriteFile(file, []byte(app), 0644); err != nil {
+		t.Fatalf("failed to write manifest: expected 'no error': actual '%s'", err)
+	}
+
+	exec.SetAuditScope(exec.AuditScope{Feature: "Test the HA of minio"})
+	defer func() { kubectl.TeardownResources(kubectl.New()) }()
+	if _, err = k.Run([]string{"apply", "-f", file}); err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+
+	cm, _ := c.Get("ConfigMap", "litmus", "app-config")
+	labels := cm["metadata"].(map[string]interface{})["labels"]
+	expectedLabels := map[string]interface{}{
+		kubectl.RunIDLabel: kubectl.RunID(),
+		kubectl.TestLabel:  "Test-the-HA-of-minio",
+	}
+	if !reflect.DeepEqual(labels, expectedLabels) {
+		t.Fatalf("failed to label: expected '%v': actual '%v'", expectedLabels, labels)
+	}
+
+	tests := map[string]struct {
+		ttl               time.Duration
+		inactive          bool
+		unlabelledVolumes bool
+		expected          map[string]Reason
+	}{
+		"find - +ve test case - inactive runs & their volumes": {
+			ttl:      DefaultTTL,
+			inactive: true,
+			expected: map[string]Reason{
+				"configmap/gone-config":          InactiveReason,
+				"configmap/app-config":           InactiveReason,
+				"persistentvolumeclaim/gone-pvc": InactiveReason,
+				"persistentvolume/" + gonePV:     InactiveReason,
+			},
+		},
+		"find - +ve test case - expired runs": {
+			ttl: time.Hour,
+			expected: map[string]Reason{
+				"pod/test-high-avail-minio":      ExpiredReason,
+				"configmap/old-config":           ExpiredReason,
+				"configmap/gone-config":          ExpiredReason,
+				"configmap/app-config":           ExpiredReason,
+				"persistentvolumeclaim/gone-pvc": ExpiredReason,
+				"persistentvolume/" + gonePV:     ExpiredReason,
+			},
+		},
+		"find - +ve test case - active runs within ttl": {
+			ttl:      DefaultTTL,
+			expected: map[string]Reason{},
+		},
+		"find - +ve test case - unlabelled released volumes": {
+			ttl:               DefaultTTL,
+			unlabelledVolumes: true,
+			expected: map[string]Reason{
+				"persistentvolume/" + pv: ReleasedReason,
+			},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			collector := NewCollector(kubectl.New())
+			collector.now = func() time.Time { return sim.Epoch.Add(2 * time.Hour) }
+			collector.TTL = mock.ttl
+			collector.Inactive = mock.inactive
+			collector.UnlabelledVolumes = mock.unlabelledVolumes
+
+			candidates, err := collector.Find()
+			if err != nil {
+				t.Fatalf("failed to find: expected 'no error': actual '%s'", err)
+			}
+			if reasons := found(candidates); !reflect.DeepEqual(reasons, mock.expected) {
+				t.Fatalf("failed to find: expected '%v': actual '%v'", mock.expected, reasons)
+			}
+		})
+	}
+
+	// the objects are found across the namespaces
+	for _, cmd := range c.History() {
+		if strings.Contains(cmd, "--all-namespaces") && strings.Contains(cmd, "--namespace=") {
+			t.Fatalf("failed to find across namespaces: expected 'no namespace flag': actual '%s'", cmd)
+		}
+	}
+
+	collector := NewCollector(kubectl.New())
+	collector.now = func() time.Time { return sim.Epoch.Add(2 * time.Hour) }
+	collector.Inactive = true
+	collector.UnlabelledVolumes = true
+	candidates, err := collector.Find()
+	if err != nil {
+		t.Fatalf("failed to find: expected 'no error': actual '%s'", err)
+	}
+	if err = collector.Delete(candidates); err != nil {
+		t.Fatalf("failed to delete: expected 'no error': actual '%s'", err)
+	}
+
+	for _, o := range []struct{ kind, namespace, name string }{
+		{"ConfigMap", "litmus", "gone-config"},
+		{"ConfigMap", "litmus", "app-config"},
+		{"PersistentVolumeClaim", "litmus", "gone-pvc"},
+		{"PersistentVolume", "", pv},
+		{"PersistentVolume", "", gonePV},
+	} {
+		if _, ok := c.Get(o.kind, o.namespace, o.name); ok {
+			t.Fatalf("failed to delete '%s/%s': expected 'not found': actual 'found'", o.kind, o.name)
+		}
+	}
+	for _, name := range []string{"old-config", "kept-config", "shared-config"} {
+		if _, ok := c.Get("ConfigMap", "litmus", name); !ok {
+			t.Fatalf("failed to keep '%s': expected 'found': actual 'not found'", name)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/AmitKumarDas/elitmus/pkg/diagnostics"
+	"github.com/DATA-DOG/godog"
+	"github.com/DATA-DOG/godog/gherkin"
+)
+
+// Collector provides the contract to gather the diagnostics of a failure
+type Collector interface {
+	Collect(reason string) (b diagnostics.Bundle, err error)
+}
+
+// Diagnostics registers the hooks that gather a diagnostics bundle when a
+// step or a scenario fails. The bundle is configured from the environment.
+func Diagnostics(s *godog.Suite) {
+	DiagnosticsWith(s, diagnostics.NewCollectorFromEnv())
+}
+
+// DiagnosticsWith registers the hooks that gather a diagnostics bundle via
+// the provided collector when a step or a scenario fails
+//
+// NOTE:
+//  A bundle is gathered once per failed scenario i.e. at the first failed
+// step. Undefined & pending steps are not considered as failures.
+func DiagnosticsWith(s *godog.Suite, c Collector) {
+	var mutex sync.Mutex
+	var collected bool
+
+	collect := func(reason string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if collected {
+			return
+		}
+		collected = true
+
+		b, err := c.Collect(reason)
+		if err != nil {
+			log.Printf("failed to collect diagnostics of '%s': %s", reason, err)
+		}
+		if len(b.Path) != 0 {
+			log.Printf("collected diagnostics of '%s' at '%s'", reason, b.Path)
+		}
+		if len(b.ConfigMap) != 0 {
+			log.Printf("pushed diagnostics of '%s' to config map '%s'", reason, b.ConfigMap)
+		}
+	}
+
+	s.BeforeScenario(func(scenario interface{}) {
+		mutex.Lock()
+		collected = false
+		mutex.Unlock()
+	})
+
+	s.AfterStep(func(step *gherkin.Step, err error) {
+		if err == nil || err == godog.ErrUndefined || err == godog.ErrPending {
+			return
+		}
+		collect(fmt.Sprintf("step '%s' failed: %s", step.Text, err))
+	})
+
+	s.AfterScenario(func(scenario interface{}, err error) {
+		if err == nil || err == godog.ErrUndefined || err == godog.ErrPending {
+			return
+		}
+		collect(fmt.Sprintf("scenario '%s' failed: %s", scenarioName(scenario), err))
+	})
+}
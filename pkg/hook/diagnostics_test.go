@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/diagnostics"
+	"github.com/DATA-DOG/godog"
+	"github.com/DATA-DOG/godog/gherkin"
+)
+
+// fakeCollector records the reasons it was asked to collect diagnostics for
+type fakeCollector struct {
+	mutex   sync.Mutex
+	reasons []string
+}
+
+func (c *fakeCollector) Collect(reason string) (b diagnostics.Bundle, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reasons = append(c.reasons, reason)
+	return
+}
+
+func TestDiagnosticsWith(t *testing.T) {
+	tests := map[string]struct {
+		feature  string
+		expected []string
+	}{
+		"diagnostics - +ve test case - passed scenario is not collected": {
+			feature: `
+Feature: collect diagnostics
+  Scenario: pass
+    Given a step that passes
+`,
+		},
+		"diagnostics - +ve test case - once per failed scenario": {
+			feature: `
+Feature: collect diagnostics
+  Scenario: fail
+    Given a step that fails
+    And a step that fails
+
+  Scenario: fail again
+    Given a step that passes
+    And a step that fails
+`,
+			expected: []string{
+				"step 'a step that fails' failed: boom",
+				"step 'a step that fails' failed: boom",
+			},
+		},
+		"diagnostics - +ve test case - failed step hook is collected": {
+			feature: `
+Feature: collect diagnostics
+  Scenario: panic
+    Given a step that panics
+`,
+			expected: []string{"step 'a step that panics' failed: boom"},
+		},
+		"diagnostics - -ve test case - undefined step is not collected": {
+			feature: `
+Feature: collect diagnostics
+  Scenario: undefined
+    Given a step that is undefined
+`,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &fakeCollector{}
+			runFeature(t, mock.feature, func(s *godog.Suite) {
+				DiagnosticsWith(s, c)
+				s.BeforeStep(func(step *gherkin.Step) {
+					if strings.Contains(step.Text, "panics") {
+						panic("boom")
+					}
+				})
+				s.Step(`^a step that passes$`, func() error { return nil })
+				s.Step(`^a step that fails$`, func() error { return fmt.Errorf("boom") })
+				s.Step(`^a step that panics$`, func() error { return nil })
+			})
+
+			if len(c.reasons) != len(mock.expected) {
+				t.Fatalf("failed to collect diagnostics: expected '%v': actual '%v'", mock.expected, c.reasons)
+			}
+			for i, reason := range c.reasons {
+				if !strings.HasPrefix(reason, mock.expected[i]) {
+					t.Fatalf("failed to collect diagnostics: expected '%v': actual '%v'", mock.expected, c.reasons)
+				}
+			}
+		})
+	}
+}
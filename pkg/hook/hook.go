@@ -0,0 +1,80 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/verify"
+	"github.com/DATA-DOG/godog"
+	"github.com/DATA-DOG/godog/gherkin"
+)
+
+// Scope registers the hooks that track the feature, scenario & step being
+// run. This scope is recorded in the audit log against every kubectl
+// execution. The values set for the install files are reset at the start of
+// every scenario & the no-warning-events condition is bounded to the events
+// since this start.
+func Scope(s *godog.Suite) {
+	s.BeforeFeature(func(f *gherkin.Feature) {
+		exec.SetAuditScope(exec.AuditScope{
+			Feature: f.Name,
+		})
+	})
+
+	s.BeforeScenario(func(scenario interface{}) {
+		meta.ResetValues()
+		verify.SetEventsSince(time.Now())
+
+		scope := exec.CurrentAuditScope()
+		scope.Scenario = scenarioName(scenario)
+		scope.Step = ""
+		exec.SetAuditScope(scope)
+	})
+
+	s.BeforeStep(func(step *gherkin.Step) {
+		scope := exec.CurrentAuditScope()
+		scope.Step = step.Text
+		exec.SetAuditScope(scope)
+	})
+
+	s.AfterScenario(func(scenario interface{}, err error) {
+		scope := exec.CurrentAuditScope()
+		scope.Scenario = ""
+		scope.Step = ""
+		exec.SetAuditScope(scope)
+	})
+
+	s.AfterFeature(func(f *gherkin.Feature) {
+		verify.SetEventsSince(time.Time{})
+		exec.SetAuditScope(exec.AuditScope{})
+	})
+}
+
+// scenarioName returns the name of the provided scenario or scenario outline
+func scenarioName(scenario interface{}) string {
+	switch sc := scenario.(type) {
+	case *gherkin.Scenario:
+		return sc.Name
+	case *gherkin.ScenarioOutline:
+		return sc.Name
+	default:
+		return ""
+	}
+}
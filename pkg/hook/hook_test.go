@@ -0,0 +1,132 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/sim"
+	"github.com/DATA-DOG/godog"
+	"github.com/DATA-DOG/godog/gherkin"
+)
+
+// fakeExec runs the kubectl commands against the simulated cluster & records
+// their args. The commands that contain the fail args are failed without
+// being run.
+type fakeExec struct {
+	cluster *sim.Cluster
+	fail    string
+
+	mutex    sync.Mutex
+	executed []string
+}
+
+func (e *fakeExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (string, error) {
+	e.mutex.Lock()
+	e.executed = append(e.executed, strings.Join(args, " "))
+	e.mutex.Unlock()
+
+	if len(e.fail) != 0 && strings.Contains(strings.Join(args, " "), e.fail) {
+		return "", &exec.ExecError{Args: args, ExitCode: 1, Stderr: "forbidden", Err: fmt.Errorf("exit status 1")}
+	}
+	return e.cluster.StdinExecuteContext(ctx, args, stdin)
+}
+
+func (e *fakeExec) ExecuteContext(ctx context.Context, args []string) (string, error) {
+	return e.StdinExecuteContext(ctx, args, nil)
+}
+
+func (e *fakeExec) StdinExecute(args []string, stdin []byte) (string, error) {
+	return e.StdinExecuteContext(context.Background(), args, stdin)
+}
+
+func (e *fakeExec) Execute(args []string) (string, error) {
+	return e.StdinExecuteContext(context.Background(), args, nil)
+}
+
+// index returns the position of the first executed command that contains
+// the provided args; -1 if there is none
+func (e *fakeExec) index(args string) int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for i, executed := range e.executed {
+		if strings.Contains(executed, args) {
+			return i
+		}
+	}
+	return -1
+}
+
+// runFeature runs the provided feature with the steps & hooks registered by
+// the provided initializer. The status & the output of the run are returned.
+func runFeature(t *testing.T, feature string, init func(s *godog.Suite)) (status int, output string) {
+	dir, err := ioutil.TempDir("", "hook")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.feature")
+	if err = ioutil.WriteFile(path, []byte(feature), 0644); err != nil {
+		t.Fatalf("failed to write feature: expected 'no error': actual '%s'", err)
+	}
+
+	var out bytes.Buffer
+	status = godog.RunWithOptions("hook", init, godog.Options{
+		Format:   "progress",
+		Paths:    []string{path},
+		NoColors: true,
+		Output:   &out,
+	})
+	return status, out.String()
+}
+
+func TestScenarioName(t *testing.T) {
+	tests := map[string]struct {
+		scenario interface{}
+		expected string
+	}{
+		"scenario name - +ve test case - scenario": {
+			scenario: &gherkin.Scenario{ScenarioDefinition: gherkin.ScenarioDefinition{Name: "put data"}},
+			expected: "put data",
+		},
+		"scenario name - +ve test case - scenario outline": {
+			scenario: &gherkin.ScenarioOutline{ScenarioDefinition: gherkin.ScenarioDefinition{Name: "put <data>"}},
+			expected: "put <data>",
+		},
+		"scenario name - -ve test case - unknown type": {
+			scenario: "put data",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := scenarioName(mock.scenario); actual != mock.expected {
+				t.Fatalf("failed to get scenario name: expected '%s': actual '%s'", mock.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+	"github.com/DATA-DOG/godog"
+	"github.com/DATA-DOG/godog/gherkin"
+)
+
+// EphemeralNamespace registers the hooks that run every feature in a unique
+// namespace if the ephemeral namespace mode is set in the environment. This
+// namespace replaces the litmus namespace of the kubectl instances, applied
+// manifests & loaded components during the feature & is deleted after the
+// feature.
+//
+// NOTE:
+//  The steps of the feature fail if this namespace can not be created. The
+// changes made during the feature are undone before this namespace is deleted
+// irrespective of the order in which Teardown is registered; see teardown.
+func EphemeralNamespace(s *godog.Suite) {
+	if strings.ToLower(util.EphemeralNamespaceENV()) != "true" {
+		return
+	}
+
+	var failed error
+	s.BeforeFeature(func(f *gherkin.Feature) {
+		base := kubectl.ResolveConfig(kubectl.Config{}).Namespace
+		name, err := kubectl.CreateEphemeralNamespace(kubectl.New(), base)
+		if err != nil {
+			failed = fmt.Errorf("failed to isolate feature '%s': %s", f.Name, err)
+			log.Print(failed)
+			return
+		}
+		meta.SetDefaultNamespace(name, base)
+		log.Printf("running feature '%s' in namespace '%s' in place of '%s'", f.Name, name, base)
+	})
+
+	// a panic of a step hook fails the step; the remaining steps of the
+	// scenario are skipped
+	s.BeforeStep(func(step *gherkin.Step) {
+		if failed != nil {
+			panic(failed)
+		}
+	})
+
+	s.AfterFeature(func(f *gherkin.Feature) {
+		failed = nil
+		teardown(f)
+		meta.SetDefaultNamespace("", "")
+		if err := kubectl.DeleteEphemeralNamespace(kubectl.New()); err != nil {
+			log.Printf("failed to delete namespace of feature '%s': %s", f.Name, err)
+		}
+	})
+}
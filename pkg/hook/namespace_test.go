@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/sim"
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+	"github.com/DATA-DOG/godog"
+)
+
+const isolated = `
+Feature: isolate the feature
+
+  Scenario: apply a config map
+    Given I apply a config map
+`
+
+const configMap = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: isolated
+  namespace: litmus
+`
+
+func TestEphemeralNamespace(t *testing.T) {
+	envKeys := []util.ENVKey{util.EphemeralNamespaceENVK, util.KubeNamespaceENVK}
+	for _, key := range envKeys {
+		if val, ok := os.LookupEnv(string(key)); ok {
+			defer os.Setenv(string(key), val)
+		} else {
+			defer os.Unsetenv(string(key))
+		}
+	}
+	os.Setenv(string(util.EphemeralNamespaceENVK), "true")
+	os.Setenv(string(util.KubeNamespaceENVK), "litmus")
+
+	tests := map[string]struct {
+		fail      string
+		isErr     bool
+		isApplied bool
+		output    string
+	}{
+		"ephemeral namespace - +ve test case - teardown before namespace delete": {
+			isApplied: true,
+		},
+		"ephemeral namespace - -ve test case - namespace create fails the steps": {
+			fail:   "create namespace",
+			isErr:  true,
+			output: "failed to isolate feature 'isolate the feature'",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := &fakeExec{cluster: sim.NewCluster("node-1"), fail: mock.fail}
+			kubectl.SetDefaultExecutor(e)
+			defer kubectl.SetDefaultExecutor(nil)
+
+			var applied string
+			status, output := runFeature(t, isolated, func(s *godog.Suite) {
+				EphemeralNamespace(s)
+				s.Step(`^I apply a config map$`, func() error {
+					applied, _ = kubectl.EphemeralNamespace()
+					return kubectl.ApplyStdIn([]byte(configMap))
+				})
+			})
+
+			if (status != 0) != mock.isErr || !strings.Contains(output, mock.output) {
+				t.Fatalf("failed to run feature: expected 'error %t' '%s': actual 'status %d' '%s'", mock.isErr, mock.output, status, output)
+			}
+			if (len(applied) != 0) != mock.isApplied {
+				t.Fatalf("failed to run step: expected 'applied %t': actual 'applied in '%s''", mock.isApplied, applied)
+			}
+			if current, _ := kubectl.EphemeralNamespace(); len(current) != 0 {
+				t.Fatalf("failed to delete ephemeral namespace: expected 'none in use': actual '%s'", current)
+			}
+			if !mock.isApplied {
+				return
+			}
+
+			// the config map is applied to & deleted from the ephemeral
+			// namespace before this namespace is deleted
+			teardown := e.index("delete configmap isolated --ignore-not-found --namespace=" + applied)
+			deleted := e.index("delete namespace " + applied)
+			if teardown == -1 || deleted == -1 || teardown > deleted {
+				t.Fatalf("failed to teardown before namespace delete: expected 'teardown first': actual '%v'", e.executed)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"log"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/DATA-DOG/godog"
+	"github.com/DATA-DOG/godog/gherkin"
+)
+
+// Teardown registers the hook that undoes the changes made to the cluster
+// during a feature run. The nodes changed via the node helpers are restored
+// & the objects created by kubectl applies are deleted in the reverse order
+// of their dependencies. Anything that could not be undone is reported.
+func Teardown(s *godog.Suite) {
+	s.AfterFeature(teardown)
+}
+
+// teardown restores the changed nodes & deletes the tracked objects. This is
+// a no-op if these have been undone already.
+func teardown(f *gherkin.Feature) {
+	k := kubectl.New()
+	if err := kubectl.RestoreNodes(k); err != nil {
+		log.Printf("failed to restore nodes after feature '%s': %s", f.Name, err)
+	}
+	if err := kubectl.TeardownResources(k); err != nil {
+		log.Printf("failed to teardown feature '%s': %s", f.Name, err)
+	}
+}
@@ -0,0 +1,551 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/ghodss/yaml"
+)
+
+const (
+	// inClusterTokenFile is the service account token mounted in a pod
+	inClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// inClusterCAFile is the service account's CA certificate mounted in a pod
+	inClusterCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// RESTConfig holds the details required to talk to the kubernetes api server
+type RESTConfig struct {
+	// Host is the url of the api server
+	Host string
+	// BearerToken is used to authenticate against the api server
+	BearerToken string
+	// Username is used for basic authentication against the api server
+	Username string
+	// Password is used for basic authentication against the api server
+	Password string
+	// CAData is the PEM encoded CA certificate of the api server
+	CAData []byte
+	// CertData is the PEM encoded client certificate
+	CertData []byte
+	// KeyData is the PEM encoded client key
+	KeyData []byte
+	// Insecure skips the verification of the api server's certificate
+	Insecure bool
+	// Namespace is the namespace set in the kubeconfig context if any
+	Namespace string
+}
+
+// kubeConfigFile is the subset of a kubeconfig file that is understood by
+// litmus
+type kubeConfigFile struct {
+	CurrentContext string `json:"current-context"`
+	Clusters       []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthority     string `json:"certificate-authority"`
+			CertificateAuthorityData string `json:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Users []struct {
+		Name string `json:"name"`
+		User struct {
+			Token                 string      `json:"token"`
+			TokenFile             string      `json:"tokenFile"`
+			ClientCertificate     string      `json:"client-certificate"`
+			ClientCertificateData string      `json:"client-certificate-data"`
+			ClientKey             string      `json:"client-key"`
+			ClientKeyData         string      `json:"client-key-data"`
+			Username              string      `json:"username"`
+			Password              string      `json:"password"`
+			AuthProvider          interface{} `json:"auth-provider"`
+			Exec                  interface{} `json:"exec"`
+		} `json:"user"`
+	} `json:"users"`
+	Contexts []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster   string `json:"cluster"`
+			User      string `json:"user"`
+			Namespace string `json:"namespace"`
+		} `json:"context"`
+	} `json:"contexts"`
+}
+
+// LoadRESTConfig loads the api server details in following order:
+//
+// 1/ from the provided kubeconfig file & context, else
+// 2/ from the service account if running within a kubernetes pod, else
+// 3/ from the kubeconfig file set in KUBECONFIG env or $HOME/.kube/config
+func LoadRESTConfig(kubeconfig, context string) (config RESTConfig, err error) {
+	if len(kubeconfig) != 0 {
+		return loadKubeConfigFile(kubeconfig, context)
+	}
+
+	if IsInCluster() {
+		return loadInClusterConfig()
+	}
+
+	kubeconfig = strings.Split(os.Getenv("KUBECONFIG"), string(os.PathListSeparator))[0]
+	if len(kubeconfig) == 0 {
+		kubeconfig = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	return loadKubeConfigFile(kubeconfig, context)
+}
+
+// IsInCluster flags if this process runs within a kubernetes pod
+func IsInCluster() bool {
+	if len(os.Getenv("KUBERNETES_SERVICE_HOST")) == 0 {
+		return false
+	}
+
+	_, err := os.Stat(inClusterTokenFile)
+	return err == nil
+}
+
+// loadInClusterConfig loads the api server details from the pod's service
+// account
+func loadInClusterConfig() (config RESTConfig, err error) {
+	token, err := ioutil.ReadFile(inClusterTokenFile)
+	if err != nil {
+		return
+	}
+
+	ca, err := ioutil.ReadFile(inClusterCAFile)
+	if err != nil {
+		return
+	}
+
+	config = RESTConfig{
+		Host:        "https://" + os.Getenv("KUBERNETES_SERVICE_HOST") + ":" + os.Getenv("KUBERNETES_SERVICE_PORT"),
+		BearerToken: strings.TrimSpace(string(token)),
+		CAData:      ca,
+	}
+	return
+}
+
+// loadKubeConfigFile loads the api server details of the provided context
+// from the kubeconfig file. The kubeconfig's current context is used if the
+// provided context is empty.
+func loadKubeConfigFile(path, context string) (config RESTConfig, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var kc kubeConfigFile
+	err = yaml.Unmarshal(data, &kc)
+	if err != nil {
+		err = fmt.Errorf("failed to load kubeconfig '%s': %s", path, err)
+		return
+	}
+
+	if len(context) == 0 {
+		context = kc.CurrentContext
+	}
+
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == context {
+			clusterName, userName, config.Namespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+			found = true
+			break
+		}
+	}
+	if !found {
+		err = fmt.Errorf("failed to load kubeconfig '%s': context '%s' not found", path, context)
+		return
+	}
+
+	// relative file paths in a kubeconfig are relative to the kubeconfig
+	dir := filepath.Dir(path)
+
+	found = false
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+
+		found = true
+		config.Host = c.Cluster.Server
+		config.Insecure = c.Cluster.InsecureSkipTLSVerify
+		config.CAData, err = dataOrFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority, dir)
+		if err != nil {
+			return
+		}
+		break
+	}
+	if !found {
+		err = fmt.Errorf("failed to load kubeconfig '%s': cluster '%s' not found", path, clusterName)
+		return
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+
+		if u.User.AuthProvider != nil || u.User.Exec != nil {
+			err = fmt.Errorf("failed to load kubeconfig '%s': auth provider & exec plugins of user '%s' are not supported by native backend", path, userName)
+			return
+		}
+
+		config.Username, config.Password = u.User.Username, u.User.Password
+		config.BearerToken = u.User.Token
+		if len(config.BearerToken) == 0 && len(u.User.TokenFile) != 0 {
+			var token []byte
+			token, err = dataOrFile("", u.User.TokenFile, dir)
+			if err != nil {
+				return
+			}
+			config.BearerToken = strings.TrimSpace(string(token))
+		}
+
+		config.CertData, err = dataOrFile(u.User.ClientCertificateData, u.User.ClientCertificate, dir)
+		if err != nil {
+			return
+		}
+
+		config.KeyData, err = dataOrFile(u.User.ClientKeyData, u.User.ClientKey, dir)
+		if err != nil {
+			return
+		}
+		break
+	}
+
+	return
+}
+
+// dataOrFile returns the base64 decoded data if set or else the contents of
+// the file
+func dataOrFile(data, file, dir string) ([]byte, error) {
+	if len(data) != 0 {
+		return base64.StdEncoding.DecodeString(data)
+	}
+
+	if len(file) == 0 {
+		return nil, nil
+	}
+
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// APIError is the error returned by the kubernetes api server
+type APIError struct {
+	// Code is the http status code
+	Code int `json:"code"`
+	// Reason is the machine readable reason of this error e.g. NotFound
+	Reason string `json:"reason"`
+	// Message is the human readable description of this error
+	Message string `json:"message"`
+}
+
+// Error returns the string representation of this error. This is similar to
+// the errors reported by kubectl so that the same error classifiers apply.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Error from server (%s): %s", e.Reason, e.Message)
+}
+
+// apiClient is a Client that talks to the kubernetes api server directly
+// via plain http requests. It understands only the requests made by the
+// Client contract.
+type apiClient struct {
+	// config has the api server details
+	config RESTConfig
+	// executor sends the requests to the api server; see apiExec
+	executor exec.AllExecutor
+	// namespace of this client's operations
+	namespace string
+	// labels used to filter the listed objects
+	labels string
+}
+
+// apiClients caches the api clients keyed by their kubeconfig & context so
+// that the kubeconfig is read & the connections are pooled once per cluster
+var apiClients = struct {
+	sync.Mutex
+	clients map[Config]*apiClient
+}{clients: map[Config]*apiClient{}}
+
+// NewAPIClientFromConfig returns a Client that talks to the api server
+// resolved from the provided configuration. The client is built once per
+// kubeconfig & context; its copies differ only by namespace & labels.
+func NewAPIClientFromConfig(c Config, labels string) (Client, error) {
+	key := Config{KubeConfig: c.KubeConfig, Context: c.Context}
+
+	apiClients.Lock()
+	defer apiClients.Unlock()
+
+	cached, ok := apiClients.clients[key]
+	if !ok {
+		config, err := LoadRESTConfig(c.KubeConfig, c.Context)
+		if err != nil {
+			return nil, err
+		}
+		cached, err = newAPIClient(config, "", "")
+		if err != nil {
+			return nil, err
+		}
+		apiClients.clients[key] = cached
+	}
+
+	client := *cached
+	client.namespace, client.labels = firstNonEmpty(c.Namespace, cached.config.Namespace), labels
+	return &client, nil
+}
+
+// NewAPIClient returns a new Client that talks to the provided api server.
+// Its operations are scoped to the provided namespace & labels.
+func NewAPIClient(config RESTConfig, namespace, labels string) (Client, error) {
+	return newAPIClient(config, namespace, labels)
+}
+
+// newAPIClient returns a new apiClient whose requests are subject to the
+// same dry run, audit & retry policies as the kubectl commands
+func newAPIClient(config RESTConfig, namespace, labels string) (*apiClient, error) {
+	if len(config.Host) == 0 {
+		return nil, fmt.Errorf("failed to create api client: api server host is missing")
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.Insecure}
+	if len(config.CAData) != 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(config.CAData) {
+			return nil, fmt.Errorf("failed to create api client: invalid CA certificate of '%s'", config.Host)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.CertData) != 0 && len(config.KeyData) != 0 {
+		cert, err := tls.X509KeyPair(config.CertData, config.KeyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create api client: invalid client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	base := &apiExec{
+		config: config,
+		http: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+			Timeout: GetKubectlTimeout(),
+		},
+	}
+	return &apiClient{
+		config:    config,
+		executor:  newAPIExecutor(base),
+		namespace: namespace,
+		labels:    labels,
+	}, nil
+}
+
+// apiExec is an executor that sends requests to the api server. The args of
+// an execution are the method & the path of the request while the stdin is
+// the request body. The output is the response body.
+//
+// NOTE:
+//  This lets the requests be wrapped by the same executors as the kubectl
+// commands e.g. to audit or to dry run them
+type apiExec struct {
+	// config has the api server details
+	config RESTConfig
+	// http is the client used to send requests to the api server
+	http *http.Client
+}
+
+func (e *apiExec) Execute(args []string) (string, error) {
+	return e.send(context.Background(), args, nil)
+}
+
+func (e *apiExec) StdinExecute(args []string, stdin []byte) (string, error) {
+	return e.send(context.Background(), args, stdin)
+}
+
+func (e *apiExec) ExecuteContext(ctx context.Context, args []string) (string, error) {
+	return e.send(ctx, args, nil)
+}
+
+func (e *apiExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (string, error) {
+	return e.send(ctx, args, stdin)
+}
+
+// send sends the request & returns the response body. A failed request is
+// returned as an APIError.
+func (e *apiExec) send(ctx context.Context, args []string, body []byte) (output string, err error) {
+	if len(args) != 2 {
+		err = fmt.Errorf("failed to send api request: expected 'method & path': actual '%v'", args)
+		return
+	}
+	method, path := args[0], args[1]
+
+	req, err := http.NewRequest(method, strings.TrimRight(e.config.Host, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Accept", "application/json")
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", "application/strategic-merge-patch+json")
+	}
+	if len(e.config.BearerToken) != 0 {
+		req.Header.Set("Authorization", "Bearer "+e.config.BearerToken)
+	} else if len(e.config.Username) != 0 {
+		req.SetBasicAuth(e.config.Username, e.config.Password)
+	}
+
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{}
+		if json.Unmarshal(data, apiErr) != nil || len(apiErr.Reason) == 0 {
+			apiErr = &APIError{Code: resp.StatusCode, Reason: http.StatusText(resp.StatusCode), Message: string(data)}
+		}
+		return "", apiErr
+	}
+	return string(data), nil
+}
+
+// do sends the request via the executor & decodes the response into out
+func (c *apiClient) do(method, path string, query url.Values, body []byte, out interface{}) (err error) {
+	if len(query) != 0 {
+		path = path + "?" + query.Encode()
+	}
+
+	var output string
+	if body == nil {
+		output, err = c.executor.Execute([]string{method, path})
+	} else {
+		output, err = c.executor.StdinExecute([]string{method, path}, body)
+	}
+	if err != nil || out == nil {
+		return
+	}
+
+	err = json.Unmarshal([]byte(output), out)
+	if err != nil {
+		err = fmt.Errorf("failed to decode response of '%s %s': %s", method, path, err)
+	}
+	return
+}
+
+// get fetches the object at the provided path
+func (c *apiClient) get(path string, out interface{}) error {
+	return c.do(http.MethodGet, path, nil, nil, out)
+}
+
+// list fetches the objects at the provided path that match the labels
+func (c *apiClient) list(path string, out interface{}) error {
+	query := url.Values{}
+	if labels := strings.TrimSpace(c.labels); len(labels) != 0 {
+		query.Set("labelSelector", labels)
+	}
+	return c.do(http.MethodGet, path, query, nil, out)
+}
+
+// namespaced returns the api path of a namespaced resource
+func (c *apiClient) namespaced(group, resource string) string {
+	return fmt.Sprintf("%s/namespaces/%s/%s", group, c.namespace, resource)
+}
+
+func (c *apiClient) ListPods() (pods []Pod, err error) {
+	var l PodList
+	err = c.list(c.namespaced("/api/v1", "pods"), &l)
+	return l.Items, err
+}
+
+func (c *apiClient) GetPod(name string) (pod Pod, err error) {
+	err = c.get(c.namespaced("/api/v1", "pods")+"/"+name, &pod)
+	return
+}
+
+func (c *apiClient) DeletePod(name string) error {
+	return c.do(http.MethodDelete, c.namespaced("/api/v1", "pods")+"/"+name, nil, nil, nil)
+}
+
+func (c *apiClient) ListNodes() (nodes []Node, err error) {
+	var l NodeList
+	err = c.list("/api/v1/nodes", &l)
+	return l.Items, err
+}
+
+func (c *apiClient) GetNode(name string) (node Node, err error) {
+	err = c.get("/api/v1/nodes/"+name, &node)
+	return
+}
+
+func (c *apiClient) CordonNode(name string) error {
+	return c.setUnschedulable(name, true)
+}
+
+func (c *apiClient) UncordonNode(name string) error {
+	return c.setUnschedulable(name, false)
+}
+
+// setUnschedulable patches the schedulability of the node
+func (c *apiClient) setUnschedulable(name string, unschedulable bool) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable))
+	return c.do(http.MethodPatch, "/api/v1/nodes/"+name, nil, patch, nil)
+}
+
+func (c *apiClient) GetService(name string) (service Service, err error) {
+	err = c.get(c.namespaced("/api/v1", "services")+"/"+name, &service)
+	return
+}
+
+func (c *apiClient) GetJob(name string) (job Job, err error) {
+	err = c.get(c.namespaced("/apis/batch/v1", "jobs")+"/"+name, &job)
+	return
+}
+
+func (c *apiClient) GetPVC(name string) (pvc PersistentVolumeClaim, err error) {
+	err = c.get(c.namespaced("/api/v1", "persistentvolumeclaims")+"/"+name, &pvc)
+	return
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+)
+
+const (
+	// ShellBackend executes the kubectl binary to talk to kubernetes
+	ShellBackend = "shell"
+	// NativeBackend talks to the kubernetes api server directly
+	NativeBackend = "native"
+)
+
+// Client provides the contract(s) to operate on typed kubernetes objects.
+// A client is scoped to the namespace & labels of the kubectl instance it
+// was derived from.
+//
+// NOTE:
+//  Only the operations of this contract talk to the api server when the
+// native backend is set. The native client is a minimal REST client written
+// for these operations & not client-go. Every other helper e.g. apply,
+// events, drain, taint, label, exec, logs, cp & port forward still runs the
+// kubectl binary.
+type Client interface {
+	// ListPods lists the pods that match the client's labels
+	ListPods() (pods []Pod, err error)
+	// GetPod gets the pod with the provided name
+	GetPod(name string) (pod Pod, err error)
+	// DeletePod deletes the pod with the provided name
+	DeletePod(name string) (err error)
+	// ListNodes lists all the nodes of the cluster
+	ListNodes() (nodes []Node, err error)
+	// GetNode gets the node with the provided name
+	GetNode(name string) (node Node, err error)
+	// CordonNode marks the node with the provided name as unschedulable
+	CordonNode(name string) (err error)
+	// UncordonNode marks the node with the provided name as schedulable
+	UncordonNode(name string) (err error)
+	// GetService gets the service with the provided name
+	GetService(name string) (service Service, err error)
+	// GetJob gets the job with the provided name
+	GetJob(name string) (job Job, err error)
+	// GetPVC gets the persistent volume claim with the provided name
+	GetPVC(name string) (pvc PersistentVolumeClaim, err error)
+}
+
+// ClientProvider provides the contract to get a Client
+type ClientProvider interface {
+	// Client returns a client that is scoped like the provider
+	Client() Client
+}
+
+// GetKubeBackend gets the backend used to talk to kubernetes
+func GetKubeBackend() string {
+	// get from environment variable
+	backend := util.KubeBackendENV()
+	if len(backend) == 0 {
+		// else use the default
+		backend = ShellBackend
+	}
+
+	return backend
+}
+
+// Client returns the client that operates on typed kubernetes objects with
+// this kubectl instance's namespace & labels. The backend of this client is
+// decided by the environment.
+func (k *Kubectl) Client() Client {
+	if GetKubeBackend() != NativeBackend {
+		return NewShellClient(k)
+	}
+
+	c, err := NewAPIClientFromConfig(Config{
+		KubeConfig: k.kubeconfig,
+		Context:    k.context,
+		Namespace:  k.resolvedNamespace(),
+	}, k.labels)
+	if err != nil {
+		return &errorClient{err: err}
+	}
+	return c
+}
+
+// clientFor returns the client that corresponds to the provided KubeRunner.
+// A KubeRunner that does not provide a client is wrapped in a shell client.
+func clientFor(k KubeRunner) Client {
+	if p, ok := k.(ClientProvider); ok {
+		return p.Client()
+	}
+	return NewShellClient(k)
+}
+
+// shellClient is a Client that runs kubectl commands with json output & decodes
+// the output into typed objects
+type shellClient struct {
+	// runner executes the kubectl commands
+	runner KubeRunner
+}
+
+// NewShellClient returns a new Client that makes use of the provided
+// KubeRunner
+func NewShellClient(k KubeRunner) Client {
+	return &shellClient{runner: k}
+}
+
+// get runs the kubectl command & decodes its json output into obj
+func (c *shellClient) get(obj interface{}, args ...string) (err error) {
+	op, err := c.runner.Run(append(args, "-o", "json"))
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal([]byte(op), obj)
+	if err != nil {
+		err = fmt.Errorf("failed to decode output of 'kubectl %v': %s", args, err)
+	}
+	return
+}
+
+func (c *shellClient) ListPods() (pods []Pod, err error) {
+	var l PodList
+	err = c.get(&l, "get", "pods")
+	return l.Items, err
+}
+
+func (c *shellClient) GetPod(name string) (pod Pod, err error) {
+	err = c.get(&pod, "get", "pods", name)
+	return
+}
+
+func (c *shellClient) DeletePod(name string) (err error) {
+	_, err = c.runner.Run([]string{"delete", "pods", name})
+	return
+}
+
+func (c *shellClient) ListNodes() (nodes []Node, err error) {
+	var l NodeList
+	err = c.get(&l, "get", "nodes")
+	return l.Items, err
+}
+
+func (c *shellClient) GetNode(name string) (node Node, err error) {
+	err = c.get(&node, "get", "nodes", name)
+	return
+}
+
+func (c *shellClient) CordonNode(name string) (err error) {
+	_, err = c.runner.Run([]string{"cordon", name})
+	return
+}
+
+func (c *shellClient) UncordonNode(name string) (err error) {
+	_, err = c.runner.Run([]string{"uncordon", name})
+	return
+}
+
+func (c *shellClient) GetService(name string) (service Service, err error) {
+	err = c.get(&service, "get", "services", name)
+	return
+}
+
+func (c *shellClient) GetJob(name string) (job Job, err error) {
+	err = c.get(&job, "get", "jobs", name)
+	return
+}
+
+func (c *shellClient) GetPVC(name string) (pvc PersistentVolumeClaim, err error) {
+	err = c.get(&pvc, "get", "pvc", name)
+	return
+}
+
+// errorClient is a Client that fails every operation with the same error.
+// This is used to surface an invalid client setup at every operation.
+type errorClient struct {
+	err error
+}
+
+func (c *errorClient) ListPods() ([]Pod, error)                { return nil, c.err }
+func (c *errorClient) GetPod(name string) (Pod, error)         { return Pod{}, c.err }
+func (c *errorClient) DeletePod(name string) error             { return c.err }
+func (c *errorClient) ListNodes() ([]Node, error)              { return nil, c.err }
+func (c *errorClient) GetNode(name string) (Node, error)       { return Node{}, c.err }
+func (c *errorClient) CordonNode(name string) error            { return c.err }
+func (c *errorClient) UncordonNode(name string) error          { return c.err }
+func (c *errorClient) GetService(name string) (Service, error) { return Service{}, c.err }
+func (c *errorClient) GetJob(name string) (Job, error)         { return Job{}, c.err }
+func (c *errorClient) GetPVC(name string) (PersistentVolumeClaim, error) {
+	return PersistentVolumeClaim{}, c.err
+}
@@ -0,0 +1,410 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+)
+
+// fakeObjects is the set of objects served by both the fake kubectl & the
+// fake api server. These are keyed by resource & then by name.
+var fakeObjects = map[string]map[string]string{
+	"pods": {
+		"minio-1": `{"metadata": {"name": "minio-1", "creationTimestamp": "2018-06-01T10:00:00Z"}, "spec": {"nodeName": "node-1"}, "status": {"phase": "Running", "containerStatuses": [{"ready": false}]}}`,
+		"minio-2": `{"metadata": {"name": "minio-2", "creationTimestamp": "2018-06-01T10:01:00Z"}, "spec": {"nodeName": "node-2"}, "status": {"phase": "Running", "containerStatuses": [{"ready": true}]}}`,
+	},
+	"nodes": {
+		"node-1": `{"metadata": {"name": "node-1"}, "spec": {"unschedulable": true}}`,
+		"node-2": `{"metadata": {"name": "node-2"}}`,
+	},
+	"services": {
+		"minio-svc": `{"metadata": {"name": "minio-svc"}, "spec": {"clusterIP": "10.0.0.12"}}`,
+	},
+	"jobs": {
+		"minio-job": `{"metadata": {"name": "minio-job"}, "status": {"succeeded": 1}}`,
+	},
+	"persistentvolumeclaims": {
+		"minio-pvc": `{"metadata": {"name": "minio-pvc"}, "spec": {"volumeName": "pvc-123"}, "status": {"phase": "Bound"}}`,
+	},
+}
+
+// fakeGet returns the json of the object or the list of objects
+func fakeGet(resource, name string) (string, bool) {
+	if resource == "pvc" {
+		resource = "persistentvolumeclaims"
+	}
+
+	objects, ok := fakeObjects[resource]
+	if !ok {
+		return "", false
+	}
+
+	if len(name) != 0 {
+		obj, ok := objects[name]
+		return obj, ok
+	}
+
+	var items []string
+	for _, obj := range objects {
+		items = append(items, obj)
+	}
+	return fmt.Sprintf(`{"items": [%s]}`, strings.Join(items, ",")), true
+}
+
+// fakeShellRunner emulates kubectl against the fake objects & records the
+// executed commands
+type fakeShellRunner struct {
+	executed []string
+}
+
+func (r *fakeShellRunner) Run(args []string) (output string, err error) {
+	r.executed = append(r.executed, strings.Join(args, " "))
+	if args[0] != "get" {
+		return
+	}
+
+	var name string
+	if args[2] != "-o" {
+		name = args[2]
+	}
+
+	output, ok := fakeGet(args[1], name)
+	if !ok {
+		err = fmt.Errorf(`Error from server (NotFound): %s "%s" not found`, args[1], name)
+	}
+	return
+}
+
+// fakeAPIRunner provides a client against the fake api server
+type fakeAPIRunner struct {
+	client Client
+}
+
+func (r *fakeAPIRunner) Run(args []string) (output string, err error) {
+	err = fmt.Errorf("unexpected kubectl execution '%v' with native backend", args)
+	return
+}
+
+func (r *fakeAPIRunner) Client() Client {
+	return r.client
+}
+
+// newFakeAPIServer returns a server that serves the fake objects & records
+// the modifying requests
+func newFakeAPIServer(executed *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+		resource, name := parts[len(parts)-1], ""
+		if _, ok := fakeObjects[resource]; !ok {
+			resource, name = parts[len(parts)-2], parts[len(parts)-1]
+		}
+
+		if req.Method != http.MethodGet {
+			body, _ := ioutil.ReadAll(req.Body)
+			*executed = append(*executed, strings.TrimSpace(fmt.Sprintf("%s %s %s", req.Method, req.URL.Path, body)))
+			return
+		}
+
+		obj, ok := fakeGet(resource, name)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"kind": "Status", "code": 404, "reason": "NotFound", "message": "%s \"%s\" not found"}`, resource, name)
+			return
+		}
+		fmt.Fprint(w, obj)
+	}))
+}
+
+// TestClientConformance verifies both the backends behave the same way
+// against the same set of objects
+func TestClientConformance(t *testing.T) {
+	var apiExecuted []string
+	server := newFakeAPIServer(&apiExecuted)
+	defer server.Close()
+
+	client, err := NewAPIClient(RESTConfig{Host: server.URL}, "litmus", "")
+	if err != nil {
+		t.Fatalf("failed to create api client: expected 'no error': actual '%s'", err)
+	}
+
+	shell := &fakeShellRunner{}
+	backends := map[string]struct {
+		runner   KubeRunner
+		executed func() []string
+		expected []string
+	}{
+		"shell backend": {
+			runner:   shell,
+			executed: func() []string { return shell.executed },
+			expected: []string{"cordon node-2", "delete pods minio-1"},
+		},
+		"native backend": {
+			runner:   &fakeAPIRunner{client: client},
+			executed: func() []string { return apiExecuted },
+			expected: []string{
+				`PATCH /api/v1/nodes/node-2 {"spec":{"unschedulable":true}}`,
+				"DELETE /api/v1/namespaces/litmus/pods/minio-1",
+			},
+		},
+	}
+
+	// the cordons are recorded in the default node ledger
+	defer func() { defaultNodeLedger = NewNodeLedger() }()
+
+	for name, mock := range backends {
+		t.Run(name, func(t *testing.T) {
+			k := mock.runner
+
+			pod, err := GetOldestRunningPod(k)
+			if err != nil || pod != "minio-2" {
+				t.Fatalf("failed to get oldest running pod: expected 'minio-2': actual '%s' '%v'", pod, err)
+			}
+
+			yes, err := ArePodsRunning(k)
+			if yes || err == nil {
+				t.Fatalf("failed to verify pods running: expected 'not running error': actual '%t' '%v'", yes, err)
+			}
+
+			yes, err = IsPodRunning(k, "minio-2")
+			if !yes || err != nil {
+				t.Fatalf("failed to verify pod running: expected 'running': actual '%t' '%v'", yes, err)
+			}
+
+			_, err = IsPodRunning(k, "minio-9")
+			if !IsNotFound(err) {
+				t.Fatalf("failed to verify pod running: expected 'not found error': actual '%v'", err)
+			}
+
+			ip, err := GetServiceIP(k, "minio-svc")
+			if err != nil || ip != "10.0.0.12" {
+				t.Fatalf("failed to get service ip: expected '10.0.0.12': actual '%s' '%v'", ip, err)
+			}
+
+			yes, err = IsJobCompleted(k, "minio-job")
+			if !yes || err != nil {
+				t.Fatalf("failed to verify job completion: expected 'completed': actual '%t' '%v'", yes, err)
+			}
+
+			yes, _ = IsJobCompleted(k, "minio-none")
+			if yes {
+				t.Fatalf("failed to verify job completion: expected 'not completed' for missing job: actual 'completed'")
+			}
+
+			pvc, err := clientFor(k).GetPVC("minio-pvc")
+			if err != nil || pvc.Spec.VolumeName != "pvc-123" || pvc.Status.Phase != "Bound" {
+				t.Fatalf("failed to get pvc: expected 'pvc-123' & 'Bound': actual '%#v' '%v'", pvc, err)
+			}
+
+			nodes, err := clientFor(k).ListNodes()
+			if err != nil || len(nodes) != 2 {
+				t.Fatalf("failed to list nodes: expected '2 nodes': actual '%#v' '%v'", nodes, err)
+			}
+
+			err = CordonNodeWithPod(k, pod)
+			if err != nil {
+				t.Fatalf("failed to cordon node with pod: expected 'no error': actual '%s'", err)
+			}
+
+			// the node of minio-1 is already cordoned & hence left as is
+			err = CordonNodeWithPod(k, "minio-1")
+			if err != nil {
+				t.Fatalf("failed to cordon node with pod: expected 'no error': actual '%s'", err)
+			}
+
+			err = DeletePod(k, "minio-1")
+			if err != nil {
+				t.Fatalf("failed to delete pod: expected 'no error': actual '%s'", err)
+			}
+
+			var modified []string
+			for _, cmd := range mock.executed() {
+				if !strings.HasPrefix(cmd, "get ") {
+					modified = append(modified, cmd)
+				}
+			}
+			if !reflect.DeepEqual(modified, mock.expected) {
+				t.Fatalf("failed to modify objects: expected '%v': actual '%v'", mock.expected, modified)
+			}
+		})
+	}
+}
+
+func TestLoadRESTConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	kubeconfig := `
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com
+    insecure-skip-tls-verify: true
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com
+users:
+- name: dev-user
+  user:
+    tokenFile: token
+- name: prod-user
+  user:
+    exec:
+      command: aws
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: dev-user
+    namespace: dev-ns
+- name: prod
+  context:
+    cluster: prod-cluster
+    user: prod-user
+`
+	path := filepath.Join(dir, "config")
+	ioutil.WriteFile(path, []byte(kubeconfig), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "token"), []byte("s3cr3t\n"), 0644)
+
+	tests := map[string]struct {
+		context  string
+		expected RESTConfig
+		isErr    bool
+	}{
+		"load rest config - current context": {
+			context:  "",
+			expected: RESTConfig{Host: "https://dev.example.com", BearerToken: "s3cr3t", Insecure: true, Namespace: "dev-ns"},
+		},
+		"load rest config - exec plugin is not supported": {
+			context: "prod",
+			isErr:   true,
+		},
+		"load rest config - unknown context": {
+			context: "staging",
+			isErr:   true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			config, err := LoadRESTConfig(path, mock.context)
+			if mock.isErr {
+				if err == nil {
+					t.Fatalf("failed to load rest config: expected 'error': actual 'no error'")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to load rest config: expected 'no error': actual '%s'", err)
+			}
+
+			if !reflect.DeepEqual(config, mock.expected) {
+				t.Fatalf("failed to load rest config: expected '%#v': actual '%#v'", mock.expected, config)
+			}
+		})
+	}
+}
+
+func TestAPIClientWithDryRun(t *testing.T) {
+	var executed []string
+	server := newFakeAPIServer(&executed)
+	defer server.Close()
+
+	os.Setenv(string(util.DryRunENVK), "true")
+	defer os.Unsetenv(string(util.DryRunENVK))
+
+	client, err := NewAPIClient(RESTConfig{Host: server.URL}, "litmus", "")
+	if err != nil {
+		t.Fatalf("failed to create api client: expected 'no error': actual '%s'", err)
+	}
+
+	pod, err := client.GetPod("minio-2")
+	if err != nil || pod.Metadata.Name != "minio-2" {
+		t.Fatalf("failed to get pod with dry run: expected 'minio-2': actual '%s' '%v'", pod.Metadata.Name, err)
+	}
+	if err = client.CordonNode("node-2"); err != nil {
+		t.Fatalf("failed to cordon node with dry run: expected 'no error': actual '%s'", err)
+	}
+	if err = client.DeletePod("minio-1"); err != nil {
+		t.Fatalf("failed to delete pod with dry run: expected 'no error': actual '%s'", err)
+	}
+
+	// the fake api server records only the modifying requests
+	if len(executed) != 0 {
+		t.Fatalf("failed to dry run: expected 'no modifying request': actual '%v'", executed)
+	}
+}
+
+func TestAPIClientFromConfigIsCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config")
+	ioutil.WriteFile(path, []byte(`
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com
+users:
+- name: dev-user
+  user:
+    token: s3cr3t
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: dev-user
+    namespace: dev-ns
+`), 0644)
+
+	first, err := NewAPIClientFromConfig(Config{KubeConfig: path}, "")
+	if err != nil {
+		t.Fatalf("failed to create api client: expected 'no error': actual '%s'", err)
+	}
+	// the kubeconfig is not read again
+	os.Remove(path)
+	second, err := NewAPIClientFromConfig(Config{KubeConfig: path, Namespace: "litmus"}, "app=minio")
+	if err != nil {
+		t.Fatalf("failed to create cached api client: expected 'no error': actual '%s'", err)
+	}
+
+	f, s := first.(*apiClient), second.(*apiClient)
+	if f.executor != s.executor {
+		t.Fatalf("failed to cache api client: expected 'shared executor': actual 'new executor'")
+	}
+	if f.namespace != "dev-ns" || s.namespace != "litmus" || s.labels != "app=minio" {
+		t.Fatalf("failed to scope api client: expected 'dev-ns' & 'litmus' & 'app=minio': actual '%s' & '%s' & '%s'", f.namespace, s.namespace, s.labels)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"strings"
+
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+)
+
+// Config is the configuration used by kubectl to connect to a kubernetes
+// cluster
+type Config struct {
+	// KubeConfig is the path to the kubeconfig file
+	KubeConfig string
+	// Context is the kubeconfig context to be used
+	Context string
+	// Namespace is the default namespace of kubectl operations
+	Namespace string
+}
+
+// ResolveConfig resolves each field of the configuration with the following
+// precedence:
+//
+// 1/ the provided value e.g. a component's namespace, else
+// 2/ the value set in the environment, else
+// 3/ the default value if any
+func ResolveConfig(given Config) Config {
+	return Config{
+		KubeConfig: firstNonEmpty(given.KubeConfig, util.KubeConfigENV()),
+		Context:    firstNonEmpty(given.Context, util.KubeContextENV()),
+		Namespace:  firstNonEmpty(given.Namespace, util.KubeNamespaceENV(), DefaultLitmusNamespace),
+	}
+}
+
+// firstNonEmpty returns the first value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); len(v) != 0 {
+			return v
+		}
+	}
+	return ""
+}
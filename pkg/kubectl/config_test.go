@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"os"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+)
+
+func TestResolveConfig(t *testing.T) {
+	envKeys := []util.ENVKey{util.KubeConfigENVK, util.KubeContextENVK, util.KubeNamespaceENVK}
+	for _, key := range envKeys {
+		if val, ok := os.LookupEnv(string(key)); ok {
+			defer os.Setenv(string(key), val)
+		} else {
+			defer os.Unsetenv(string(key))
+		}
+	}
+
+	tests := map[string]struct {
+		given    Config
+		env      Config
+		expected Config
+	}{
+		"resolve config - defaults": {
+			given:    Config{},
+			env:      Config{},
+			expected: Config{Namespace: DefaultLitmusNamespace},
+		},
+		"resolve config - env overrides defaults": {
+			given:    Config{},
+			env:      Config{KubeConfig: "/root/.kube/config", Context: "minikube", Namespace: "e2e"},
+			expected: Config{KubeConfig: "/root/.kube/config", Context: "minikube", Namespace: "e2e"},
+		},
+		"resolve config - given value overrides env": {
+			given:    Config{Namespace: "default"},
+			env:      Config{KubeConfig: "/root/.kube/config", Context: "minikube", Namespace: "e2e"},
+			expected: Config{KubeConfig: "/root/.kube/config", Context: "minikube", Namespace: "default"},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv(string(util.KubeConfigENVK), mock.env.KubeConfig)
+			os.Setenv(string(util.KubeContextENVK), mock.env.Context)
+			os.Setenv(string(util.KubeNamespaceENVK), mock.env.Namespace)
+
+			c := ResolveConfig(mock.given)
+			if c != mock.expected {
+				t.Fatalf("failed to resolve config: expected '%#v': actual '%#v'", mock.expected, c)
+			}
+
+			// a kubectl instance from the factory applies the same config
+			k := NewKubeFactory().NewInstance(mock.given.Namespace).(*Kubectl)
+			if k.kubeconfig != c.KubeConfig || k.context != c.Context || k.namespace != c.Namespace {
+				t.Fatalf("failed to apply resolved config: expected '%#v': actual kubectl '%#v'", c, k)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+)
+
+// statusReason is the reason reported by kubernetes api server when a request
+// fails
+//
+// An example of kubectl error with a status reason:
+//
+// $ kubectl get pods my-pod
+// Error from server (NotFound): pods "my-pod" not found
+type statusReason string
+
+const (
+	// notFoundReason is reported when the requested resource is not available
+	notFoundReason statusReason = "NotFound"
+	// alreadyExistsReason is reported when the resource to be created is
+	// available already
+	alreadyExistsReason statusReason = "AlreadyExists"
+	// forbiddenReason is reported when the request is not authorized
+	forbiddenReason statusReason = "Forbidden"
+	// conflictReason is reported when the request conflicts with the current
+	// state of the resource
+	conflictReason statusReason = "Conflict"
+	// timeoutReason is reported when the request could not be completed
+	// within the time allotted
+	timeoutReason statusReason = "Timeout"
+	// serverTimeoutReason is reported when the server could not complete the
+	// request in a reasonable time
+	serverTimeoutReason statusReason = "ServerTimeout"
+)
+
+// stderr returns the error output of a failed kubectl command. It falls back
+// to the error's message if the error is not an execution error.
+func stderr(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if e, ok := exec.AsExecError(err); ok {
+		return e.Stderr
+	}
+
+	return err.Error()
+}
+
+// hasReason flags if the error is due to the provided status reason
+func hasReason(err error, reason statusReason) bool {
+	return strings.Contains(stderr(err), fmt.Sprintf("(%s)", reason))
+}
+
+// hasAnyMessage flags if the error output contains any of the provided
+// messages
+func hasAnyMessage(err error, messages ...string) bool {
+	s := strings.ToLower(stderr(err))
+	for _, m := range messages {
+		if strings.Contains(s, strings.ToLower(m)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound flags if the error is due to a resource that is not available at
+// the kubernetes cluster
+func IsNotFound(err error) bool {
+	return hasReason(err, notFoundReason)
+}
+
+// IsAlreadyExists flags if the error is due to a resource that is available
+// already at the kubernetes cluster
+func IsAlreadyExists(err error) bool {
+	return hasReason(err, alreadyExistsReason)
+}
+
+// IsForbidden flags if the error is due to a request that is not authorized
+// to be executed at the kubernetes cluster
+func IsForbidden(err error) bool {
+	return hasReason(err, forbiddenReason)
+}
+
+// IsConflict flags if the error is due to a request that conflicts with the
+// current state of the resource e.g. the resource was modified by someone else
+func IsConflict(err error) bool {
+	return hasReason(err, conflictReason) ||
+		hasAnyMessage(err, "the object has been modified")
+}
+
+// IsTimeout flags if the error is due to a request that could not complete in
+// time. This includes the kubectl command getting killed after its timeout.
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if e, ok := exec.AsExecError(err); ok && e.IsTimedOut() {
+		return true
+	}
+
+	return hasReason(err, timeoutReason) ||
+		hasReason(err, serverTimeoutReason) ||
+		hasAnyMessage(err,
+			"the server was unable to return a response in the time allotted",
+			"request timed out",
+			"Client.Timeout exceeded",
+			"i/o timeout",
+			"context deadline exceeded",
+		)
+}
+
+// IsDisruptionBudgetViolation flags if the error is due to an eviction that
+// would violate the pod disruption budget of the evicted pod e.g. during a
+// drain of a node
+func IsDisruptionBudgetViolation(err error) bool {
+	return hasAnyMessage(err,
+		"violate the pod's disruption budget",
+		"Cannot evict pod as it would violate",
+	)
+}
+
+// IsTransient flags if the error is a temporary one that may go away if the
+// same kubectl command is retried e.g. the api server was unreachable for a
+// moment or the resource was modified concurrently
+//
+// NOTE:
+//  A kubectl command that was killed after its own timeout is not transient
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if e, ok := exec.AsExecError(err); ok && (e.IsTimedOut() || e.IsCancelled()) {
+		return false
+	}
+
+	return IsConflict(err) ||
+		hasReason(err, timeoutReason) ||
+		hasReason(err, serverTimeoutReason) ||
+		hasReason(err, "ServiceUnavailable") ||
+		hasReason(err, "TooManyRequests") ||
+		hasReason(err, "InternalError") ||
+		hasAnyMessage(err,
+			"connection refused",
+			"connection reset by peer",
+			"etcdserver: request timed out",
+			"etcdserver: leader changed",
+			"TLS handshake timeout",
+			"i/o timeout",
+			"Client.Timeout exceeded",
+			"unexpected EOF",
+			"the server is currently unable to handle the request",
+			"the server was unable to return a response in the time allotted",
+		)
+}
+
+// IsNotApplied flags if the error guarantees that the request made no change
+// at the kubernetes cluster i.e. the api server could not be reached or it
+// turned the request away before processing it
+//
+// NOTE:
+//  A kubectl command that was killed after its own timeout may have been
+// applied
+func IsNotApplied(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if e, ok := exec.AsExecError(err); ok && (e.IsTimedOut() || e.IsCancelled()) {
+		return false
+	}
+
+	return hasReason(err, "ServiceUnavailable") ||
+		hasReason(err, "TooManyRequests") ||
+		hasAnyMessage(err,
+			"connection refused",
+			"TLS handshake timeout",
+		)
+}
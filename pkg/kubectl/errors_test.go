@@ -0,0 +1,224 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+)
+
+func TestErrorClassifiers(t *testing.T) {
+	tests := map[string]struct {
+		err         error
+		isNotFound  bool
+		isForbidden bool
+		isConflict  bool
+		isTimeout   bool
+		isPDB       bool
+	}{
+		"classify - nil error": {
+			err: nil,
+		},
+		"classify - not found": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `Error from server (NotFound): pods "my-pod" not found`,
+			},
+			isNotFound: true,
+		},
+		"classify - forbidden": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `Error from server (Forbidden): pods is forbidden: User "litmus" cannot list pods in the namespace "default"`,
+			},
+			isForbidden: true,
+		},
+		"classify - conflict": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `Error from server (Conflict): Operation cannot be fulfilled on deployments.extensions "my-app": the object has been modified; please apply your changes to the latest version and try again`,
+			},
+			isConflict: true,
+		},
+		"classify - server timeout": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `Error from server (Timeout): the server was unable to return a response in the time allotted, but may still be processing the request`,
+			},
+			isTimeout: true,
+		},
+		"classify - killed after deadline": {
+			err: &exec.ExecError{
+				ExitCode: exec.UnknownExitCode,
+				Err:      context.DeadlineExceeded,
+			},
+			isTimeout: true,
+		},
+		"classify - disruption budget violation": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `error when evicting pods/"minio-1" -n "litmus" (will retry after 5s): Cannot evict pod as it would violate the pod's disruption budget.`,
+			},
+			isPDB: true,
+		},
+		"classify - wrapped disruption budget violation": {
+			err:   fmt.Errorf("failed to drain node 'node-1': error: Cannot evict pod as it would violate the pod's disruption budget."),
+			isPDB: true,
+		},
+		"classify - non exec error": {
+			err:        fmt.Errorf(`Error from server (NotFound): pods "my-pod" not found`),
+			isNotFound: true,
+		},
+		"classify - unclassified error": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `error: the server doesn't have a resource type "pdos"`,
+			},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if IsNotFound(mock.err) != mock.isNotFound {
+				t.Fatalf("failed to classify error '%v': expected not found '%t'", mock.err, mock.isNotFound)
+			}
+
+			if IsForbidden(mock.err) != mock.isForbidden {
+				t.Fatalf("failed to classify error '%v': expected forbidden '%t'", mock.err, mock.isForbidden)
+			}
+
+			if IsConflict(mock.err) != mock.isConflict {
+				t.Fatalf("failed to classify error '%v': expected conflict '%t'", mock.err, mock.isConflict)
+			}
+
+			if IsTimeout(mock.err) != mock.isTimeout {
+				t.Fatalf("failed to classify error '%v': expected timeout '%t'", mock.err, mock.isTimeout)
+			}
+
+			if IsDisruptionBudgetViolation(mock.err) != mock.isPDB {
+				t.Fatalf("failed to classify error '%v': expected disruption budget violation '%t'", mock.err, mock.isPDB)
+			}
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := map[string]struct {
+		err         error
+		isTransient bool
+	}{
+		"transient - nil error": {
+			err: nil,
+		},
+		"transient - connection refused": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `The connection to the server 10.0.0.1:6443 was refused - did you specify the right host or port?: dial tcp 10.0.0.1:6443: connect: connection refused`,
+			},
+			isTransient: true,
+		},
+		"transient - etcd request timed out": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `Error from server: etcdserver: request timed out`,
+			},
+			isTransient: true,
+		},
+		"transient - object modified": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `Error from server (Conflict): the object has been modified; please apply your changes to the latest version and try again`,
+			},
+			isTransient: true,
+		},
+		"transient - not found is permanent": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `Error from server (NotFound): pods "my-pod" not found`,
+			},
+		},
+		"transient - killed after deadline is permanent": {
+			err: &exec.ExecError{
+				ExitCode: exec.UnknownExitCode,
+				Err:      context.DeadlineExceeded,
+			},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if IsTransient(mock.err) != mock.isTransient {
+				t.Fatalf("failed to classify error '%v': expected transient '%t'", mock.err, mock.isTransient)
+			}
+		})
+	}
+}
+
+func TestIsNotApplied(t *testing.T) {
+	tests := map[string]struct {
+		err          error
+		isNotApplied bool
+	}{
+		"not applied - nil error": {
+			err: nil,
+		},
+		"not applied - connection refused": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `The connection to the server 10.0.0.1:6443 was refused - did you specify the right host or port?: dial tcp 10.0.0.1:6443: connect: connection refused`,
+			},
+			isNotApplied: true,
+		},
+		"not applied - too many requests": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `Error from server (TooManyRequests): the server has received too many requests and has asked us to try again later`,
+			},
+			isNotApplied: true,
+		},
+		"not applied - tls handshake timeout": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `Unable to connect to the server: net/http: TLS handshake timeout`,
+			},
+			isNotApplied: true,
+		},
+		"not applied - server timeout may be applied": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `Error from server (Timeout): the server was unable to return a response in the time allotted, but may still be processing the request`,
+			},
+		},
+		"not applied - connection reset may be applied": {
+			err: &exec.ExecError{
+				ExitCode: 1,
+				Stderr:   `error: read tcp 10.0.0.2:51234->10.0.0.1:6443: read: connection reset by peer`,
+			},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if IsNotApplied(mock.err) != mock.isNotApplied {
+				t.Fatalf("failed to classify error '%v': expected not applied '%t'", mock.err, mock.isNotApplied)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Time returns the time when the event last occurred
+func (e Event) Time() time.Time {
+	for _, t := range []time.Time{e.LastTimestamp, e.EventTime, e.FirstTimestamp} {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return e.Metadata.CreationTimestamp
+}
+
+// String returns the event in a form that is similar to the one printed by
+// 'kubectl get events'
+func (e Event) String() string {
+	s := fmt.Sprintf("%s %s %s/%s: %s", e.Type, e.Reason, strings.ToLower(e.InvolvedObject.Kind), e.InvolvedObject.Name, e.Message)
+	if e.Count > 1 {
+		s = fmt.Sprintf("%s (x%d)", s, e.Count)
+	}
+	return s
+}
+
+// GetEvents fetches the events of the object with the provided name that
+// occurred within the provided duration. All the events of the namespace are
+// fetched if the name is empty & a zero duration fetches the events
+// irrespective of their age. The events are sorted from the oldest to the
+// latest.
+//
+// NOTE:
+//  Labels set against a *Kubectl are ignored since events do not carry the
+// labels of the object they are about. This always makes use of kubectl.
+func GetEvents(k KubeRunner, involvedObject string, since time.Duration) (events []Event, err error) {
+	if kk, ok := k.(*Kubectl); ok {
+		k = kk.Labels("")
+	}
+
+	args := []string{"get", "events"}
+	if len(strings.TrimSpace(involvedObject)) != 0 {
+		args = append(args, "--field-selector", "involvedObject.name="+involvedObject)
+	}
+
+	var l EventList
+	err = (&shellClient{runner: k}).get(&l, args...)
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-since)
+	for _, e := range l.Items {
+		if since > 0 && e.Time().Before(cutoff) {
+			continue
+		}
+		events = append(events, e)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time().Before(events[j].Time())
+	})
+	return
+}
+
+// WarningEvents returns the warning events whose reason is not one of the
+// allowed reasons
+func WarningEvents(events []Event, allowed ...string) (warnings []Event) {
+	for _, e := range events {
+		if e.Type != EventWarning || containsReason(allowed, e.Reason) {
+			continue
+		}
+		warnings = append(warnings, e)
+	}
+	return
+}
+
+// containsReason flags if the reason is one of the provided reasons
+func containsReason(reasons []string, reason string) bool {
+	for _, r := range reasons {
+		if strings.TrimSpace(r) == reason {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eventsJSON returns the json of an event list whose events occurred at the
+// provided times before now
+func eventsJSON(ago ...time.Duration) string {
+	now := time.Now().UTC()
+	items := []string{
+		`{"metadata": {"name": "minio-1.1"}, "involvedObject": {"kind": "Pod", "name": "minio-1"}, "type": "Warning", "reason": "FailedScheduling", "message": "0/3 nodes are available", "count": 4, "lastTimestamp": "%s"}`,
+		`{"metadata": {"name": "minio-1.2"}, "involvedObject": {"kind": "Pod", "name": "minio-1"}, "type": "Normal", "reason": "Scheduled", "message": "Successfully assigned litmus/minio-1 to node-1", "firstTimestamp": null, "lastTimestamp": null, "eventTime": "%s"}`,
+		`{"metadata": {"name": "minio-pvc.1"}, "involvedObject": {"kind": "PersistentVolumeClaim", "name": "minio-pvc"}, "type": "Warning", "reason": "ProvisioningFailed", "message": "storageclass not found", "lastTimestamp": "%s"}`,
+	}
+	for i := range items {
+		items[i] = fmt.Sprintf(items[i], now.Add(-ago[i]).Format(time.RFC3339Nano))
+	}
+	return `{"items": [` + strings.Join(items, ",") + `]}`
+}
+
+func TestGetEvents(t *testing.T) {
+	tests := map[string]struct {
+		k        KubeRunner
+		object   string
+		since    time.Duration
+		expected []string
+		reasons  []string
+	}{
+		"get events - +ve test case - all events sorted by time": {
+			k:        &recordRunner{},
+			expected: []string{"get", "events", "-o", "json"},
+			reasons:  []string{"ProvisioningFailed", "FailedScheduling", "Scheduled"},
+		},
+		"get events - +ve test case - events of an object": {
+			k:        &recordRunner{},
+			object:   "minio-1",
+			expected: []string{"get", "events", "--field-selector", "involvedObject.name=minio-1", "-o", "json"},
+			reasons:  []string{"ProvisioningFailed", "FailedScheduling", "Scheduled"},
+		},
+		"get events - +ve test case - recent events": {
+			k:        &recordRunner{},
+			since:    30 * time.Minute,
+			expected: []string{"get", "events", "-o", "json"},
+			reasons:  []string{"FailedScheduling", "Scheduled"},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := mock.k.(*recordRunner)
+			r.output = eventsJSON(10*time.Minute, 5*time.Minute, time.Hour)
+
+			events, err := GetEvents(mock.k, mock.object, mock.since)
+			if err != nil {
+				t.Fatalf("failed to get events: expected 'no error': actual '%s'", err)
+			}
+			if !reflect.DeepEqual(r.args, mock.expected) {
+				t.Fatalf("failed to get events: expected args '%v': actual '%v'", mock.expected, r.args)
+			}
+
+			var reasons []string
+			for _, e := range events {
+				reasons = append(reasons, e.Reason)
+			}
+			if !reflect.DeepEqual(reasons, mock.reasons) {
+				t.Fatalf("failed to get events: expected reasons '%v': actual '%v'", mock.reasons, reasons)
+			}
+		})
+	}
+}
+
+// emptyEventsExec is an executor that records its args & returns an empty
+// list of events
+type emptyEventsExec struct {
+	echoExec
+	args *[]string
+}
+
+func (e emptyEventsExec) Execute(args []string) (string, error) {
+	*e.args = args
+	return `{"items": []}`, nil
+}
+
+func (e emptyEventsExec) ExecuteContext(ctx context.Context, args []string) (string, error) {
+	return e.Execute(args)
+}
+
+func TestGetEventsIgnoresLabels(t *testing.T) {
+	var args []string
+	k := New().Executor(emptyEventsExec{args: &args}).Namespace("litmus").Labels("app=minio")
+
+	if _, err := GetEvents(k, "", 0); err != nil {
+		t.Fatalf("failed to get events: expected 'no error': actual '%s'", err)
+	}
+	if joined := strings.Join(args, " "); strings.Contains(joined, "app=minio") || !strings.Contains(joined, "--namespace=litmus") {
+		t.Fatalf("failed to get events: expected 'namespace without labels': actual '%v'", args)
+	}
+}
+
+func TestWarningEvents(t *testing.T) {
+	r := &recordRunner{output: eventsJSON(time.Minute, time.Minute, time.Minute)}
+	events, err := GetEvents(r, "", 0)
+	if err != nil {
+		t.Fatalf("failed to get events: expected 'no error': actual '%s'", err)
+	}
+
+	tests := map[string]struct {
+		allowed  []string
+		expected []string
+	}{
+		"warning events - +ve test case - no allowed reasons": {
+			expected: []string{
+				"Warning FailedScheduling pod/minio-1: 0/3 nodes are available (x4)",
+				"Warning ProvisioningFailed persistentvolumeclaim/minio-pvc: storageclass not found",
+			},
+		},
+		"warning events - +ve test case - allowed reason": {
+			allowed: []string{"FailedScheduling"},
+			expected: []string{
+				"Warning ProvisioningFailed persistentvolumeclaim/minio-pvc: storageclass not found",
+			},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual []string
+			for _, e := range WarningEvents(events, mock.allowed...) {
+				actual = append(actual, e.String())
+			}
+			if !reflect.DeepEqual(actual, mock.expected) {
+				t.Fatalf("failed to filter warning events: expected '%v': actual '%v'", mock.expected, actual)
+			}
+		})
+	}
+}
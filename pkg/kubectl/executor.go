@@ -0,0 +1,349 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+)
+
+const (
+	// RecordCassetteMode records every kubectl execution into the cassette
+	RecordCassetteMode = "record"
+)
+
+const (
+	// PassthroughDryRunMode plans the mutating kubectl commands & executes
+	// the read only kubectl commands
+	PassthroughDryRunMode = "passthrough"
+	// StubDryRunMode plans the mutating kubectl commands & stubs the read
+	// only kubectl commands with empty output
+	StubDryRunMode = "stub"
+)
+
+// mutatingVerbs are the kubectl sub commands that change the state of the
+// kubernetes cluster
+var mutatingVerbs = map[string]bool{
+	"annotate":  true,
+	"apply":     true,
+	"autoscale": true,
+	"cordon":    true,
+	"cp":        true,
+	"create":    true,
+	"delete":    true,
+	"drain":     true,
+	"edit":      true,
+	"exec":      true,
+	"expose":    true,
+	"label":     true,
+	"patch":     true,
+	"replace":   true,
+	"rollout":   true,
+	"run":       true,
+	"scale":     true,
+	"set":       true,
+	"taint":     true,
+	"uncordon":  true,
+}
+
+// readOnlySubcommands are the sub commands of the mutating verbs that do not
+// change the state of the kubernetes cluster
+var readOnlySubcommands = map[string]map[string]bool{
+	"rollout": {"history": true, "status": true},
+}
+
+// settlingVerbs are the verbs of the mutating commands whose retry is settled
+// by an error that shows the change of an earlier attempt. The http methods
+// are the verbs of the api requests.
+var settlingVerbs = map[string]func(err error) bool{
+	"create":          IsAlreadyExists,
+	"delete":          IsNotFound,
+	http.MethodPost:   IsAlreadyExists,
+	http.MethodDelete: IsNotFound,
+}
+
+const (
+	// KubectlRetryMaxElapsed is the default maximum time spent in retrying a
+	// kubectl command
+	KubectlRetryMaxElapsed = 2 * time.Minute
+)
+
+var (
+	// defaultExecutor is shared by all the kubectl instances that are built
+	// via New()
+	defaultExecutor exec.AllExecutor
+	// defaultExecutorSet flags if the default executor was set via
+	// SetDefaultExecutor rather than built from the environment
+	defaultExecutorSet bool
+	// defaultExecutorMutex guards defaultExecutor
+	defaultExecutorMutex sync.Mutex
+)
+
+// DefaultExecutor returns the executor that is shared by all the kubectl
+// instances built via New(). It is built once from the environment.
+func DefaultExecutor() exec.AllExecutor {
+	defaultExecutorMutex.Lock()
+	defer defaultExecutorMutex.Unlock()
+
+	if defaultExecutor == nil {
+		defaultExecutor = newExecutor()
+	}
+	return defaultExecutor
+}
+
+// SetDefaultExecutor overrides the executor that is shared by all the kubectl
+// instances built via New(). This is typically used to run kubectl logic
+// against replayed or simulated executions. Setting nil resets the executor
+// to be built again from the environment.
+func SetDefaultExecutor(executor exec.AllExecutor) {
+	defaultExecutorMutex.Lock()
+	defer defaultExecutorMutex.Unlock()
+
+	defaultExecutor = executor
+	defaultExecutorSet = executor != nil
+}
+
+// IsIntercepted flags if the kubectl commands are not executed against the
+// cluster as is i.e. these are dry run, recorded, replayed or executed by an
+// executor set via SetDefaultExecutor
+//
+// NOTE:
+//  The commands that bypass the default executor e.g. a port forward can not
+// honour this interception & hence should not be run
+func IsIntercepted() bool {
+	defaultExecutorMutex.Lock()
+	set := defaultExecutorSet
+	defaultExecutorMutex.Unlock()
+
+	mode := strings.ToLower(util.DryRunENV())
+	return set || (len(mode) != 0 && mode != "false") || len(util.CassetteENV()) != 0
+}
+
+// GetKubectlRetryPolicy gets the policy to retry kubectl commands that fail
+// with transient errors. The policy is built from the environment.
+//
+// NOTE:
+//
+//	Retries are disabled i.e. max attempts is 1 if the env is not set
+func GetKubectlRetryPolicy() exec.RetryPolicy {
+	attempts, err := strconv.Atoi(util.KubectlRetryAttemptsENV())
+	if err != nil || attempts < 1 {
+		attempts = 1
+	}
+
+	elapsed, err := time.ParseDuration(util.KubectlRetryMaxElapsedENV())
+	if err != nil || elapsed < 0 {
+		elapsed = KubectlRetryMaxElapsed
+	}
+
+	return exec.DefaultRetryPolicy(attempts, elapsed)
+}
+
+// IsMutating flags if the kubectl command with the provided args changes the
+// state of the kubernetes cluster
+func IsMutating(args []string) bool {
+	var verb string
+	for _, arg := range args {
+		// skip the flags set before the sub command
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if len(verb) == 0 {
+			verb = arg
+			if !mutatingVerbs[verb] || readOnlySubcommands[verb] == nil {
+				return mutatingVerbs[verb]
+			}
+			continue
+		}
+		return !readOnlySubcommands[verb][arg]
+	}
+	return mutatingVerbs[verb]
+}
+
+// verbOf returns the sub command of the kubectl command or the method of the
+// api request with the provided args
+func verbOf(args []string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
+
+// IsSettled flags if the error of a retried command with the provided args
+// shows that an earlier attempt has made its change already i.e. a create
+// finds the object or a delete does not
+func IsSettled(args []string, err error) bool {
+	settled, ok := settlingVerbs[verbOf(args)]
+	return ok && settled(err)
+}
+
+// retriable returns the function that flags if a failed command is worth a
+// retry. A read only command is retried on transient errors. A mutating
+// command is retried on transient errors only if its retry can be settled;
+// else only on errors that guarantee it made no change.
+func retriable(isMutating exec.MutatingFunc) exec.RetriableFunc {
+	return func(args []string, err error) bool {
+		if !isMutating(args) || settlingVerbs[verbOf(args)] != nil {
+			return IsTransient(err)
+		}
+		return IsNotApplied(err)
+	}
+}
+
+// IsRetriable flags if the kubectl command with the provided args is worth a
+// retry after it failed with the provided error; see retriable
+func IsRetriable(args []string, err error) bool {
+	return retriable(IsMutating)(args, err)
+}
+
+// stubOutput is the output of a read only kubectl command that is stubbed
+// during a dry run. A json output is an empty list that decodes into an empty
+// list as well as an empty object.
+func stubOutput(args []string) string {
+	if argValue(args, "-o", "--output") == "json" {
+		return `{"items": []}`
+	}
+	return ""
+}
+
+// newExecutor builds a kubectl executor based on the environment
+func newExecutor() exec.AllExecutor {
+	return withPolicies(newCassetteExecutor(), "kubectl", IsMutating, stubOutput)
+}
+
+// newAPIExecutor builds an executor of api requests based on the environment
+//
+// NOTE:
+//  The requests are not recorded to or replayed from cassettes. Hence the
+// native backend fails every request if a cassette is set.
+func newAPIExecutor(executor exec.AllExecutor) exec.AllExecutor {
+	if cassette := util.CassetteENV(); len(cassette) != 0 {
+		return &errorExec{
+			err: fmt.Errorf("native backend does not support cassettes: cassette '%s'", cassette),
+		}
+	}
+	return withPolicies(executor, "api", isMutatingRequest, stubResponse)
+}
+
+// withPolicies wraps the executor with the audit, retry & dry run policies
+// set in the environment
+func withPolicies(executor exec.AllExecutor, binary string, isMutating exec.MutatingFunc, stub exec.StubFunc) exec.AllExecutor {
+	if auditLog := util.AuditLogENV(); len(auditLog) != 0 {
+		executor = exec.NewAuditExec(executor, auditLog)
+	}
+
+	policy := GetKubectlRetryPolicy()
+	if policy.MaxAttempts > 1 {
+		executor = exec.NewRetryExec(executor, policy, retriable(isMutating)).Settled(IsSettled)
+	}
+
+	switch mode := strings.ToLower(util.DryRunENV()); mode {
+	case "", "false":
+		return executor
+	case "true", PassthroughDryRunMode:
+		return exec.NewDryRunExec(executor, binary, isMutating, os.Stdout)
+	case StubDryRunMode:
+		return exec.NewDryRunExec(nil, binary, isMutating, os.Stdout).Stub(stub)
+	default:
+		return &errorExec{
+			err: fmt.Errorf("dry run mode '%s' is not supported", mode),
+		}
+	}
+}
+
+// isMutatingRequest flags if the api request with the provided method & path
+// changes the state of the kubernetes cluster
+func isMutatingRequest(args []string) bool {
+	return len(args) != 0 && args[0] != http.MethodGet
+}
+
+// stubResponse is the response of a read only api request that is stubbed
+// during a dry run
+func stubResponse(args []string) string {
+	return `{"items": []}`
+}
+
+// newCassetteExecutor builds an executor that records to or replays from the
+// cassette set in the environment
+func newCassetteExecutor() exec.AllExecutor {
+	var executor exec.AllExecutor = exec.NewShellExec(GetKubectlPath())
+
+	cassette := util.CassetteENV()
+	if len(cassette) == 0 {
+		return executor
+	}
+
+	mode := util.CassetteModeENV()
+	switch mode {
+	case RecordCassetteMode:
+		return exec.NewRecordExec(executor, cassette).Substitute(CassetteSubstitutions()...)
+	case string(exec.ReplayInOrderMode), string(exec.ReplayByArgsMode):
+		interactions, err := exec.LoadCassette(cassette)
+		if err != nil {
+			return &errorExec{err: err}
+		}
+		return exec.NewReplayExec(interactions, exec.ReplayMode(mode)).Substitute(CassetteSubstitutions()...)
+	default:
+		return &errorExec{
+			err: fmt.Errorf("cassette mode '%s' is not supported: cassette '%s'", mode, cassette),
+		}
+	}
+}
+
+// CassetteSubstitutions returns the substitutions of the values that differ
+// between runs i.e. the run id & the ephemeral namespace. A cassette recorded
+// with these substitutions can be replayed by any run.
+func CassetteSubstitutions() []exec.Substitution {
+	return []exec.Substitution{
+		{Placeholder: "<ephemeral-namespace>", Value: lastEphemeralNamespace},
+		{Placeholder: "<run-id>", Value: RunID},
+		{Placeholder: "<run-id>", Value: func() string { return LabelValue(RunID()) }},
+	}
+}
+
+// errorExec is an executor that fails every execution with the same error.
+// This is used to surface an invalid executor setup at every kubectl run.
+type errorExec struct {
+	err error
+}
+
+func (e *errorExec) Execute(args []string) (string, error) {
+	return "", e.err
+}
+
+func (e *errorExec) StdinExecute(args []string, stdin []byte) (string, error) {
+	return "", e.err
+}
+
+func (e *errorExec) ExecuteContext(ctx context.Context, args []string) (string, error) {
+	return "", e.err
+}
+
+func (e *errorExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (string, error) {
+	return "", e.err
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"os"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+)
+
+func TestIsMutating(t *testing.T) {
+	tests := map[string]struct {
+		args       []string
+		isMutating bool
+	}{
+		"is mutating - apply":              {args: []string{"apply", "-f", "app.yaml"}, isMutating: true},
+		"is mutating - delete":             {args: []string{"delete", "pods", "my-pod"}, isMutating: true},
+		"is mutating - cordon":             {args: []string{"cordon", "node-1"}, isMutating: true},
+		"is mutating - uncordon":           {args: []string{"uncordon", "node-1"}, isMutating: true},
+		"is mutating - get":                {args: []string{"get", "pods", "-o", "json"}, isMutating: false},
+		"is mutating - flag before verb":   {args: []string{"--kubeconfig=/root/config", "delete", "pods"}, isMutating: true},
+		"is mutating - delete as resource": {args: []string{"get", "delete"}, isMutating: false},
+		"is mutating - empty":              {args: nil, isMutating: false},
+		"is mutating - rollout undo":       {args: []string{"rollout", "undo", "deploy/minio"}, isMutating: true},
+		"is mutating - rollout restart":    {args: []string{"--namespace=litmus", "rollout", "restart", "deploy/minio"}, isMutating: true},
+		"is mutating - rollout status":     {args: []string{"rollout", "status", "deploy/minio"}, isMutating: false},
+		"is mutating - rollout history":    {args: []string{"rollout", "history", "deploy/minio"}, isMutating: false},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if IsMutating(mock.args) != mock.isMutating {
+				t.Fatalf("failed to classify args '%v': expected mutating '%t'", mock.args, mock.isMutating)
+			}
+		})
+	}
+}
+
+func TestIsRetriable(t *testing.T) {
+	refused := &exec.ExecError{ExitCode: 1, Stderr: `dial tcp 10.0.0.1:6443: connect: connection refused`}
+	reset := &exec.ExecError{ExitCode: 1, Stderr: `read: connection reset by peer`}
+	exists := &exec.ExecError{ExitCode: 1, Stderr: `Error from server (AlreadyExists): namespaces "litmus-1a2b" already exists`}
+	notFound := &exec.ExecError{ExitCode: 1, Stderr: `Error from server (NotFound): pods "my-pod" not found`}
+
+	tests := map[string]struct {
+		args        []string
+		err         error
+		isRetriable bool
+		isSettled   bool
+	}{
+		"retriable - read only on transient error": {
+			args: []string{"get", "pods"}, err: reset, isRetriable: true,
+		},
+		"retriable - apply on not applied error": {
+			args: []string{"apply", "-f", "-"}, err: refused, isRetriable: true,
+		},
+		"retriable - apply on transient error that may be applied": {
+			args: []string{"apply", "-f", "-"}, err: reset,
+		},
+		"retriable - create on transient error that may be applied": {
+			args: []string{"create", "namespace", "litmus-1a2b"}, err: reset, isRetriable: true,
+		},
+		"retriable - create settled by already exists": {
+			args: []string{"create", "namespace", "litmus-1a2b"}, err: exists, isSettled: true,
+		},
+		"retriable - delete settled by not found": {
+			args: []string{"--namespace=litmus", "delete", "pods", "my-pod"}, err: notFound, isSettled: true,
+		},
+		"retriable - post request settled by already exists": {
+			args: []string{"POST", "/api/v1/namespaces"}, err: exists, isSettled: true,
+		},
+		"retriable - apply not settled by already exists": {
+			args: []string{"apply", "-f", "-"}, err: exists,
+		},
+		"retriable - get not settled by not found": {
+			args: []string{"get", "pods", "my-pod"}, err: notFound,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if IsRetriable(mock.args, mock.err) != mock.isRetriable {
+				t.Fatalf("failed to classify '%v' '%v': expected retriable '%t'", mock.args, mock.err, mock.isRetriable)
+			}
+			if IsSettled(mock.args, mock.err) != mock.isSettled {
+				t.Fatalf("failed to classify '%v' '%v': expected settled '%t'", mock.args, mock.err, mock.isSettled)
+			}
+		})
+	}
+}
+
+func TestNewExecutorWithDryRun(t *testing.T) {
+	defer os.Unsetenv(string(util.DryRunENVK))
+
+	tests := map[string]struct {
+		dryRunVal string
+		isDryRun  bool
+	}{
+		"new executor - dry run not set":          {dryRunVal: "", isDryRun: false},
+		"new executor - dry run disabled":         {dryRunVal: "false", isDryRun: false},
+		"new executor - dry run with passthrough": {dryRunVal: "true", isDryRun: true},
+		"new executor - dry run with stubs":       {dryRunVal: "stub", isDryRun: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv(string(util.DryRunENVK), mock.dryRunVal)
+
+			_, ok := newExecutor().(*exec.DryRunExec)
+			if ok != mock.isDryRun {
+				t.Fatalf("failed to build executor: expected dry run '%t': actual '%t'", mock.isDryRun, ok)
+			}
+		})
+	}
+}
+
+func TestIsIntercepted(t *testing.T) {
+	defer os.Unsetenv(string(util.DryRunENVK))
+	defer os.Unsetenv(string(util.CassetteENVK))
+	defer SetDefaultExecutor(nil)
+
+	tests := map[string]struct {
+		dryRun      string
+		cassette    string
+		executor    exec.AllExecutor
+		intercepted bool
+	}{
+		"is intercepted - +ve test case - nothing is set": {},
+		"is intercepted - +ve test case - dry run is disabled": {
+			dryRun: "false",
+		},
+		"is intercepted - -ve test case - dry run": {
+			dryRun:      "stub",
+			intercepted: true,
+		},
+		"is intercepted - -ve test case - cassette": {
+			cassette:    "ha.jsonl",
+			intercepted: true,
+		},
+		"is intercepted - -ve test case - executor is set": {
+			executor:    exec.NewShellExec("kubectl"),
+			intercepted: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv(string(util.DryRunENVK), mock.dryRun)
+			os.Setenv(string(util.CassetteENVK), mock.cassette)
+			SetDefaultExecutor(mock.executor)
+
+			if actual := IsIntercepted(); actual != mock.intercepted {
+				t.Fatalf("failed to check interception: expected '%t': actual '%t'", mock.intercepted, actual)
+			}
+		})
+	}
+}
+
+func TestTypedGetWithStubDryRun(t *testing.T) {
+	os.Setenv(string(util.DryRunENVK), StubDryRunMode)
+	defer os.Unsetenv(string(util.DryRunENVK))
+
+	k := New().Executor(newExecutor())
+	pods, err := k.Client().ListPods()
+	if err != nil || len(pods) != 0 {
+		t.Fatalf("failed to list pods with stubs: expected 'no pods': actual '%v': error '%v'", pods, err)
+	}
+	pod, err := k.Client().GetPod("minio")
+	if err != nil || len(pod.Metadata.Name) != 0 {
+		t.Fatalf("failed to get pod with stubs: expected 'empty pod': actual '%v': error '%v'", pod, err)
+	}
+	if err = k.Client().DeletePod("minio"); err != nil {
+		t.Fatalf("failed to delete pod with stubs: expected 'no error': actual '%s'", err)
+	}
+}
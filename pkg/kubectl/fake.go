@@ -28,6 +28,10 @@ func (k *mockKubectl) StdinRun(args []string, stdin []byte) (output string, err
 	return
 }
 
+func (k *mockKubectl) Client() Client {
+	return &mockClient{output: "mocked"}
+}
+
 type mockKubectlNoOutput struct{}
 
 func (k *mockKubectlNoOutput) Run(args []string) (output string, err error) {
@@ -38,6 +42,75 @@ func (k *mockKubectlNoOutput) StdinRun(args []string, stdin []byte) (output stri
 	return
 }
 
+func (k *mockKubectlNoOutput) Client() Client {
+	return &mockClient{}
+}
+
+// mockClient is a Client that fills the names, addresses & phases of the
+// objects it returns with its output
+type mockClient struct {
+	output string
+}
+
+func (c *mockClient) ListPods() (pods []Pod, err error) {
+	if len(c.output) == 0 {
+		return
+	}
+	pod, err := c.GetPod(c.output)
+	pods = append(pods, pod)
+	return
+}
+
+func (c *mockClient) GetPod(name string) (pod Pod, err error) {
+	pod.Metadata.Name = name
+	pod.Spec.NodeName = c.output
+	pod.Status.Phase = c.output
+	return
+}
+
+func (c *mockClient) DeletePod(name string) (err error) {
+	return
+}
+
+func (c *mockClient) ListNodes() (nodes []Node, err error) {
+	if len(c.output) == 0 {
+		return
+	}
+	nodes = append(nodes, Node{Metadata: ObjectMeta{Name: c.output}})
+	return
+}
+
+func (c *mockClient) GetNode(name string) (node Node, err error) {
+	node.Metadata.Name = name
+	return
+}
+
+func (c *mockClient) CordonNode(name string) (err error) {
+	return
+}
+
+func (c *mockClient) UncordonNode(name string) (err error) {
+	return
+}
+
+func (c *mockClient) GetService(name string) (service Service, err error) {
+	service.Metadata.Name = name
+	service.Spec.ClusterIP = c.output
+	return
+}
+
+func (c *mockClient) GetJob(name string) (job Job, err error) {
+	job.Metadata.Name = name
+	return
+}
+
+func (c *mockClient) GetPVC(name string) (pvc PersistentVolumeClaim, err error) {
+	pvc.Metadata.Name = name
+	pvc.Spec.VolumeName = c.output
+	pvc.Status.Phase = c.output
+	return
+}
+
 type MockKubeFactory struct{}
 
 func (m *MockKubeFactory) NewInstance(namespace string) KubeAllRunner {
@@ -17,8 +17,11 @@ limitations under the License.
 package kubectl
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/AmitKumarDas/elitmus/pkg/exec"
 	"github.com/AmitKumarDas/elitmus/pkg/util"
@@ -35,6 +38,10 @@ type TemplatedKubectlFile string
 const (
 	// KubectlPath is the expected location where kubectl executable may be found
 	KubectlPath = "/usr/local/bin/kubectl"
+
+	// KubectlTimeout is the default duration a kubectl command is allowed to
+	// run before getting killed
+	KubectlTimeout = 5 * time.Minute
 )
 
 const (
@@ -46,21 +53,40 @@ const (
 // kubectlArgs builds the arguments required to execute any kubectl command
 //
 // This has been borrowed from https://github.com/CanopyTax/ckube
-func kubectlArgs(args []string, namespace string, context string, labels string) []string {
+func kubectlArgs(args []string, kubeconfig string, namespace string, context string, labels string) []string {
+	kubeconfig = strings.TrimSpace(kubeconfig)
 	namespace = strings.TrimSpace(namespace)
 	context = strings.TrimSpace(context)
 	labels = strings.TrimSpace(labels)
 
+	var flags []string
+	if len(kubeconfig) != 0 {
+		flags = append(flags, fmt.Sprintf("--kubeconfig=%v", kubeconfig))
+	}
 	if len(namespace) != 0 {
-		args = append(args, fmt.Sprintf("--namespace=%v", namespace))
+		flags = append(flags, fmt.Sprintf("--namespace=%v", namespace))
 	}
 	if len(context) != 0 {
-		args = append(args, fmt.Sprintf("--context=%v", context))
+		flags = append(flags, fmt.Sprintf("--context=%v", context))
 	}
 	if len(labels) != 0 {
-		args = append(args, fmt.Sprintf("--selector=%v", labels))
+		flags = append(flags, fmt.Sprintf("--selector=%v", labels))
+	}
+	if len(flags) == 0 {
+		return args
 	}
-	return args
+
+	// the args after a "--" separator belong to the command that is run
+	// inside a container; hence the flags are placed before the separator
+	for i, arg := range args {
+		if arg == "--" {
+			all := make([]string, 0, len(args)+len(flags))
+			all = append(all, args[:i]...)
+			all = append(all, flags...)
+			return append(all, args[i:]...)
+		}
+	}
+	return append(args, flags...)
 }
 
 // KubeRunner interface provides the contract i.e. method signature to
@@ -108,15 +134,29 @@ func (k *kubeFactory) NewInstance(namespace string) KubeAllRunner {
 // Kubectl holds the properties required to execute any kubectl command.
 // Kubectl is an implementation of following interfaces:
 // 1. KubeRunner
+//
+// NOTE:
+//  Kubectl is immutable; its setters return a modified copy & its runs do not
+// modify it. Hence an instance is safe for concurrent use.
 type Kubectl struct {
+	// kubeconfig is the path to the kubeconfig file used by this kubectl
+	// command
+	kubeconfig string
 	// namespace where this kubectl command will be run
 	namespace string
+	// pinned flags if the namespace is used as is even if an ephemeral
+	// namespace replaces it; an empty pinned namespace implies cluster scope
+	pinned bool
 	// labels to be used during kubectl execution
 	labels string
 	// context where this kubectl command will be run
 	context string
-	// args are provided to kubectl command during its run
+	// args are prepended to the args of every kubectl command run by this
+	// instance
 	args []string
+	// timeout is the maximum duration this kubectl command is allowed to run;
+	// zero implies no timeout
+	timeout time.Duration
 	// executor does actual kubectl execution
 	executor exec.AllExecutor
 }
@@ -134,276 +174,410 @@ func GetKubectlPath() string {
 	return kpath
 }
 
+// GetKubectlTimeout gets the duration a kubectl command is allowed to run
+//
+// NOTE:
+//  A zero duration implies no timeout
+func GetKubectlTimeout() time.Duration {
+	// get from environment variable
+	ktimeout := util.KubectlTimeoutENV()
+	if len(ktimeout) == 0 {
+		// else use the constant
+		return KubectlTimeout
+	}
+
+	t, err := time.ParseDuration(ktimeout)
+	if err != nil || t < 0 {
+		// fallback to the constant on invalid values
+		return KubectlTimeout
+	}
+
+	return t
+}
+
 // New returns a new instance of kubectl based on defaults
+//
+// NOTE:
+//  The kubeconfig, context & namespace are resolved from the environment
+// before falling back to defaults
 func New() *Kubectl {
+	config := ResolveConfig(Config{})
 	return &Kubectl{
-		namespace: DefaultLitmusNamespace,
-		executor:  exec.NewShellExec(GetKubectlPath()),
+		kubeconfig: config.KubeConfig,
+		namespace:  config.Namespace,
+		context:    config.Context,
+		timeout:    GetKubectlTimeout(),
+		executor:   DefaultExecutor(),
 	}
 }
 
-// Namespace sets the namespace to be used during kubectl run
+// clone returns a copy of this instance that can be modified without
+// affecting the receiver
+func (k *Kubectl) clone() *Kubectl {
+	c := *k
+	c.args = append([]string(nil), k.args...)
+	return &c
+}
+
+// KubeConfig returns a copy of this instance that uses the provided kubeconfig
+// file. An empty value resolves to the kubeconfig set in the environment.
+func (k *Kubectl) KubeConfig(kubeconfig string) *Kubectl {
+	c := k.clone()
+	c.kubeconfig = ResolveConfig(Config{KubeConfig: kubeconfig}).KubeConfig
+	return c
+}
+
+// Namespace returns a copy of this instance that runs in the provided
+// namespace. An empty value resolves to the namespace set in the environment
+// or the default namespace; see ClusterScoped to run without a namespace.
 func (k *Kubectl) Namespace(namespace string) *Kubectl {
-	k.namespace = namespace
-	return k
+	c := k.clone()
+	c.namespace = ResolveConfig(Config{Namespace: namespace}).Namespace
+	c.pinned = false
+	return c
+}
+
+// PinNamespace returns a copy of this instance that runs in the provided
+// namespace even if an ephemeral namespace replaces it. This is useful to
+// create objects that should outlive the ephemeral namespace.
+//
+// NOTE:
+//  The namespaces of the applied manifests are not rewritten either
+func (k *Kubectl) PinNamespace(namespace string) *Kubectl {
+	c := k.Namespace(namespace)
+	c.pinned = true
+	return c
+}
+
+// ClusterScoped returns a copy of this instance that runs without any
+// namespace. This is useful to operate on cluster scoped objects e.g. nodes
+// & namespaces or on the objects of all the namespaces via --all-namespaces.
+func (k *Kubectl) ClusterScoped() *Kubectl {
+	c := k.clone()
+	c.namespace = ""
+	c.pinned = true
+	return c
+}
+
+// resolvedNamespace returns the namespace this instance actually runs in
+func (k *Kubectl) resolvedNamespace() string {
+	if k.pinned {
+		return k.namespace
+	}
+	return ResolveNamespace(k.namespace)
 }
 
-// Labels sets the labels to be used during kubectl run
+// Labels returns a copy of this instance that uses the provided labels
 func (k *Kubectl) Labels(labels string) *Kubectl {
-	k.labels = labels
-	return k
+	c := k.clone()
+	c.labels = labels
+	return c
 }
 
-// Context sets the context to be used during kubectl run
+// Context returns a copy of this instance that runs in the provided context.
+// An empty value resolves to the context set in the environment.
 func (k *Kubectl) Context(context string) *Kubectl {
-	k.context = context
-	return k
+	c := k.clone()
+	c.context = ResolveConfig(Config{Context: context}).Context
+	return c
 }
 
-// Args sets the args to be used during kubectl run
+// Args returns a copy of this instance with the provided args. These args
+// are prepended to the args of every run.
 func (k *Kubectl) Args(args []string) *Kubectl {
-	k.args = args
-	return k
+	c := k.clone()
+	c.args = append([]string(nil), args...)
+	return c
+}
+
+// Executor returns a copy of this instance that uses the provided executor
+// to do the actual kubectl execution
+func (k *Kubectl) Executor(executor exec.AllExecutor) *Kubectl {
+	c := k.clone()
+	c.executor = executor
+	return c
+}
+
+// Retry returns a copy of this instance that retries its commands with
+// exponential backoff if they fail with transient errors; see IsRetriable &
+// IsSettled for the mutating commands
+func (k *Kubectl) Retry(maxAttempts int, maxElapsed time.Duration) *Kubectl {
+	c := k.clone()
+	c.executor = exec.NewRetryExec(k.executor, exec.DefaultRetryPolicy(maxAttempts, maxElapsed), IsRetriable).Settled(IsSettled)
+	return c
 }
 
-// Run will execute the kubectl command & provide output or error
+// Timeout returns a copy of this instance that is allowed to run for the
+// provided duration. A zero duration implies no timeout.
+func (k *Kubectl) Timeout(timeout time.Duration) *Kubectl {
+	c := k.clone()
+	c.timeout = timeout
+	return c
+}
+
+// GoString returns the properties of this instance in a form that is
+// suitable for error messages
+func (k *Kubectl) GoString() string {
+	return fmt.Sprintf("kubectl{kubeconfig: '%s', context: '%s', namespace: '%s', labels: '%s', args: %q, timeout: '%s'}",
+		k.kubeconfig, k.context, k.namespace, k.labels, k.args, k.timeout)
+}
+
+// newContext returns a context that is bounded by this instance's timeout
+func (k *Kubectl) newContext() (context.Context, context.CancelFunc) {
+	if k.timeout > 0 {
+		return context.WithTimeout(context.Background(), k.timeout)
+	}
+
+	return context.WithCancel(context.Background())
+}
+
+// Command returns the complete list of args for the provided kubectl args.
+// This is useful to run kubectl commands that do not complete on their own
+// e.g. port-forward.
+//
+// NOTE:
+//  This does not modify the instance & hence is safe for concurrent use. The
+// namespace is replaced by the ephemeral namespace if it is the base
+// namespace of the latter & is not pinned.
+func (k *Kubectl) Command(args []string) []string {
+	all := make([]string, 0, len(k.args)+len(args))
+	all = append(all, k.args...)
+	all = append(all, args...)
+	return kubectlArgs(all, k.kubeconfig, k.resolvedNamespace(), k.context, k.labels)
+}
+
+// Run will execute the kubectl command & provide output or error. The objects
+// created by an apply are tracked by the default resource tracker.
+//
+// NOTE:
+//  The manifests of an apply are applied from stdin if their objects are
+// labelled with the run labels or if their namespaces are rewritten to the
+// ephemeral namespace
 func (k *Kubectl) Run(args []string) (output string, err error) {
-	k.args = kubectlArgs(args, k.namespace, k.context, k.labels)
+	if isApply(args) {
+		if rewritten, stdin, ok := k.rewriteManifests(args, nil); ok {
+			return k.stdinRun(rewritten, stdin)
+		}
+	}
 
-	output, err = k.executor.Execute(k.args)
+	ctx, cancel := k.newContext()
+	defer cancel()
+
+	output, err = k.executor.ExecuteContext(ctx, k.Command(args))
+	if err == nil && isApply(args) {
+		defaultResourceTracker.trackApply(k.resolvedNamespace(), args, nil, output)
+	}
 	return
 }
 
-// StdinRun will execute the kubectl command & provide output or error
+// StdinRun will execute the kubectl command & provide output or error. The
+// objects created by an apply are tracked by the default resource tracker.
 func (k *Kubectl) StdinRun(args []string, stdin []byte) (output string, err error) {
-	k.args = kubectlArgs(args, k.namespace, k.context, k.labels)
+	if isApply(args) {
+		args, stdin, _ = k.rewriteManifests(args, stdin)
+	}
+	return k.stdinRun(args, stdin)
+}
 
-	output, err = k.executor.StdinExecute(k.args, stdin)
+// stdinRun executes the kubectl command with the provided stdin & tracks the
+// objects created by an apply
+func (k *Kubectl) stdinRun(args []string, stdin []byte) (output string, err error) {
+	ctx, cancel := k.newContext()
+	defer cancel()
+
+	output, err = k.executor.StdinExecuteContext(ctx, k.Command(args), stdin)
+	if err == nil && isApply(args) {
+		defaultResourceTracker.trackApply(k.resolvedNamespace(), args, stdin, output)
+	}
 	return
 }
 
+// rewriteManifests rewrites the namespaces of the objects in the applied
+// manifests & labels them with the run labels; see rewriteNamespaces &
+// labelManifests
+func (k *Kubectl) rewriteManifests(args []string, stdin []byte) (rewritten []string, data []byte, ok bool) {
+	rewritten, data, namespaced := args, stdin, false
+	if !k.pinned {
+		rewritten, data, namespaced = rewriteNamespaces(args, stdin)
+	}
+	rewritten, data, labelled := k.labelManifests(rewritten, data)
+	return rewritten, data, namespaced || labelled
+}
+
 // ArePodsRunning returns true if all the pod(s) are running, false otherwise
 //
-// An example of kubectl get pods & its state:
-//  This makes use of status.containerStatuses[*].state.*.reason
-//
-// $ kubectl get po -n kube-system --selector=k8s-app=kube-dns -o jsonpath='{.items[*].status.containerStatuses[*].state.*.reason}'
-// CrashLoopBackOff CrashLoopBackOff
-//
-// Another example of kubectl get pods & its state:
-//  This makes use of status.containerStatuses[*].ready
-//
-// $ kubectl get pods -n kube-system --selector=k8s-app=kube-dns -o jsonpath='{.items[*].status.containerStatuses[*].ready}'
-// true false true
+// NOTE:
+//  A pod is considered to be running if it is in Running phase, all its init
+// containers have completed & all its containers are ready
 func ArePodsRunning(k KubeRunner) (yes bool, err error) {
-	isReady, err := k.Run([]string{"get", "pods", "-o", "jsonpath='{.items[*].status.containerStatuses[*].ready}'"})
+	pods, err := clientFor(k).ListPods()
 	if err != nil {
 		return
 	}
 
-	// split the output by space
-	isReadyArr := strings.Split(strings.TrimSpace(isReady), " ")
-
-	if contains(isReadyArr, "false") {
-		err = fmt.Errorf("pod(s) are not running: '%#v' '%#v'", k, isReadyArr)
+	if len(pods) == 0 {
+		err = fmt.Errorf("status of pod(s) could not be determined: no pods found: '%#v'", k)
 		return
 	}
 
-	// double check
-	if contains(isReadyArr, "true") {
-		yes = true
-	} else {
-		err = fmt.Errorf("status of pod(s) could not be determined: '%#v' '%#v'", k, isReadyArr)
+	for _, p := range pods {
+		if reason := podNotRunningReason(p); len(reason) != 0 {
+			err = fmt.Errorf("pod(s) are not running: %s: '%#v'", reason, k)
+			return
+		}
 	}
 
+	yes = true
 	return
 }
 
 // IsPodRunning returns true if the specified pod is running, false otherwise
 //
-// An example of kubectl get pods & its state:
-//  This makes use of status.containerStatuses[*].state.*.reason
-//
-// $ kubectl get po -n kube-system my-pod -o jsonpath='{.status.containerStatuses[*].state.*.reason}'
-// CrashLoopBackOff CrashLoopBackOff
-//
-// Another example of kubectl get pods & its state:
-//  This makes use of status.containerStatuses[*].ready
-//
-// $ kubectl get pods -n kube-system my-pod -o jsonpath='{.status.containerStatuses[*].ready}'
-// true false true
+// NOTE:
+//  A pod is considered to be running if it is in Running phase, all its init
+// containers have completed & all its containers are ready
 func IsPodRunning(k KubeRunner, name string) (yes bool, err error) {
 	if len(name) == 0 {
 		err = fmt.Errorf("unable to determine pod running status: pod name is missing")
 		return
 	}
 
-	isReady, err := k.Run([]string{"get", "pods", name, "-o", "jsonpath='{.status.containerStatuses[*].ready}'"})
+	pod, err := clientFor(k).GetPod(name)
 	if err != nil {
 		return
 	}
 
-	// split the output by space
-	isReadyArr := strings.Split(strings.TrimSpace(isReady), " ")
-
-	if contains(isReadyArr, "false") {
-		err = fmt.Errorf("pod '%s' is not running: '%#v'", name, isReadyArr)
+	if reason := podNotRunningReason(pod); len(reason) != 0 {
+		err = fmt.Errorf("pod '%s' is not running: %s", name, reason)
 		return
 	}
 
-	// double check
-	if contains(isReadyArr, "true") {
-		yes = true
-	} else {
-		err = fmt.Errorf("status of pod '%s' could not be determined: received output '%#v'", name, isReadyArr)
-	}
-
+	yes = true
 	return
 }
 
 // GetPodNodes fetches the nodes that hosts the pods. Pods are referred to
 // via the provided labels
 func GetPodNodes(k KubeRunner) (nodes []string, err error) {
-	n, err := k.Run([]string{"get", "pods", "-o", "jsonpath='{.items[*].spec.nodeName}'"})
+	pods, err := clientFor(k).ListPods()
 	if err != nil {
 		return
 	}
 
-	// split the output by space
-	nodes = strings.Split(strings.TrimSpace(n), " ")
+	for _, p := range pods {
+		nodes = append(nodes, p.Spec.NodeName)
+	}
 	return
 }
 
 // GetAllPodNames fetches the names of all the pods based on the labels & namespace set
 // against the KubeRunner
 func GetAllPodNames(k KubeRunner) (pods []string, err error) {
-	p, err := k.Run([]string{"get", "pods", "-o", "jsonpath='{.items[*].metadata.name}'"})
+	l, err := clientFor(k).ListPods()
 	if err != nil {
 		return
 	}
 
-	// split the output by space
-	pods = strings.Split(strings.TrimSpace(p), " ")
+	for _, p := range l {
+		pods = append(pods, p.Metadata.Name)
+	}
 	return
 }
 
 // GetAllNodeNames fetches the names of all the nodes registered to the cluster
 func GetAllNodeNames(k KubeRunner) (nodes []string, err error) {
-	op, err := k.Run([]string{"get", "nodes", "-o", "jsonpath='{.items[*].metadata.name}'"})
+	l, err := clientFor(k).ListNodes()
 	if err != nil {
 		return
 	}
 
-	// split the output by space
-	nodes = strings.Split(strings.TrimSpace(op), " ")
+	for _, n := range l {
+		nodes = append(nodes, n.Metadata.Name)
+	}
 	return
 }
 
 // GetRunningPods fetches the pods which are running based on the provided labels
-//
-// Sample code to do this:
-//
-// $ JSONPATH='{range .items[*]}{@.metadata.name}::{@.status.containerStatuses[*].ready}::::{end}'  && kubectl get po -n kube-system -o jsonpath="$JSONPATH"
-// kube-addon-manager-amit-thinkpad-l470::true::::kube-dns-54cccfbdf8-q7v2c::false false true::::kubernetes-dashboard-77d8b98585-cwbjq::false::::storage-provisioner::true::::tiller-deploy-5b48764ff7-g9qz7::true::::
 func GetRunningPods(k KubeRunner) (pods []string, err error) {
-	// fetch pods
-	o, err := k.Run([]string{"get", "pods", "-o", "jsonpath='{range .items[*]}{@.metadata.name}::{@.status.containerStatuses[*].ready}::::{end}'"})
+	l, err := clientFor(k).ListPods()
 	if err != nil {
 		return
 	}
 
-	// split the output by the splitter used in above command
-	firstSplit := strings.Split(strings.TrimSpace(o), "::::")
-	for _, fs := range firstSplit {
-		if len(fs) == 0 {
-			continue
-		}
-
-		secondSplit := strings.Split(strings.TrimSpace(fs), "::")
-		// ignore if pod is not running
-		if strings.Contains(secondSplit[1], "false") {
-			continue
-		}
-
-		// add the running pod to the list
-		if strings.Contains(secondSplit[1], "true") {
-			pods = append(pods, secondSplit[0])
+	for _, p := range l {
+		if len(podNotRunningReason(p)) == 0 {
+			pods = append(pods, p.Metadata.Name)
 		}
 	}
-
 	return
 }
 
 // GetOldestRunningPod fetches the oldest running pod based on the provided labels
 // and sorted based on their age
-//
-// Sample code to do this:
-//
-// $ JSONPATH='{range .items[*]}{@.metadata.name}::{@.status.containerStatuses[*].ready}::::{end}'  && kubectl get po -n kube-system --sort-by=.metadata.creationTimestamp -o jsonpath="$JSONPATH"
-// kube-addon-manager-amit-thinkpad-l470::true::::kube-dns-54cccfbdf8-q7v2c::false false true::::kubernetes-dashboard-77d8b98585-cwbjq::false::::storage-provisioner::true::::tiller-deploy-5b48764ff7-g9qz7::true::::
 func GetOldestRunningPod(k KubeRunner) (pod string, err error) {
-	// fetch pods sorted by creation timestamp
-	o, err := k.Run([]string{"get", "pods", "--sort-by=.metadata.creationTimestamp", "-o", "jsonpath='{range .items[*]}{@.metadata.name}::{@.status.containerStatuses[*].ready}::::{end}'"})
+	l, err := clientFor(k).ListPods()
 	if err != nil {
 		return
 	}
 
-	// split the output by the splitter used in above command
-	firstSplit := strings.Split(strings.TrimSpace(o), "::::")
-	for _, fs := range firstSplit {
-		if len(fs) == 0 {
-			continue
-		}
-
-		secondSplit := strings.Split(strings.TrimSpace(fs), "::")
-		// ignore if pod is not running
-		if strings.Contains(secondSplit[1], "false") {
-			continue
-		}
+	// sort the pods by their creation timestamp
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].Metadata.CreationTimestamp.Before(l[j].Metadata.CreationTimestamp)
+	})
 
-		// return the first running pod
-		if strings.Contains(secondSplit[1], "true") {
-			pod = secondSplit[0]
+	// return the first running pod
+	for _, p := range l {
+		if len(podNotRunningReason(p)) == 0 {
+			pod = p.Metadata.Name
 			return
 		}
 	}
-
 	return
 }
 
 // DeletePod deletes the specified pod
 func DeletePod(k KubeRunner, name string) (err error) {
-	_, err = k.Run([]string{"delete", "pods", name})
-	return
+	return clientFor(k).DeletePod(name)
 }
 
-// CordonNodeWithPod cordons the node that host the specified pod
+// CordonNodeWithPod cordons the node that host the specified pod. The cordon
+// is recorded in the default node ledger & is undone by RestoreNodes.
 func CordonNodeWithPod(k KubeRunner, pod string) (err error) {
-	op, err := k.Run([]string{"get", "pods", pod, "-o", "jsonpath='{.spec.nodeName}'"})
+	p, err := clientFor(k).GetPod(pod)
 	if err != nil {
 		return
 	}
 
-	node := strings.TrimSpace(op)
+	node := strings.TrimSpace(p.Spec.NodeName)
 	if len(node) == 0 {
 		err = fmt.Errorf("unable to cordon node: node not found for pod '%s'", pod)
 		return
 	}
 
-	_, err = k.Run([]string{"cordon", node})
-	return
+	return defaultNodeLedger.Cordon(k, node)
 }
 
 // GetServiceIP gets the cluster IP address of the service
 func GetServiceIP(k KubeRunner, service string) (ip string, err error) {
-	return k.Run([]string{"get", "services", service, "-o", "jsonpath='{.spec.clusterIP}'"})
+	s, err := clientFor(k).GetService(service)
+	if err != nil {
+		return
+	}
+
+	ip = s.Spec.ClusterIP
+	return
 }
 
 // IsJobCompleted flags if the job is completed
 func IsJobCompleted(k KubeRunner, name string) (yes bool, err error) {
-	op, _ := k.Run([]string{"get", "jobs", name, "-o", "jsonpath='{.status.succeeded}'"})
-	if op == "1" {
+	job, err := clientFor(k).GetJob(name)
+	if err != nil && !IsNotFound(err) {
+		return
+	}
+
+	if job.Status.Succeeded > 0 {
 		yes = true
+		err = nil
 	} else {
 		err = fmt.Errorf("job is not completed: command '%#v'", k)
 	}
@@ -415,16 +589,19 @@ func IsJobCompleted(k KubeRunner, name string) (yes bool, err error) {
 //
 // NOTE: This expects the KubeRunner with appropriate label selector
 func AreJobPodsCompleted(k KubeRunner) (yes bool, err error) {
-	op, err := k.Run([]string{"get", "pods", "-o", "jsonpath='{.items[*].status.phase}'"})
+	pods, err := clientFor(k).ListPods()
 	if err != nil {
 		return
 	}
 
-	// split the output by space
-	states := strings.Split(strings.ToLower(op), " ")
-	for _, state := range states {
-		if state != "succeeded" {
-			err = fmt.Errorf("job pod is not completed '%#v': output '%#v'", k, states)
+	if len(pods) == 0 {
+		err = fmt.Errorf("job pod is not completed '%#v': no pods found", k)
+		return
+	}
+
+	for _, p := range pods {
+		if p.Status.Phase != PodSucceeded {
+			err = fmt.Errorf("job pod '%s' is not completed: pod is in '%s' phase", p.Metadata.Name, p.Status.Phase)
 			return
 		}
 	}
@@ -434,14 +611,20 @@ func AreJobPodsCompleted(k KubeRunner) (yes bool, err error) {
 }
 
 // UnCordonAllNodes uncordons all the nodes in the cluster
+//
+// NOTE:
+//  This uncordons the nodes that were cordoned by others as well. Prefer
+// RestoreNodes which undoes only the changes made by litmus.
 func UnCordonAllNodes(ignoreError bool) (err error) {
-	nodes, err := GetAllNodeNames(New())
+	c := New().Client()
+
+	nodes, err := c.ListNodes()
 	if err != nil {
 		return
 	}
 
 	for _, n := range nodes {
-		_, err = New().Run([]string{"uncordon", n})
+		err = c.UncordonNode(n.Metadata.Name)
 		if !ignoreError && err != nil {
 			return
 		}
@@ -450,19 +633,82 @@ func UnCordonAllNodes(ignoreError bool) (err error) {
 	return
 }
 
+// GetObject fetches the object of the provided kind & name
+//
+// NOTE:
+//  This works with any kind & hence always makes use of kubectl
+func GetObject(k KubeRunner, kind, name string) (obj Object, err error) {
+	err = (&shellClient{runner: k}).get(&obj, "get", kind, name)
+	return
+}
+
+// ListObjects fetches the objects of the provided kind based on the labels &
+// namespace set against the KubeRunner
+//
+// NOTE:
+//  This works with any kind & hence always makes use of kubectl
+func ListObjects(k KubeRunner, kind string) (objs []Object, err error) {
+	var l ObjectList
+	err = (&shellClient{runner: k}).get(&l, "get", kind)
+	return l.Items, err
+}
+
+// GetConfigMap fetches the config map based on the namespace set against the
+// KubeRunner
+func GetConfigMap(k KubeRunner, name string) (cm ConfigMap, err error) {
+	err = (&shellClient{runner: k}).get(&cm, "get", "configmap", name)
+	return
+}
+
+// GetObjectState fetches the object of the provided kind & name along with
+// its state based on the namespace set against the KubeRunner
+//
+// NOTE:
+//  This works with any kind & hence always makes use of kubectl
+func GetObjectState(k KubeRunner, kind, name string) (obj ObjectState, err error) {
+	err = (&shellClient{runner: k}).get(&obj, "get", kind, name)
+	return
+}
+
+// ListObjectStates fetches the objects of the provided kind along with their
+// state based on the labels & namespace set against the KubeRunner
+//
+// NOTE:
+//  This works with any kind & hence always makes use of kubectl
+func ListObjectStates(k KubeRunner, kind string) (objs []ObjectState, err error) {
+	var l ObjectStateList
+	err = (&shellClient{runner: k}).get(&l, "get", kind)
+	return l.Items, err
+}
+
 // ApplyStdIn does a kubectl apply from stdin
 func ApplyStdIn(stdin []byte) (err error) {
 	_, err = New().StdinRun([]string{"apply", "-f", "-"}, stdin)
 	return
 }
 
-// contains verifies if a specific element is present in the provided array
-func contains(s []string, e string) bool {
-	for _, a := range s {
-		a = strings.TrimSpace(a)
-		if a == e {
-			return true
+// podNotRunningReason returns the reason why the pod is not running. An empty
+// reason implies the pod is running.
+func podNotRunningReason(p Pod) string {
+	if p.Status.Phase != PodRunning {
+		return fmt.Sprintf("pod '%s' is in '%s' phase", p.Metadata.Name, p.Status.Phase)
+	}
+
+	for _, cs := range p.Status.InitContainerStatuses {
+		if !cs.Ready {
+			return fmt.Sprintf("init container '%s' of pod '%s' has not completed", cs.Name, p.Metadata.Name)
+		}
+	}
+
+	if len(p.Status.ContainerStatuses) == 0 {
+		return fmt.Sprintf("pod '%s' has no container statuses", p.Metadata.Name)
+	}
+
+	for _, cs := range p.Status.ContainerStatuses {
+		if !cs.Ready {
+			return fmt.Sprintf("container '%s' of pod '%s' is not ready", cs.Name, p.Metadata.Name)
 		}
 	}
-	return false
+
+	return ""
 }
@@ -17,9 +17,18 @@ limitations under the License.
 package kubectl
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
 	"github.com/AmitKumarDas/elitmus/pkg/util"
 )
 
@@ -62,14 +71,64 @@ func TestGetKubectlPath(t *testing.T) {
 	}
 }
 
+func TestGetKubectlTimeout(t *testing.T) {
+	if envVal := util.KubectlTimeoutENV(); len(envVal) != 0 {
+		os.Unsetenv(string(util.KubectlTimeoutENVK))
+		defer func() { os.Setenv(string(util.KubectlTimeoutENVK), envVal) }()
+	} else {
+		defer os.Unsetenv(string(util.KubectlTimeoutENVK))
+	}
+
+	tests := map[string]struct {
+		kubectlTimeoutVal string
+		expected          time.Duration
+	}{
+		"get kubectl timeout: positive test case - with env set - 1": {
+			kubectlTimeoutVal: "90s",
+			expected:          90 * time.Second,
+		},
+		"get kubectl timeout: positive test case - with env set to zero": {
+			kubectlTimeoutVal: "0s",
+			expected:          0,
+		},
+		"get kubectl timeout: positive test case - env not set": {
+			// no value will be set in env
+			kubectlTimeoutVal: "",
+			expected:          KubectlTimeout,
+		},
+		"get kubectl timeout: negative test case - invalid env value": {
+			kubectlTimeoutVal: "five minutes",
+			expected:          KubectlTimeout,
+		},
+		"get kubectl timeout: negative test case - negative env value": {
+			kubectlTimeoutVal: "-5m",
+			expected:          KubectlTimeout,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			// set a test value in env before testing
+			os.Setenv(string(util.KubectlTimeoutENVK), mock.kubectlTimeoutVal)
+			// function under test
+			d := GetKubectlTimeout()
+
+			if d != mock.expected {
+				t.Fatalf("failed to get kubectl timeout: expected '%s': actual '%s'", mock.expected, d)
+			}
+		})
+	}
+}
+
 func TestKubeCtlArgs(t *testing.T) {
 	tests := map[string]struct {
-		args      []string
-		namespace string
-		context   string
-		labels    string
-		expected  []string
-		isEmpty   bool
+		args       []string
+		kubeconfig string
+		namespace  string
+		context    string
+		labels     string
+		expected   []string
+		isEmpty    bool
 	}{
 		"kubectl args - positive test case": {
 			args:      []string{"kubectl", "get", "po", "my-pod"},
@@ -79,6 +138,21 @@ func TestKubeCtlArgs(t *testing.T) {
 			expected:  []string{"kubectl", "get", "po", "my-pod", "--namespace=litmus", "--selector=name=my-pod"},
 			isEmpty:   false,
 		},
+		"kubectl args - positive test case - with kubeconfig & context": {
+			args:       []string{"get", "po"},
+			kubeconfig: "/home/litmus/.kube/config",
+			namespace:  "litmus",
+			context:    "admin",
+			labels:     "",
+			expected:   []string{"get", "po", "--kubeconfig=/home/litmus/.kube/config", "--namespace=litmus", "--context=admin"},
+			isEmpty:    false,
+		},
+		"kubectl args - positive test case - with separator": {
+			args:      []string{"exec", "my-pod", "--", "ls", "-l"},
+			namespace: "litmus",
+			expected:  []string{"exec", "my-pod", "--namespace=litmus", "--", "ls", "-l"},
+			isEmpty:   false,
+		},
 		"kubectl args - negative test case - empty": {
 			args:      []string{},
 			namespace: "",
@@ -99,7 +173,7 @@ func TestKubeCtlArgs(t *testing.T) {
 
 	for name, mock := range tests {
 		t.Run(name, func(t *testing.T) {
-			ops := kubectlArgs(mock.args, mock.namespace, mock.context, mock.labels)
+			ops := kubectlArgs(mock.args, mock.kubeconfig, mock.namespace, mock.context, mock.labels)
 
 			if len(ops) == 0 && !mock.isEmpty {
 				t.Fatalf("failed to execute kubectl args: expected 'non nil output': actual 'nil output'")
@@ -108,6 +182,12 @@ func TestKubeCtlArgs(t *testing.T) {
 			if len(ops) != len(mock.expected) {
 				t.Fatalf("failed to execute kubectl args: expected output '%s': actual output '%s'", mock.expected, ops)
 			}
+
+			for i := range ops {
+				if ops[i] != mock.expected[i] {
+					t.Fatalf("failed to execute kubectl args: expected output '%s': actual output '%s'", mock.expected, ops)
+				}
+			}
 		})
 	}
 }
@@ -210,3 +290,372 @@ func TestArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeout(t *testing.T) {
+	tests := map[string]struct {
+		timeout time.Duration
+	}{
+		"timeout - +ve test case - non-zero timeout": {
+			timeout: 10 * time.Second,
+		},
+		"timeout - +ve test case - zero timeout": {
+			timeout: 0,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			kctl := New()
+			kctl = kctl.Timeout(mock.timeout)
+
+			if kctl == nil {
+				t.Fatalf("failed to execute timeout: expected 'non nil kubectl': actual 'nil kubectl'")
+			}
+
+			if kctl.timeout != mock.timeout {
+				t.Fatalf("failed to execute timeout: expected '%s': actual '%s'", mock.timeout, kctl.timeout)
+			}
+		})
+	}
+}
+
+func TestReplayCordonNodeWithOldestPod(t *testing.T) {
+	replayer := exec.NewReplayExec([]exec.Interaction{
+		{
+			Args: []string{"get", "pods", "-o", "json", "--namespace=litmus", "--selector=app=minio"},
+			Stdout: `{"items": [
+				{"metadata": {"name": "minio-3", "creationTimestamp": "2018-06-01T10:02:00Z"}, "status": {"phase": "Running", "containerStatuses": [{"ready": true}]}},
+				{"metadata": {"name": "minio-1", "creationTimestamp": "2018-06-01T10:00:00Z"}, "status": {"phase": "Running", "containerStatuses": [{"ready": false}]}},
+				{"metadata": {"name": "minio-2", "creationTimestamp": "2018-06-01T10:01:00Z"}, "status": {"phase": "Running", "containerStatuses": [{"ready": true}]}}
+			]}`,
+		},
+		{
+			Args:   []string{"get", "pods", "minio-2", "-o", "json", "--namespace=litmus"},
+			Stdout: `{"metadata": {"name": "minio-2"}, "spec": {"nodeName": "node-2"}}`,
+		},
+		{
+			Args:   []string{"get", "nodes", "node-2", "-o", "json", "--namespace=litmus"},
+			Stdout: `{"metadata": {"name": "node-2"}}`,
+		},
+		{
+			Args: []string{"cordon", "node-2", "--namespace=litmus"},
+		},
+	}, exec.ReplayInOrderMode)
+
+	pod, err := GetOldestRunningPod(New().Executor(replayer).Namespace("litmus").Labels("app=minio"))
+	if err != nil {
+		t.Fatalf("failed to get oldest running pod: expected 'no error': actual '%s'", err)
+	}
+
+	if pod != "minio-2" {
+		t.Fatalf("failed to get oldest running pod: expected 'minio-2': actual '%s'", pod)
+	}
+
+	defer func() { defaultNodeLedger = NewNodeLedger() }()
+	err = CordonNodeWithPod(New().Executor(replayer).Namespace("litmus"), pod)
+	if err != nil {
+		t.Fatalf("failed to cordon node with pod: expected 'no error': actual '%s'", err)
+	}
+
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Fatalf("failed to replay: expected all interactions to be used: actual unused '%v'", unused)
+	}
+}
+
+// fixtureRunner is a KubeRunner that returns the contents of a json fixture
+// from testdata as the output of every kubectl command
+type fixtureRunner struct {
+	fixture string
+}
+
+func (r *fixtureRunner) Run(args []string) (output string, err error) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", r.fixture))
+	output = string(data)
+	return
+}
+
+func TestArePodsRunning(t *testing.T) {
+	tests := map[string]struct {
+		fixture string
+		isErr   bool
+	}{
+		"are pods running - positive test case - all pods are ready": {
+			fixture: "pods-running.json",
+			isErr:   false,
+		},
+		"are pods running - negative test case - pod is pending without container statuses": {
+			fixture: "pods-pending.json",
+			isErr:   true,
+		},
+		"are pods running - negative test case - init container is running": {
+			fixture: "pods-init.json",
+			isErr:   true,
+		},
+		"are pods running - negative test case - container is not ready": {
+			fixture: "pods-not-ready.json",
+			isErr:   true,
+		},
+		"are pods running - negative test case - no pods": {
+			fixture: "pods-empty.json",
+			isErr:   true,
+		},
+		"are pods running - negative test case - job pods have completed": {
+			fixture: "pods-job-completed.json",
+			isErr:   true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			yes, err := ArePodsRunning(&fixtureRunner{fixture: mock.fixture})
+
+			if err != nil && !mock.isErr {
+				t.Fatalf("failed to verify pods running: expected 'no error': actual '%s'", err)
+			}
+
+			if err == nil && mock.isErr {
+				t.Fatalf("failed to verify pods running: expected 'error': actual 'no error'")
+			}
+
+			if yes == mock.isErr {
+				t.Fatalf("failed to verify pods running: expected '%t': actual '%t'", !mock.isErr, yes)
+			}
+		})
+	}
+}
+
+func TestPodHelpers(t *testing.T) {
+	tests := map[string]struct {
+		fixture string
+		running []string
+		oldest  string
+		nodes   []string
+	}{
+		"pod helpers - all pods are running": {
+			fixture: "pods-running.json",
+			running: []string{"minio-deployment-7d6b6b5fd7-4gkdx", "minio-deployment-7d6b6b5fd7-x9q2m"},
+			oldest:  "minio-deployment-7d6b6b5fd7-x9q2m",
+			nodes:   []string{"node-2", "node-1"},
+		},
+		"pod helpers - oldest pod is not ready": {
+			fixture: "pods-not-ready.json",
+			running: []string{"minio-deployment-7d6b6b5fd7-4gkdx"},
+			oldest:  "minio-deployment-7d6b6b5fd7-4gkdx",
+			nodes:   []string{"node-1", "node-2"},
+		},
+		"pod helpers - pod is pending & not scheduled": {
+			fixture: "pods-pending.json",
+			running: nil,
+			oldest:  "",
+			nodes:   []string{""},
+		},
+		"pod helpers - pod is waiting for init container": {
+			fixture: "pods-init.json",
+			running: nil,
+			oldest:  "",
+			nodes:   []string{"node-3"},
+		},
+		"pod helpers - no pods": {
+			fixture: "pods-empty.json",
+			running: nil,
+			oldest:  "",
+			nodes:   nil,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			k := &fixtureRunner{fixture: mock.fixture}
+
+			running, err := GetRunningPods(k)
+			if err != nil || !reflect.DeepEqual(running, mock.running) {
+				t.Fatalf("failed to get running pods: expected '%v': actual '%v' '%v'", mock.running, running, err)
+			}
+
+			oldest, err := GetOldestRunningPod(k)
+			if err != nil || oldest != mock.oldest {
+				t.Fatalf("failed to get oldest running pod: expected '%s': actual '%s' '%v'", mock.oldest, oldest, err)
+			}
+
+			nodes, err := GetPodNodes(k)
+			if err != nil || !reflect.DeepEqual(nodes, mock.nodes) {
+				t.Fatalf("failed to get pod nodes: expected '%v': actual '%v' '%v'", mock.nodes, nodes, err)
+			}
+		})
+	}
+}
+
+func TestAreJobPodsCompleted(t *testing.T) {
+	tests := map[string]struct {
+		fixture string
+		isErr   bool
+	}{
+		"are job pods completed - positive test case - pods have succeeded": {
+			fixture: "pods-job-completed.json",
+			isErr:   false,
+		},
+		"are job pods completed - negative test case - pods are running": {
+			fixture: "pods-running.json",
+			isErr:   true,
+		},
+		"are job pods completed - negative test case - no pods": {
+			fixture: "pods-empty.json",
+			isErr:   true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			yes, err := AreJobPodsCompleted(&fixtureRunner{fixture: mock.fixture})
+
+			if err != nil && !mock.isErr {
+				t.Fatalf("failed to verify job pods completion: expected 'no error': actual '%s'", err)
+			}
+
+			if err == nil && mock.isErr {
+				t.Fatalf("failed to verify job pods completion: expected 'error': actual 'no error'")
+			}
+
+			if yes == mock.isErr {
+				t.Fatalf("failed to verify job pods completion: expected '%t': actual '%t'", !mock.isErr, yes)
+			}
+		})
+	}
+}
+
+func TestTypedGetters(t *testing.T) {
+	ip, err := GetServiceIP(&fixtureRunner{fixture: "service.json"}, "minio-service")
+	if err != nil || ip != "10.96.41.182" {
+		t.Fatalf("failed to get service ip: expected '10.96.41.182': actual '%s' '%v'", ip, err)
+	}
+
+	yes, err := IsJobCompleted(&fixtureRunner{fixture: "job.json"}, "minio-client")
+	if err != nil || !yes {
+		t.Fatalf("failed to verify job completion: expected 'completed': actual '%t' '%v'", yes, err)
+	}
+
+	yes, err = IsJobCompleted(&fixtureRunner{fixture: "job-active.json"}, "minio-client")
+	if err == nil || yes {
+		t.Fatalf("failed to verify job completion: expected 'not completed': actual '%t' '%v'", yes, err)
+	}
+
+	pvc, err := NewShellClient(&fixtureRunner{fixture: "pvc.json"}).GetPVC("minio-pv-claim")
+	if err != nil || pvc.Spec.VolumeName != "pvc-3f1a9e4c-67d8-11e8-9c2a-080027a5e3c1" || pvc.Spec.StorageClassName != "openebs-standard" {
+		t.Fatalf("failed to get pvc: expected volume 'pvc-3f1a9e4c-67d8-11e8-9c2a-080027a5e3c1': actual '%#v' '%v'", pvc, err)
+	}
+
+	nodes, err := GetAllNodeNames(&fixtureRunner{fixture: "nodes.json"})
+	if err != nil || !reflect.DeepEqual(nodes, []string{"node-1", "node-2"}) {
+		t.Fatalf("failed to get node names: expected '[node-1 node-2]': actual '%v' '%v'", nodes, err)
+	}
+
+	objs, err := ListObjects(&fixtureRunner{fixture: "nodes.json"}, "nodes")
+	if err != nil || len(objs) != 2 || objs[1].Kind != "Node" {
+		t.Fatalf("failed to list objects: expected '2 nodes': actual '%#v' '%v'", objs, err)
+	}
+}
+
+// echoExec is a stateless executor that returns its args as output
+type echoExec struct{}
+
+func (e echoExec) Execute(args []string) (string, error) {
+	return strings.Join(args, " "), nil
+}
+
+func (e echoExec) StdinExecute(args []string, stdin []byte) (string, error) {
+	return strings.Join(args, " ") + " " + string(stdin), nil
+}
+
+func (e echoExec) ExecuteContext(ctx context.Context, args []string) (string, error) {
+	return e.Execute(args)
+}
+
+func (e echoExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (string, error) {
+	return e.StdinExecute(args, stdin)
+}
+
+func TestSettersReturnCopy(t *testing.T) {
+	base := New().Executor(echoExec{}).KubeConfig("/base/config").Namespace("base").Context("base").Timeout(time.Minute)
+	before := *base
+
+	derived := base.KubeConfig("/derived/config").
+		Namespace("derived").
+		Context("derived").
+		Labels("app=derived").
+		Args([]string{"--v=4"}).
+		Timeout(time.Second).
+		Retry(2, time.Second)
+
+	if !reflect.DeepEqual(before, *base) {
+		t.Fatalf("failed to verify setters: expected 'unmodified receiver %#v': actual '%#v'", &before, base)
+	}
+
+	if derived == base || derived.namespace != "derived" || derived.labels != "app=derived" || derived.timeout != time.Second {
+		t.Fatalf("failed to verify setters: expected 'modified copy': actual '%#v'", derived)
+	}
+}
+
+func TestRunDoesNotModifyInstance(t *testing.T) {
+	k := New().Executor(echoExec{}).Namespace("litmus").Labels("app=minio").Args([]string{"--v=4"})
+	before := *k
+
+	output, err := k.Run([]string{"get", "pods"})
+	if err != nil {
+		t.Fatalf("failed to run: expected 'no error': actual '%v'", err)
+	}
+	if !strings.HasPrefix(output, "--v=4 get pods") || !strings.Contains(output, "--namespace=litmus") {
+		t.Fatalf("failed to run: expected 'args with flags': actual '%s'", output)
+	}
+
+	_, err = k.StdinRun([]string{"apply", "-f", "-"}, []byte("kind: Pod"))
+	if err != nil {
+		t.Fatalf("failed to stdin run: expected 'no error': actual '%v'", err)
+	}
+
+	if !reflect.DeepEqual(before, *k) {
+		t.Fatalf("failed to verify run: expected 'unmodified instance %#v': actual '%#v'", &before, k)
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	shared := New().Executor(echoExec{}).Labels("app=minio")
+	// args with spare capacity are shared by all goroutines to verify that
+	// runs do not append into the caller's slice
+	args := make([]string, 2, 16)
+	args[0], args[1] = "get", "pods"
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ns := fmt.Sprintf("ns-%d", i)
+
+			output, err := shared.Namespace(ns).Run(args)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !strings.Contains(output, "--namespace="+ns+" ") || strings.Count(output, "--namespace=") != 1 {
+				errs <- fmt.Errorf("expected namespace '%s': actual '%s'", ns, output)
+			}
+
+			_, err = shared.Run(args)
+			if err != nil {
+				errs <- err
+			}
+			_ = fmt.Sprintf("%#v", shared)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("failed to use kubectl concurrently: %v", err)
+	}
+
+	if len(args) != 2 || args[0] != "get" || args[1] != "pods" {
+		t.Fatalf("failed to use kubectl concurrently: expected 'unmodified args': actual '%v'", args)
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ephemeral is the namespace created for a feature run that replaces the
+// base namespace of the kubectl instances & applied manifests
+var ephemeral struct {
+	sync.RWMutex
+	// base is the namespace that is replaced
+	base string
+	// name of the ephemeral namespace
+	name string
+	// last is the name of the latest ephemeral namespace; this is set before
+	// the namespace is created & is kept after it is deleted
+	last string
+}
+
+// EphemeralNamespace returns the namespace that replaces the base namespace
+// & the base namespace itself. These are empty if no ephemeral namespace is
+// in use.
+func EphemeralNamespace() (name, base string) {
+	ephemeral.RLock()
+	defer ephemeral.RUnlock()
+	return ephemeral.name, ephemeral.base
+}
+
+// ResolveNamespace returns the ephemeral namespace if the provided namespace
+// is the base namespace it replaces, else the provided namespace
+func ResolveNamespace(namespace string) string {
+	name, base := EphemeralNamespace()
+	if len(name) != 0 && namespace == base {
+		return name
+	}
+	return namespace
+}
+
+// CreateEphemeralNamespace creates a uniquely named namespace that replaces
+// the provided base namespace till it is deleted. The namespace is tracked &
+// labelled like any other object created by litmus.
+func CreateEphemeralNamespace(k *Kubectl, base string) (name string, err error) {
+	if current, _ := EphemeralNamespace(); len(current) != 0 {
+		err = fmt.Errorf("failed to create ephemeral namespace: namespace '%s' is in use", current)
+		return
+	}
+	if len(strings.TrimSpace(base)) == 0 {
+		err = fmt.Errorf("failed to create ephemeral namespace: base namespace is required")
+		return
+	}
+
+	b := make([]byte, 4)
+	rand.Read(b)
+	name = fmt.Sprintf("%s-%s", base, hex.EncodeToString(b))
+	ephemeral.Lock()
+	ephemeral.last = name
+	ephemeral.Unlock()
+
+	_, err = k.ClusterScoped().Labels("").Run([]string{"create", "namespace", name})
+	if err != nil {
+		err = fmt.Errorf("failed to create ephemeral namespace '%s': %s", name, err)
+		return
+	}
+
+	ephemeral.Lock()
+	ephemeral.base, ephemeral.name = base, name
+	ephemeral.Unlock()
+	return
+}
+
+// lastEphemeralNamespace returns the name of the latest ephemeral namespace
+// even if it is being created or has been deleted
+func lastEphemeralNamespace() string {
+	ephemeral.RLock()
+	defer ephemeral.RUnlock()
+	return ephemeral.last
+}
+
+// DeleteEphemeralNamespace deletes the ephemeral namespace & waits till it
+// is gone. The base namespace is no longer replaced hereafter.
+func DeleteEphemeralNamespace(k *Kubectl) (err error) {
+	name, _ := EphemeralNamespace()
+	if len(name) == 0 {
+		return
+	}
+
+	ephemeral.Lock()
+	ephemeral.base, ephemeral.name = "", ""
+	ephemeral.Unlock()
+
+	obj := TrackedObject{Kind: "namespace", Name: name}
+	err = deleteObject(k.ClusterScoped().Labels(""), obj, DefaultTeardownTimeout)
+	if err != nil {
+		err = fmt.Errorf("failed to delete ephemeral namespace '%s': %s", name, err)
+		return
+	}
+	for _, o := range defaultResourceTracker.Objects() {
+		if o.Kind == obj.Kind && o.Name == obj.Name {
+			defaultResourceTracker.forget(o)
+		}
+	}
+	return
+}
+
+// rewriteNamespaces replaces the base namespace of the objects in the
+// applied manifests with the ephemeral namespace. The manifests are then
+// applied from stdin. The args are returned as is if there is no ephemeral
+// namespace or if any manifest can not be read e.g. urls & directories.
+func rewriteNamespaces(args []string, stdin []byte) (rewritten []string, data []byte, ok bool) {
+	name, base := EphemeralNamespace()
+	if len(name) == 0 {
+		return args, stdin, false
+	}
+
+	objs, err := readManifests(args, stdin)
+	if err != nil {
+		return args, stdin, false
+	}
+	for _, obj := range objs {
+		replaceNamespace(obj, base, name)
+	}
+
+	rewritten, data, err = stdinManifests(args, objs)
+	if err != nil {
+		return args, stdin, false
+	}
+	return rewritten, data, true
+}
+
+// replaceNamespace replaces the base namespace of the object & of the
+// subjects of a role binding with the provided namespace
+func replaceNamespace(obj map[string]interface{}, base, namespace string) {
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok && metadata["namespace"] == base {
+		metadata["namespace"] = namespace
+	}
+
+	subjects, _ := obj["subjects"].([]interface{})
+	for _, s := range subjects {
+		if subject, ok := s.(map[string]interface{}); ok && subject["namespace"] == base {
+			subject["namespace"] = namespace
+		}
+	}
+}
+
+// withoutFlags returns the args without the flags that match any of the
+// provided names & their values
+func withoutFlags(args []string, names ...string) (remaining []string) {
+	for i := 0; i < len(args); i++ {
+		skip := false
+		for _, n := range names {
+			switch {
+			case args[i] == n:
+				skip = true
+				i++
+			case strings.HasPrefix(args[i], n+"="):
+				skip = true
+			}
+			if skip {
+				break
+			}
+		}
+		if !skip {
+			remaining = append(remaining, args[i])
+		}
+	}
+	return
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRewriteNamespaces(t *testing.T) {
+	manifest := []byte(`
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: litmus
+  namespace: litmus
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: litmus
+subjects:
+- kind: ServiceAccount
+  name: litmus
+  namespace: litmus
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: maya-apiserver
+  namespace: default
+`)
+
+	tests := map[string]struct {
+		ephemeral    string
+		args         []string
+		isRewritten  bool
+		expectedArgs []string
+		contains     []string
+	}{
+		"rewrite namespaces - +ve test case - base namespace is replaced": {
+			ephemeral:    "litmus-1a2b3c4d",
+			args:         []string{"apply", "-f", "-", "--record"},
+			isRewritten:  true,
+			expectedArgs: []string{"apply", "--record", "-f", "-"},
+			contains:     []string{"namespace: litmus-1a2b3c4d\n", "namespace: default\n"},
+		},
+		"rewrite namespaces - +ve test case - no ephemeral namespace": {
+			args:         []string{"apply", "-f", "-"},
+			expectedArgs: []string{"apply", "-f", "-"},
+			contains:     []string{"namespace: litmus\n"},
+		},
+		"rewrite namespaces - -ve test case - unreadable manifest": {
+			ephemeral:    "litmus-1a2b3c4d",
+			args:         []string{"apply", "-f", "-", "--filename=/missing/app.yaml"},
+			expectedArgs: []string{"apply", "-f", "-", "--filename=/missing/app.yaml"},
+			contains:     []string{"namespace: litmus\n"},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			ephemeral.base, ephemeral.name = "litmus", mock.ephemeral
+			defer func() { ephemeral.base, ephemeral.name = "", "" }()
+
+			args, data, ok := rewriteNamespaces(mock.args, manifest)
+			if ok != mock.isRewritten || !reflect.DeepEqual(args, mock.expectedArgs) {
+				t.Fatalf("failed to rewrite namespaces: expected '%t %v': actual '%t %v'", mock.isRewritten, mock.expectedArgs, ok, args)
+			}
+			for _, c := range mock.contains {
+				if !strings.Contains(string(data), c) {
+					t.Fatalf("failed to rewrite namespaces: expected '%s': actual '%s'", c, data)
+				}
+			}
+			if mock.isRewritten && strings.Contains(string(data), "namespace: litmus\n") {
+				t.Fatalf("failed to rewrite namespaces: expected 'no litmus namespace': actual '%s'", data)
+			}
+		})
+	}
+}
+
+func TestPinNamespace(t *testing.T) {
+	tests := map[string]struct {
+		kubectl  *Kubectl
+		expected string
+	}{
+		"pin namespace - +ve test case - base namespace is replaced": {
+			kubectl:  New().Namespace("litmus"),
+			expected: "litmus-1a2b3c4d",
+		},
+		"pin namespace - +ve test case - pinned base namespace is kept": {
+			kubectl:  New().PinNamespace("litmus"),
+			expected: "litmus",
+		},
+		"pin namespace - +ve test case - namespace resets the pin": {
+			kubectl:  New().PinNamespace("litmus").Namespace("litmus"),
+			expected: "litmus-1a2b3c4d",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			ephemeral.base, ephemeral.name = "litmus", "litmus-1a2b3c4d"
+			defer func() { ephemeral.base, ephemeral.name = "", "" }()
+
+			k := mock.kubectl.Executor(echoExec{})
+			args := k.Command([]string{"get", "pods"})
+			if args[len(args)-1] != "--namespace="+mock.expected {
+				t.Fatalf("failed to pin namespace: expected '%s': actual '%v'", mock.expected, args)
+			}
+
+			_, data, _ := k.rewriteManifests([]string{"apply", "-f", "-"}, []byte("kind: ConfigMap\nmetadata:\n  name: cm\n  namespace: litmus\n"))
+			if !strings.Contains(string(data), "namespace: "+mock.expected+"\n") {
+				t.Fatalf("failed to pin namespace of manifest: expected '%s': actual '%s'", mock.expected, data)
+			}
+		})
+	}
+}
+
+func TestClusterScoped(t *testing.T) {
+	tests := map[string]struct {
+		kubectl  *Kubectl
+		expected []string
+	}{
+		"cluster scoped - +ve test case - no namespace": {
+			kubectl:  New().Namespace("litmus").ClusterScoped(),
+			expected: []string{"get", "nodes"},
+		},
+		"cluster scoped - +ve test case - namespace resets the scope": {
+			kubectl:  New().ClusterScoped().Namespace("litmus"),
+			expected: []string{"get", "nodes", "--namespace=litmus-1a2b3c4d"},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			ephemeral.base, ephemeral.name = "litmus", "litmus-1a2b3c4d"
+			defer func() { ephemeral.base, ephemeral.name = "", "" }()
+
+			args := mock.kubectl.Context("").KubeConfig("").Command([]string{"get", "nodes"})
+			if !reflect.DeepEqual(args, mock.expected) {
+				t.Fatalf("failed to scope to cluster: expected '%v': actual '%v'", mock.expected, args)
+			}
+		})
+	}
+}
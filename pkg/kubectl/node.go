@@ -0,0 +1,406 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NodeChangeKind is the kind of change made to a node
+type NodeChangeKind string
+
+const (
+	// CordonChange marks a node as unschedulable
+	CordonChange NodeChangeKind = "cordon"
+	// TaintChange adds, modifies or removes a taint of a node
+	TaintChange NodeChangeKind = "taint"
+	// LabelChange adds, modifies or removes a label of a node
+	LabelChange NodeChangeKind = "label"
+)
+
+// NodeChange is a change made to a node along with the state of the node
+// prior to this change
+type NodeChange struct {
+	// Node that was changed
+	Node string
+	// Kind of this change
+	Kind NodeChangeKind
+	// Key of the taint or label that was changed
+	Key string
+	// Effect of the taint that was changed
+	Effect string
+	// Existed flags if the taint or label was set prior to this change
+	Existed bool
+	// Prior is the value of the taint or label prior to this change
+	Prior string
+}
+
+// String returns a readable representation of this change
+func (c NodeChange) String() string {
+	switch c.Kind {
+	case TaintChange:
+		return fmt.Sprintf("node '%s': taint '%s:%s'", c.Node, c.Key, c.Effect)
+	case LabelChange:
+		return fmt.Sprintf("node '%s': label '%s'", c.Node, c.Key)
+	default:
+		return fmt.Sprintf("node '%s': %s", c.Node, c.Kind)
+	}
+}
+
+// DrainOptions are the options to drain a node
+type DrainOptions struct {
+	// Timeout is the maximum duration to wait for the pods to get evicted.
+	// Evictions that are blocked by a pod disruption budget are retried till
+	// this timeout. A zero timeout implies DefaultDrainTimeout.
+	Timeout time.Duration
+	// GracePeriod is the duration given to each pod to terminate gracefully.
+	// A zero grace period implies the pod's own grace period.
+	GracePeriod time.Duration
+	// IgnoreDaemonSets flags if the pods managed by daemonsets are ignored
+	IgnoreDaemonSets bool
+	// DeleteLocalData flags if the pods using emptyDir volumes are evicted
+	DeleteLocalData bool
+	// Force flags if the pods that are not managed by a controller are
+	// deleted
+	Force bool
+	// PodSelector limits the eviction to the pods that match this selector
+	PodSelector string
+}
+
+// DefaultDrainTimeout is the maximum duration to wait for the pods of a node
+// to get evicted
+const DefaultDrainTimeout = 5 * time.Minute
+
+// drainTimeoutMargin is the time given to a kubectl drain beyond its own
+// timeout so that the drain reports its timeout instead of getting killed
+const drainTimeoutMargin = 30 * time.Second
+
+// timeout returns the drain timeout of these options
+func (o DrainOptions) timeout() time.Duration {
+	if o.Timeout <= 0 {
+		return DefaultDrainTimeout
+	}
+	return o.Timeout
+}
+
+// args returns the kubectl drain flags of these options
+func (o DrainOptions) args() []string {
+	args := []string{fmt.Sprintf("--timeout=%s", o.timeout())}
+	if o.GracePeriod > 0 {
+		args = append(args, fmt.Sprintf("--grace-period=%d", int(o.GracePeriod.Seconds())))
+	}
+	if o.IgnoreDaemonSets {
+		args = append(args, "--ignore-daemonsets")
+	}
+	if o.DeleteLocalData {
+		args = append(args, "--delete-local-data")
+	}
+	if o.Force {
+		args = append(args, "--force")
+	}
+	if len(o.PodSelector) != 0 {
+		args = append(args, fmt.Sprintf("--pod-selector=%s", o.PodSelector))
+	}
+	return args
+}
+
+// NodeLedger changes nodes & records the state of each node prior to its
+// change. Restoring the ledger puts back exactly what was changed via this
+// ledger & nothing more.
+//
+// NOTE:
+//  Only the first change to a node's cordon, taint or label is recorded since
+// it holds the node's original state. Taints & labels are always changed via
+// kubectl.
+type NodeLedger struct {
+	mutex   sync.Mutex
+	changes []NodeChange
+}
+
+// NewNodeLedger returns a new instance of NodeLedger
+func NewNodeLedger() *NodeLedger {
+	return &NodeLedger{}
+}
+
+// defaultNodeLedger records the node changes made via the package level
+// helpers
+var defaultNodeLedger = NewNodeLedger()
+
+// DefaultNodeLedger returns the ledger that records the node changes made via
+// the package level helpers e.g. CordonNodeWithPod, DrainNode
+func DefaultNodeLedger() *NodeLedger {
+	return defaultNodeLedger
+}
+
+// Changes returns the recorded changes in the order they were made
+func (l *NodeLedger) Changes() []NodeChange {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]NodeChange(nil), l.changes...)
+}
+
+// record records the change unless a change of the same node, kind & key is
+// already recorded
+func (l *NodeLedger) record(change NodeChange) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, c := range l.changes {
+		if c.Node == change.Node && c.Kind == change.Kind && c.Key == change.Key && c.Effect == change.Effect {
+			return
+		}
+	}
+	l.changes = append(l.changes, change)
+}
+
+// findTaint returns the taint of the node with the provided key & effect
+func findTaint(node Node, key, effect string) (taint Taint, found bool) {
+	for _, t := range node.Spec.Taints {
+		if t.Key == key && t.Effect == effect {
+			return t, true
+		}
+	}
+	return
+}
+
+// Cordon marks the node as unschedulable. The node is uncordoned on restore
+// unless it was already cordoned.
+func (l *NodeLedger) Cordon(k KubeRunner, node string) (err error) {
+	n, err := clientFor(k).GetNode(node)
+	if err != nil {
+		return
+	}
+
+	if n.Spec.Unschedulable {
+		return
+	}
+
+	l.record(NodeChange{Node: node, Kind: CordonChange})
+	return clientFor(k).CordonNode(node)
+}
+
+// Drain cordons the node & evicts its pods. The node is uncordoned on restore
+// unless it was already cordoned. The evicted pods are not restored.
+//
+// NOTE:
+//  Use IsDisruptionBudgetViolation to check if the drain failed since the
+// eviction of a pod would violate its pod disruption budget
+func (l *NodeLedger) Drain(k KubeRunner, node string, opts DrainOptions) (err error) {
+	n, err := clientFor(k).GetNode(node)
+	if err != nil {
+		return
+	}
+
+	// a drain cordons the node even if its evictions fail
+	if !n.Spec.Unschedulable {
+		l.record(NodeChange{Node: node, Kind: CordonChange})
+	}
+
+	// a kubectl instance that would kill the drain before its timeout is
+	// given the drain timeout plus a margin
+	if kc, ok := k.(*Kubectl); ok && kc.timeout > 0 && kc.timeout < opts.timeout()+drainTimeoutMargin {
+		k = kc.Timeout(opts.timeout() + drainTimeoutMargin)
+	}
+
+	_, err = k.Run(append([]string{"drain", node}, opts.args()...))
+	if err != nil {
+		err = fmt.Errorf("failed to drain node '%s': %v", node, err)
+	}
+	return
+}
+
+// AddTaint adds the taint to the node or modifies the value of the node's
+// taint with the same key & effect. The node's prior taint is put back on
+// restore.
+func (l *NodeLedger) AddTaint(k KubeRunner, node string, taint Taint) (err error) {
+	if len(taint.Key) == 0 || len(taint.Effect) == 0 {
+		err = fmt.Errorf("failed to taint node '%s': key & effect are required: '%#v'", node, taint)
+		return
+	}
+
+	n, err := clientFor(k).GetNode(node)
+	if err != nil {
+		return
+	}
+
+	prior, found := findTaint(n, taint.Key, taint.Effect)
+	if found && prior.Value == taint.Value {
+		return
+	}
+
+	l.record(NodeChange{Node: node, Kind: TaintChange, Key: taint.Key, Effect: taint.Effect, Existed: found, Prior: prior.Value})
+	_, err = k.Run([]string{"taint", "nodes", node, taintArg(taint), "--overwrite"})
+	return
+}
+
+// RemoveTaint removes the taint with the key & effect from the node. The
+// taint is put back on restore.
+func (l *NodeLedger) RemoveTaint(k KubeRunner, node, key, effect string) (err error) {
+	n, err := clientFor(k).GetNode(node)
+	if err != nil {
+		return
+	}
+
+	prior, found := findTaint(n, key, effect)
+	if !found {
+		return
+	}
+
+	l.record(NodeChange{Node: node, Kind: TaintChange, Key: key, Effect: effect, Existed: true, Prior: prior.Value})
+	_, err = k.Run([]string{"taint", "nodes", node, fmt.Sprintf("%s:%s-", key, effect)})
+	return
+}
+
+// AddLabel adds the label to the node or modifies the value of the node's
+// label with the same key. The node's prior label is put back on restore.
+func (l *NodeLedger) AddLabel(k KubeRunner, node, key, value string) (err error) {
+	if len(key) == 0 {
+		err = fmt.Errorf("failed to label node '%s': key is required", node)
+		return
+	}
+
+	n, err := clientFor(k).GetNode(node)
+	if err != nil {
+		return
+	}
+
+	prior, found := n.Metadata.Labels[key]
+	if found && prior == value {
+		return
+	}
+
+	l.record(NodeChange{Node: node, Kind: LabelChange, Key: key, Existed: found, Prior: prior})
+	_, err = k.Run([]string{"label", "nodes", node, fmt.Sprintf("%s=%s", key, value), "--overwrite"})
+	return
+}
+
+// RemoveLabel removes the label with the key from the node. The label is put
+// back on restore.
+func (l *NodeLedger) RemoveLabel(k KubeRunner, node, key string) (err error) {
+	n, err := clientFor(k).GetNode(node)
+	if err != nil {
+		return
+	}
+
+	prior, found := n.Metadata.Labels[key]
+	if !found {
+		return
+	}
+
+	l.record(NodeChange{Node: node, Kind: LabelChange, Key: key, Existed: true, Prior: prior})
+	_, err = k.Run([]string{"label", "nodes", node, fmt.Sprintf("%s-", key)})
+	return
+}
+
+// Restore puts back the recorded state of the changed nodes in the reverse
+// order of their changes. The changes that could not be restored are retained
+// in the ledger & the first such error is returned.
+func (l *NodeLedger) Restore(k KubeRunner) (err error) {
+	l.mutex.Lock()
+	changes := l.changes
+	l.changes = nil
+	l.mutex.Unlock()
+
+	var failed []NodeChange
+	for i := len(changes) - 1; i >= 0; i-- {
+		restoreErr := restoreNode(k, changes[i])
+		if restoreErr != nil && !IsNotFound(restoreErr) {
+			failed = append([]NodeChange{changes[i]}, failed...)
+			if err == nil {
+				err = fmt.Errorf("failed to restore %s: %v", changes[i], restoreErr)
+			}
+		}
+	}
+
+	if len(failed) != 0 {
+		l.mutex.Lock()
+		l.changes = append(failed, l.changes...)
+		l.mutex.Unlock()
+	}
+	return
+}
+
+// restoreNode puts back the state of the node prior to the change
+func restoreNode(k KubeRunner, c NodeChange) (err error) {
+	var args []string
+	switch {
+	case c.Kind == CordonChange:
+		return clientFor(k).UncordonNode(c.Node)
+	case c.Kind == TaintChange && c.Existed:
+		args = []string{"taint", "nodes", c.Node, taintArg(Taint{Key: c.Key, Value: c.Prior, Effect: c.Effect}), "--overwrite"}
+	case c.Kind == TaintChange:
+		args = []string{"taint", "nodes", c.Node, fmt.Sprintf("%s:%s-", c.Key, c.Effect)}
+	case c.Kind == LabelChange && c.Existed:
+		args = []string{"label", "nodes", c.Node, fmt.Sprintf("%s=%s", c.Key, c.Prior), "--overwrite"}
+	case c.Kind == LabelChange:
+		args = []string{"label", "nodes", c.Node, fmt.Sprintf("%s-", c.Key)}
+	default:
+		return fmt.Errorf("unsupported node change '%s'", c.Kind)
+	}
+
+	_, err = k.Run(args)
+	if c.Kind == TaintChange && !c.Existed && hasAnyMessage(err, "not found") {
+		// the taint was removed by someone else
+		err = nil
+	}
+	return
+}
+
+// taintArg returns the taint in the key=value:effect form used by kubectl
+func taintArg(t Taint) string {
+	if len(t.Value) == 0 {
+		return fmt.Sprintf("%s:%s", t.Key, t.Effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", t.Key, t.Value, t.Effect)
+}
+
+// DrainNode drains the node & records it in the default node ledger
+func DrainNode(k KubeRunner, node string, opts DrainOptions) error {
+	return defaultNodeLedger.Drain(k, node, opts)
+}
+
+// TaintNode adds the taint to the node & records it in the default node
+// ledger
+func TaintNode(k KubeRunner, node string, taint Taint) error {
+	return defaultNodeLedger.AddTaint(k, node, taint)
+}
+
+// UntaintNode removes the taint from the node & records it in the default
+// node ledger
+func UntaintNode(k KubeRunner, node, key, effect string) error {
+	return defaultNodeLedger.RemoveTaint(k, node, key, effect)
+}
+
+// LabelNode adds the label to the node & records it in the default node
+// ledger
+func LabelNode(k KubeRunner, node, key, value string) error {
+	return defaultNodeLedger.AddLabel(k, node, key, value)
+}
+
+// UnlabelNode removes the label from the node & records it in the default
+// node ledger
+func UnlabelNode(k KubeRunner, node, key string) error {
+	return defaultNodeLedger.RemoveLabel(k, node, key)
+}
+
+// RestoreNodes puts back the state of the nodes changed via the default node
+// ledger
+func RestoreNodes(k KubeRunner) error {
+	return defaultNodeLedger.Restore(k)
+}
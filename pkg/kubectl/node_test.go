@@ -0,0 +1,277 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+)
+
+// nodeRunner serves the nodes as json & records the other commands. The
+// commands that start with any of the failing prefixes fail with the
+// corresponding error output.
+type nodeRunner struct {
+	nodes    map[string]string
+	executed []string
+	failing  map[string]string
+}
+
+func (r *nodeRunner) Run(args []string) (output string, err error) {
+	if args[0] == "get" {
+		node, ok := r.nodes[args[2]]
+		if !ok {
+			err = &exec.ExecError{ExitCode: 1, Stderr: fmt.Sprintf(`Error from server (NotFound): nodes "%s" not found`, args[2])}
+		}
+		return node, err
+	}
+
+	cmd := strings.Join(args, " ")
+	r.executed = append(r.executed, cmd)
+	for prefix, stderr := range r.failing {
+		if strings.HasPrefix(cmd, prefix) {
+			err = &exec.ExecError{ExitCode: 1, Stderr: stderr}
+		}
+	}
+	return
+}
+
+func newNodeRunner() *nodeRunner {
+	return &nodeRunner{
+		nodes: map[string]string{
+			"node-1": `{"metadata": {"name": "node-1", "labels": {"zone": "a"}}, "spec": {"taints": [{"key": "dedicated", "value": "db", "effect": "NoSchedule"}]}}`,
+			"node-2": `{"metadata": {"name": "node-2"}, "spec": {"unschedulable": true}}`,
+		},
+	}
+}
+
+func TestNodeLedger(t *testing.T) {
+	r := newNodeRunner()
+	l := NewNodeLedger()
+
+	ops := []func() error{
+		func() error { return l.Cordon(r, "node-1") },
+		// already cordoned by someone else & hence not changed
+		func() error { return l.Cordon(r, "node-2") },
+		func() error { return l.AddTaint(r, "node-1", Taint{Key: "litmus", Effect: "NoSchedule"}) },
+		// same as the existing taint & hence not changed
+		func() error {
+			return l.AddTaint(r, "node-1", Taint{Key: "dedicated", Value: "db", Effect: "NoSchedule"})
+		},
+		func() error {
+			return l.AddTaint(r, "node-1", Taint{Key: "dedicated", Value: "web", Effect: "NoSchedule"})
+		},
+		// not set & hence not changed
+		func() error { return l.RemoveTaint(r, "node-1", "dedicated", "NoExecute") },
+		func() error { return l.AddLabel(r, "node-1", "zone", "b") },
+		func() error { return l.AddLabel(r, "node-1", "role", "chaos") },
+		// only the first change of a label is recorded
+		func() error { return l.RemoveLabel(r, "node-1", "zone") },
+		// not set & hence not changed
+		func() error { return l.RemoveLabel(r, "node-1", "missing") },
+	}
+
+	for i, op := range ops {
+		if err := op(); err != nil {
+			t.Fatalf("failed to change node at op '%d': expected 'no error': actual '%v'", i, err)
+		}
+	}
+
+	expected := []string{
+		"cordon node-1",
+		"taint nodes node-1 litmus:NoSchedule --overwrite",
+		"taint nodes node-1 dedicated=web:NoSchedule --overwrite",
+		"label nodes node-1 zone=b --overwrite",
+		"label nodes node-1 role=chaos --overwrite",
+		"label nodes node-1 zone-",
+	}
+	if !reflect.DeepEqual(r.executed, expected) {
+		t.Fatalf("failed to change nodes: expected '%q': actual '%q'", expected, r.executed)
+	}
+
+	if len(l.Changes()) != 5 {
+		t.Fatalf("failed to record changes: expected '5 changes': actual '%v'", l.Changes())
+	}
+
+	r.executed = nil
+	err := l.Restore(r)
+	if err != nil {
+		t.Fatalf("failed to restore: expected 'no error': actual '%v'", err)
+	}
+
+	expected = []string{
+		"label nodes node-1 role-",
+		"label nodes node-1 zone=a --overwrite",
+		"taint nodes node-1 dedicated=db:NoSchedule --overwrite",
+		"taint nodes node-1 litmus:NoSchedule-",
+		"uncordon node-1",
+	}
+	if !reflect.DeepEqual(r.executed, expected) {
+		t.Fatalf("failed to restore: expected '%q': actual '%q'", expected, r.executed)
+	}
+
+	if len(l.Changes()) != 0 {
+		t.Fatalf("failed to restore: expected 'no changes': actual '%v'", l.Changes())
+	}
+}
+
+func TestNodeLedgerFailedRestore(t *testing.T) {
+	r := newNodeRunner()
+	l := NewNodeLedger()
+
+	l.Cordon(r, "node-1")
+	l.AddLabel(r, "node-1", "role", "chaos")
+
+	r.failing = map[string]string{"uncordon": "error: unable to uncordon"}
+	err := l.Restore(r)
+	if err == nil || !strings.Contains(err.Error(), "node 'node-1': cordon") {
+		t.Fatalf("failed to restore: expected 'cordon restore error': actual '%v'", err)
+	}
+
+	changes := l.Changes()
+	if len(changes) != 1 || changes[0].Kind != CordonChange {
+		t.Fatalf("failed to restore: expected 'retained cordon change': actual '%v'", changes)
+	}
+
+	r.failing = nil
+	r.executed = nil
+	err = l.Restore(r)
+	if err != nil || !reflect.DeepEqual(r.executed, []string{"uncordon node-1"}) {
+		t.Fatalf("failed to restore: expected 'uncordon node-1': actual '%q' '%v'", r.executed, err)
+	}
+}
+
+func TestNodeLedgerDrain(t *testing.T) {
+	tests := map[string]struct {
+		opts     DrainOptions
+		failing  map[string]string
+		expected string
+		isPDB    bool
+	}{
+		"drain - +ve test case - default options": {
+			expected: "drain node-1 --timeout=5m0s",
+		},
+		"drain - +ve test case - all options": {
+			opts: DrainOptions{
+				Timeout:          time.Minute,
+				GracePeriod:      30 * time.Second,
+				IgnoreDaemonSets: true,
+				DeleteLocalData:  true,
+				Force:            true,
+				PodSelector:      "app=minio",
+			},
+			expected: "drain node-1 --timeout=1m0s --grace-period=30 --ignore-daemonsets --delete-local-data --force --pod-selector=app=minio",
+		},
+		"drain - -ve test case - blocked by disruption budget": {
+			failing: map[string]string{
+				"drain": `error when evicting pods/"minio-1" -n "litmus" (will retry after 5s): Cannot evict pod as it would violate the pod's disruption budget.`,
+			},
+			expected: "drain node-1 --timeout=5m0s",
+			isPDB:    true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := newNodeRunner()
+			r.failing = mock.failing
+			l := NewNodeLedger()
+
+			err := l.Drain(r, "node-1", mock.opts)
+			if (err != nil) != mock.isPDB || IsDisruptionBudgetViolation(err) != mock.isPDB {
+				t.Fatalf("failed to drain: expected disruption budget violation '%t': actual '%v'", mock.isPDB, err)
+			}
+
+			if len(r.executed) != 1 || r.executed[0] != mock.expected {
+				t.Fatalf("failed to drain: expected '%s': actual '%q'", mock.expected, r.executed)
+			}
+
+			// the node is cordoned even if the drain fails
+			changes := l.Changes()
+			if len(changes) != 1 || changes[0].Kind != CordonChange {
+				t.Fatalf("failed to drain: expected 'recorded cordon': actual '%v'", changes)
+			}
+		})
+	}
+}
+
+// deadlineExec serves a node as json & records the time left for each drain
+// before its context is done
+type deadlineExec struct {
+	left []time.Duration
+}
+
+func (e *deadlineExec) Execute(args []string) (string, error) {
+	return e.ExecuteContext(context.Background(), args)
+}
+
+func (e *deadlineExec) StdinExecute(args []string, stdin []byte) (string, error) {
+	return e.ExecuteContext(context.Background(), args)
+}
+
+func (e *deadlineExec) ExecuteContext(ctx context.Context, args []string) (string, error) {
+	if args[0] != "drain" {
+		return `{"metadata": {"name": "node-1"}}`, nil
+	}
+	deadline, _ := ctx.Deadline()
+	e.left = append(e.left, time.Until(deadline))
+	return "", nil
+}
+
+func (e *deadlineExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (string, error) {
+	return e.ExecuteContext(ctx, args)
+}
+
+func TestNodeLedgerDrainTimeout(t *testing.T) {
+	tests := map[string]struct {
+		kubectlTimeout time.Duration
+		opts           DrainOptions
+		minLeft        time.Duration
+		maxLeft        time.Duration
+	}{
+		"drain timeout - default drain timeout exceeds kubectl timeout": {
+			kubectlTimeout: KubectlTimeout,
+			minLeft:        DefaultDrainTimeout + drainTimeoutMargin - time.Second,
+			maxLeft:        DefaultDrainTimeout + drainTimeoutMargin,
+		},
+		"drain timeout - kubectl timeout exceeds drain timeout": {
+			kubectlTimeout: time.Hour,
+			opts:           DrainOptions{Timeout: time.Minute},
+			minLeft:        time.Hour - time.Second,
+			maxLeft:        time.Hour,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := &deadlineExec{}
+			k := New().Executor(e).Timeout(mock.kubectlTimeout)
+
+			if err := NewNodeLedger().Drain(k, "node-1", mock.opts); err != nil {
+				t.Fatalf("failed to drain: expected 'no error': actual '%s'", err)
+			}
+			if len(e.left) != 1 || e.left[0] < mock.minLeft || e.left[0] > mock.maxLeft {
+				t.Fatalf("failed to drain: expected time left between '%s' & '%s': actual '%v'", mock.minLeft, mock.maxLeft, e.left)
+			}
+		})
+	}
+}
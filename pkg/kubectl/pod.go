@@ -0,0 +1,243 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+)
+
+// ExecResult is the result of a command executed inside a pod's container
+type ExecResult struct {
+	// Pod where the command was executed
+	Pod string
+	// Container where the command was executed; empty implies the pod's
+	// default container
+	Container string
+	// Command that was executed
+	Command []string
+	// Stdout of the executed command
+	Stdout string
+	// Stderr of the executed command; this is set only if the command failed
+	Stderr string
+	// ExitCode of the executed command
+	ExitCode int
+}
+
+// LogsResult is the result of fetching the logs of a pod's container
+type LogsResult struct {
+	// Pod whose logs were fetched
+	Pod string
+	// Container whose logs were fetched; empty implies the pod's default
+	// container
+	Container string
+	// Previous flags if these logs belong to the previous instance of the
+	// container
+	Previous bool
+	// Output is the fetched logs
+	Output string
+}
+
+// Lines returns the fetched logs as lines
+func (r LogsResult) Lines() []string {
+	if len(r.Output) == 0 {
+		return nil
+	}
+
+	return strings.Split(r.Output, "\n")
+}
+
+// CopyResult is the result of copying file(s) between the local filesystem &
+// a pod's container
+type CopyResult struct {
+	// Pod to or from where the file(s) were copied
+	Pod string
+	// Container to or from where the file(s) were copied; empty implies the
+	// pod's default container
+	Container string
+	// Source path of the copy
+	Source string
+	// Destination path of the copy
+	Destination string
+	// Size is the number of bytes of the local file(s)
+	Size int64
+}
+
+// ExecInPod executes the provided command inside the container of the pod.
+// The provided stdin, if not nil, is passed to the command.
+func ExecInPod(k KubeAllRunner, pod, container string, cmd []string, stdin []byte) (result ExecResult, err error) {
+	if len(pod) == 0 {
+		err = fmt.Errorf("failed to exec in pod: pod name is not provided")
+		return
+	}
+
+	if len(cmd) == 0 {
+		err = fmt.Errorf("failed to exec in pod '%s': command is not provided", pod)
+		return
+	}
+
+	result = ExecResult{
+		Pod:       pod,
+		Container: container,
+		Command:   cmd,
+	}
+
+	args := []string{"exec", pod}
+	if len(container) != 0 {
+		args = append(args, "--container", container)
+	}
+	if stdin != nil {
+		args = append(args, "--stdin")
+	}
+	args = append(args, "--")
+	args = append(args, cmd...)
+
+	if stdin != nil {
+		result.Stdout, err = k.StdinRun(args, stdin)
+	} else {
+		result.Stdout, err = k.Run(args)
+	}
+
+	if e, ok := exec.AsExecError(err); ok {
+		result.Stdout = strings.TrimSpace(e.Stdout)
+		result.Stderr = strings.TrimSpace(e.Stderr)
+		result.ExitCode = e.ExitCode
+	}
+
+	if err != nil {
+		err = fmt.Errorf("failed to exec '%s' in pod '%s': exit code '%d': %v", strings.Join(cmd, " "), pod, result.ExitCode, err)
+	}
+	return
+}
+
+// GetPodLogs fetches the logs of the container of the pod. A non zero since
+// limits the logs to the provided duration. Previous fetches the logs of the
+// previous instance of the container e.g. before it was restarted.
+func GetPodLogs(k KubeRunner, pod, container string, since time.Duration, previous bool) (result LogsResult, err error) {
+	if len(pod) == 0 {
+		err = fmt.Errorf("failed to get pod logs: pod name is not provided")
+		return
+	}
+
+	result = LogsResult{
+		Pod:       pod,
+		Container: container,
+		Previous:  previous,
+	}
+
+	args := []string{"logs", pod}
+	if len(container) != 0 {
+		args = append(args, "--container", container)
+	}
+	if since > 0 {
+		args = append(args, fmt.Sprintf("--since=%s", since))
+	}
+	if previous {
+		args = append(args, "--previous")
+	}
+
+	result.Output, err = k.Run(args)
+	if err != nil {
+		err = fmt.Errorf("failed to get logs of pod '%s': %v", pod, err)
+	}
+	return
+}
+
+// CopyToPod copies the local file or directory at src to the path dst inside
+// the container of the pod
+func CopyToPod(k KubeRunner, src, pod, container, dst string) (result CopyResult, err error) {
+	if len(pod) == 0 {
+		err = fmt.Errorf("failed to copy to pod: pod name is not provided")
+		return
+	}
+
+	result = CopyResult{
+		Pod:         pod,
+		Container:   container,
+		Source:      src,
+		Destination: dst,
+	}
+
+	result.Size, err = localSize(src)
+	if err != nil {
+		err = fmt.Errorf("failed to copy '%s' to pod '%s': %v", src, pod, err)
+		return
+	}
+
+	_, err = k.Run(copyArgs(src, fmt.Sprintf("%s:%s", pod, dst), container))
+	if err != nil {
+		err = fmt.Errorf("failed to copy '%s' to pod '%s': %v", src, pod, err)
+	}
+	return
+}
+
+// CopyFromPod copies the file or directory at path src inside the container
+// of the pod to the local path dst
+func CopyFromPod(k KubeRunner, pod, container, src, dst string) (result CopyResult, err error) {
+	if len(pod) == 0 {
+		err = fmt.Errorf("failed to copy from pod: pod name is not provided")
+		return
+	}
+
+	result = CopyResult{
+		Pod:         pod,
+		Container:   container,
+		Source:      src,
+		Destination: dst,
+	}
+
+	_, err = k.Run(copyArgs(fmt.Sprintf("%s:%s", pod, src), dst, container))
+	if err != nil {
+		err = fmt.Errorf("failed to copy '%s' from pod '%s': %v", src, pod, err)
+		return
+	}
+
+	result.Size, err = localSize(dst)
+	if err != nil {
+		err = fmt.Errorf("failed to copy '%s' from pod '%s': %v", src, pod, err)
+	}
+	return
+}
+
+// copyArgs builds the kubectl args to copy from src to dst
+func copyArgs(src, dst, container string) []string {
+	args := []string{"cp", src, dst}
+	if len(container) != 0 {
+		args = append(args, "--container", container)
+	}
+	return args
+}
+
+// localSize returns the number of bytes of the local file or of all the files
+// of the local directory
+func localSize(path string) (size int64, err error) {
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+)
+
+// recordRunner records the args & stdin it is run with & returns the
+// configured output or error
+type recordRunner struct {
+	args   []string
+	stdin  []byte
+	output string
+	err    error
+	// onRun is invoked with the args of every run
+	onRun func(args []string)
+}
+
+func (r *recordRunner) Run(args []string) (output string, err error) {
+	r.args = args
+	if r.onRun != nil {
+		r.onRun(args)
+	}
+	return r.output, r.err
+}
+
+func (r *recordRunner) StdinRun(args []string, stdin []byte) (output string, err error) {
+	r.stdin = stdin
+	return r.Run(args)
+}
+
+func TestExecInPod(t *testing.T) {
+	tests := map[string]struct {
+		pod       string
+		container string
+		cmd       []string
+		stdin     []byte
+		runErr    error
+		expected  []string
+		result    ExecResult
+		isErr     bool
+	}{
+		"exec in pod - +ve test case - command": {
+			pod:      "minio-0",
+			cmd:      []string{"ls", "-l"},
+			expected: []string{"exec", "minio-0", "--", "ls", "-l"},
+			result:   ExecResult{Pod: "minio-0", Command: []string{"ls", "-l"}, Stdout: "output"},
+		},
+		"exec in pod - +ve test case - command with container & stdin": {
+			pod:       "minio-0",
+			container: "minio",
+			cmd:       []string{"sh", "-c", "cat > /data/file"},
+			stdin:     []byte("hello"),
+			expected:  []string{"exec", "minio-0", "--container", "minio", "--stdin", "--", "sh", "-c", "cat > /data/file"},
+			result:    ExecResult{Pod: "minio-0", Container: "minio", Command: []string{"sh", "-c", "cat > /data/file"}, Stdout: "output"},
+		},
+		"exec in pod - -ve test case - command fails": {
+			pod:      "minio-0",
+			cmd:      []string{"cat", "/data/file"},
+			runErr:   &exec.ExecError{ExitCode: 1, Stdout: "partial\n", Stderr: "no such file\n", Err: fmt.Errorf("exit status 1")},
+			expected: []string{"exec", "minio-0", "--", "cat", "/data/file"},
+			result:   ExecResult{Pod: "minio-0", Command: []string{"cat", "/data/file"}, Stdout: "partial", Stderr: "no such file", ExitCode: 1},
+			isErr:    true,
+		},
+		"exec in pod - -ve test case - no pod": {
+			cmd:   []string{"ls"},
+			isErr: true,
+		},
+		"exec in pod - -ve test case - no command": {
+			pod:    "minio-0",
+			result: ExecResult{},
+			isErr:  true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := &recordRunner{output: "output", err: mock.runErr}
+			result, err := ExecInPod(r, mock.pod, mock.container, mock.cmd, mock.stdin)
+
+			if mock.isErr != (err != nil) {
+				t.Fatalf("failed to exec in pod: expected error '%t': actual error '%v'", mock.isErr, err)
+			}
+
+			if !reflect.DeepEqual(r.args, mock.expected) {
+				t.Fatalf("failed to exec in pod: expected args '%q': actual args '%q'", mock.expected, r.args)
+			}
+
+			if string(r.stdin) != string(mock.stdin) {
+				t.Fatalf("failed to exec in pod: expected stdin '%s': actual stdin '%s'", mock.stdin, r.stdin)
+			}
+
+			if !reflect.DeepEqual(result, mock.result) {
+				t.Fatalf("failed to exec in pod: expected result '%#v': actual result '%#v'", mock.result, result)
+			}
+		})
+	}
+}
+
+func TestGetPodLogs(t *testing.T) {
+	tests := map[string]struct {
+		container string
+		since     time.Duration
+		previous  bool
+		expected  []string
+	}{
+		"get pod logs - +ve test case - all logs": {
+			expected: []string{"logs", "minio-0"},
+		},
+		"get pod logs - +ve test case - recent logs of container": {
+			container: "minio",
+			since:     5 * time.Minute,
+			expected:  []string{"logs", "minio-0", "--container", "minio", "--since=5m0s"},
+		},
+		"get pod logs - +ve test case - previous logs": {
+			previous: true,
+			expected: []string{"logs", "minio-0", "--previous"},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := &recordRunner{output: "started\nserving"}
+			result, err := GetPodLogs(r, "minio-0", mock.container, mock.since, mock.previous)
+			if err != nil {
+				t.Fatalf("failed to get pod logs: expected 'no error': actual '%v'", err)
+			}
+
+			if !reflect.DeepEqual(r.args, mock.expected) {
+				t.Fatalf("failed to get pod logs: expected args '%q': actual args '%q'", mock.expected, r.args)
+			}
+
+			if len(result.Lines()) != 2 || result.Previous != mock.previous || result.Container != mock.container {
+				t.Fatalf("failed to get pod logs: expected '2 lines': actual result '%#v'", result)
+			}
+		})
+	}
+
+	_, err := GetPodLogs(&recordRunner{}, "", "", 0, false)
+	if err == nil {
+		t.Fatalf("failed to get pod logs: expected 'error for missing pod': actual 'no error'")
+	}
+}
+
+func TestCopyPod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "litmus-cp")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "data.txt")
+	err = ioutil.WriteFile(src, []byte("hello"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	r := &recordRunner{}
+	result, err := CopyToPod(r, src, "minio-0", "minio", "/data/data.txt")
+	if err != nil {
+		t.Fatalf("failed to copy to pod: expected 'no error': actual '%v'", err)
+	}
+
+	expected := []string{"cp", src, "minio-0:/data/data.txt", "--container", "minio"}
+	if !reflect.DeepEqual(r.args, expected) || result.Size != 5 {
+		t.Fatalf("failed to copy to pod: expected args '%q' & size '5': actual args '%q' & result '%#v'", expected, r.args, result)
+	}
+
+	_, err = CopyToPod(r, filepath.Join(dir, "missing.txt"), "minio-0", "", "/data/")
+	if err == nil {
+		t.Fatalf("failed to copy to pod: expected 'error for missing file': actual 'no error'")
+	}
+
+	// the runner mimics kubectl by writing the copied file locally
+	dst := filepath.Join(dir, "copied.txt")
+	r = &recordRunner{onRun: func(args []string) {
+		ioutil.WriteFile(args[len(args)-1], []byte("hello world"), 0644)
+	}}
+	result, err = CopyFromPod(r, "minio-0", "", "/data/data.txt", dst)
+	if err != nil {
+		t.Fatalf("failed to copy from pod: expected 'no error': actual '%v'", err)
+	}
+
+	expected = []string{"cp", "minio-0:/data/data.txt", dst}
+	if !reflect.DeepEqual(r.args, expected) || result.Size != 11 {
+		t.Fatalf("failed to copy from pod: expected args '%q' & size '11': actual args '%q' & result '%#v'", expected, r.args, result)
+	}
+
+	r = &recordRunner{err: fmt.Errorf("tar: not found")}
+	_, err = CopyFromPod(r, "minio-0", "", "/data/data.txt", filepath.Join(dir, "other.txt"))
+	if err == nil || !strings.Contains(err.Error(), "tar: not found") {
+		t.Fatalf("failed to copy from pod: expected 'tar error': actual '%v'", err)
+	}
+}
@@ -0,0 +1,572 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+	"github.com/AmitKumarDas/elitmus/pkg/wait"
+	"github.com/ghodss/yaml"
+)
+
+const (
+	// DefaultTeardownTimeout is the maximum time spent in waiting for the
+	// deletion of a tracked object
+	DefaultTeardownTimeout = 2 * time.Minute
+	// teardownPollInterval is the interval between the checks of a deletion
+	teardownPollInterval = 2 * time.Second
+
+	// RunIDLabel is the label that is set to the id of the run on every
+	// object created by an apply; see labelManifests
+	RunIDLabel = "litmus.io/run-id"
+	// TestLabel is the label that is set to the name of the test on every
+	// object created by an apply; see labelManifests
+	TestLabel = "litmus.io/test"
+	// RetainLabel when set to true in the manifest of an object retains the
+	// object beyond the run i.e. the object is neither deleted at teardown
+	// nor garbage collected
+	RetainLabel = "litmus.io/retain"
+	// maxLabelValueLength is the maximum length of a label value
+	maxLabelValueLength = 63
+)
+
+// runID is the id of this run of litmus
+var runID struct {
+	once sync.Once
+	id   string
+}
+
+// RunID returns the id of this run of litmus. It is taken from the
+// environment if set, else a unique id is generated once per process.
+func RunID() string {
+	runID.once.Do(func() {
+		runID.id = util.RunIDENV()
+		if len(runID.id) != 0 {
+			return
+		}
+
+		b := make([]byte, 4)
+		rand.Read(b)
+		runID.id = fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102t150405"), hex.EncodeToString(b))
+	})
+	return runID.id
+}
+
+// TestName returns the name of the test being run. It is taken from the
+// environment if set, else the name of the feature being run is used.
+func TestName() string {
+	if name := util.TestNameENV(); len(name) != 0 {
+		return name
+	}
+	return exec.CurrentAuditScope().Feature
+}
+
+// LabelValue returns the value in a form that is valid for a label i.e. at
+// most 63 characters that are alphanumeric, '-', '_' or '.' & that begin &
+// end with an alphanumeric character. Invalid characters are replaced by '-'.
+func LabelValue(value string) string {
+	b := []byte(strings.TrimSpace(value))
+	for i, c := range b {
+		if !isAlphaNumeric(c) && c != '-' && c != '_' && c != '.' {
+			b[i] = '-'
+		}
+	}
+	if len(b) > maxLabelValueLength {
+		b = b[:maxLabelValueLength]
+	}
+	return strings.Trim(string(b), "-_.")
+}
+
+// isAlphaNumeric flags if the character is a letter or a digit
+func isAlphaNumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// RunLabels returns the labels that are set on every object created by an
+// apply of this run
+func RunLabels() map[string]string {
+	labels := map[string]string{RunIDLabel: LabelValue(RunID())}
+	if test := LabelValue(TestName()); len(test) != 0 {
+		labels[TestLabel] = test
+	}
+	return labels
+}
+
+// TrackedObject is an object that was created by a kubectl apply
+type TrackedObject struct {
+	// Kind of the object as displayed by kubectl e.g. deployment.apps
+	Kind string
+	// Name of the object
+	Name string
+	// Namespace of the object
+	Namespace string
+	// RunID is the id of the run that created the object
+	RunID string
+}
+
+// String returns the kind, name & namespace of the object
+func (o TrackedObject) String() string {
+	return fmt.Sprintf("%s/%s in namespace '%s'", o.Kind, o.Name, o.Namespace)
+}
+
+// deleteOrder is the order in which the kinds are created. The objects are
+// deleted in the reverse of this order. Kinds that are not listed e.g. custom
+// resources are deleted first since they depend on their definitions.
+var deleteOrder = []string{
+	"namespace",
+	"resourcequota",
+	"limitrange",
+	"podsecuritypolicy",
+	"secret",
+	"configmap",
+	"storageclass",
+	"persistentvolume",
+	"persistentvolumeclaim",
+	"serviceaccount",
+	"customresourcedefinition",
+	"clusterrole",
+	"clusterrolebinding",
+	"role",
+	"rolebinding",
+	"service",
+	"daemonset",
+	"pod",
+	"replicationcontroller",
+	"replicaset",
+	"deployment",
+	"statefulset",
+	"job",
+	"cronjob",
+	"ingress",
+	"poddisruptionbudget",
+}
+
+// createRank returns the position of the kind in the creation order
+func createRank(kind string) int {
+	k := strings.SplitN(kind, ".", 2)[0]
+	for i, o := range deleteOrder {
+		if k == o {
+			return i
+		}
+	}
+	return len(deleteOrder)
+}
+
+// ResourceTracker tracks the objects created by kubectl applies so that they
+// can be deleted at teardown
+//
+// NOTE:
+//  Objects that were configured or left unchanged by an apply existed before
+// the apply & hence are not tracked. Objects labelled to be retained are not
+// tracked either. The namespace of the command is assumed for the objects of
+// manifests that can not be read e.g. urls.
+type ResourceTracker struct {
+	mutex   sync.Mutex
+	objects []TrackedObject
+}
+
+// NewResourceTracker returns a new instance of ResourceTracker
+func NewResourceTracker() *ResourceTracker {
+	return &ResourceTracker{}
+}
+
+// defaultResourceTracker tracks the objects created by the applies of every
+// kubectl instance
+var defaultResourceTracker = NewResourceTracker()
+
+// DefaultResourceTracker returns the tracker of the objects created by the
+// applies of every kubectl instance
+func DefaultResourceTracker() *ResourceTracker {
+	return defaultResourceTracker
+}
+
+// Objects returns the tracked objects in the order they were created
+func (t *ResourceTracker) Objects() []TrackedObject {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]TrackedObject(nil), t.objects...)
+}
+
+// Track tracks the object unless it is already tracked
+func (t *ResourceTracker) Track(obj TrackedObject) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, o := range t.objects {
+		if o.Kind == obj.Kind && o.Name == obj.Name && o.Namespace == obj.Namespace {
+			return
+		}
+	}
+	t.objects = append(t.objects, obj)
+}
+
+// forget stops tracking the object
+func (t *ResourceTracker) forget(obj TrackedObject) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for i, o := range t.objects {
+		if o.Kind == obj.Kind && o.Name == obj.Name && o.Namespace == obj.Namespace {
+			t.objects = append(t.objects[:i], t.objects[i+1:]...)
+			return
+		}
+	}
+}
+
+// trackApply tracks the objects reported as created in the output of the
+// apply & returns them. The namespace of an object is taken from its manifest
+// if set, else from the command.
+func (t *ResourceTracker) trackApply(namespace string, args []string, stdin []byte, output string) (created []TrackedObject) {
+	if ns := argValue(args, "-n", "--namespace"); len(ns) != 0 {
+		namespace = ns
+	}
+	manifests := manifestObjects(args, stdin)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[1] != "created" {
+			continue
+		}
+
+		kn := strings.SplitN(fields[0], "/", 2)
+		if len(kn) != 2 {
+			continue
+		}
+
+		obj := TrackedObject{Kind: kn[0], Name: kn[1], Namespace: namespace, RunID: RunID()}
+		if m, ok := manifests[strings.SplitN(kn[0], ".", 2)[0]+"/"+kn[1]]; ok {
+			if m.Metadata.Labels[RetainLabel] == "true" {
+				continue
+			}
+			if len(m.Metadata.Namespace) != 0 {
+				obj.Namespace = m.Metadata.Namespace
+			}
+		}
+		t.Track(obj)
+		created = append(created, obj)
+	}
+	return
+}
+
+// labelManifests sets the run labels on the objects of the applied manifests
+// that do not exist yet. The labels are set on their pod templates & volume
+// claim templates as well so that the pods & claims created for them carry
+// these labels. The manifests are then applied from stdin. The args are
+// returned as is if any manifest can not be read e.g. urls & directories.
+//
+// NOTE:
+//  Objects that exist are looked up before the apply & are not labelled since
+// they do not belong to this run. All the objects are labelled if this lookup
+// fails e.g. custom resources whose definition is part of the manifests.
+// Objects labelled to be retained are not labelled either.
+func (k *Kubectl) labelManifests(args []string, stdin []byte) (rewritten []string, data []byte, ok bool) {
+	objs, err := readManifests(args, stdin)
+	if err != nil || len(objs) == 0 {
+		return args, stdin, false
+	}
+
+	existing, err := k.existingObjects(args, objs)
+	if err != nil {
+		log.Printf("failed to look up existing objects: all objects will be labelled with the run labels: %s", err)
+	}
+
+	labels := RunLabels()
+	for _, obj := range objs {
+		o := toObject(obj)
+		if existing[manifestKey(o)] || o.Metadata.Labels[RetainLabel] == "true" {
+			continue
+		}
+		setRunLabels(obj, labels)
+	}
+
+	rewritten, data, err = stdinManifests(args, objs)
+	if err != nil {
+		return args, stdin, false
+	}
+	return rewritten, data, true
+}
+
+// existingObjects returns the keys of the objects that exist; see manifestKey
+func (k *Kubectl) existingObjects(args []string, objs []map[string]interface{}) (existing map[string]bool, err error) {
+	existing = map[string]bool{}
+	getArgs, data, err := stdinManifests([]string{"get"}, objs)
+	if err != nil {
+		return
+	}
+
+	kk := k.Labels("")
+	if ns := argValue(args, "-n", "--namespace"); len(ns) != 0 {
+		kk = kk.Namespace(ns)
+	}
+	ctx, cancel := kk.newContext()
+	defer cancel()
+
+	output, err := kk.executor.StdinExecuteContext(ctx, kk.Command(append(getArgs, "--ignore-not-found", "-o", "name")), data)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		kn := strings.SplitN(strings.TrimSpace(line), "/", 2)
+		if len(kn) != 2 {
+			continue
+		}
+		existing[strings.SplitN(kn[0], ".", 2)[0]+"/"+kn[1]] = true
+	}
+	return
+}
+
+// setRunLabels sets the run labels on the object, on its pod template & on
+// its volume claim templates. The pod template of a cron job is found within
+// its job template.
+func setRunLabels(obj map[string]interface{}, labels map[string]string) {
+	setLabels(obj, labels)
+
+	spec, _ := obj["spec"].(map[string]interface{})
+	if template, ok := spec["template"].(map[string]interface{}); ok {
+		setLabels(template, labels)
+	}
+	if template, ok := spec["jobTemplate"].(map[string]interface{}); ok {
+		setRunLabels(template, labels)
+	}
+
+	claims, _ := spec["volumeClaimTemplates"].([]interface{})
+	for _, c := range claims {
+		if claim, ok := c.(map[string]interface{}); ok {
+			setLabels(claim, labels)
+		}
+	}
+}
+
+// setLabels sets the labels in the metadata of the object
+func setLabels(obj map[string]interface{}, labels map[string]string) {
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		obj["metadata"] = metadata
+	}
+	current, ok := metadata["labels"].(map[string]interface{})
+	if !ok {
+		current = map[string]interface{}{}
+		metadata["labels"] = current
+	}
+	for key, value := range labels {
+		current[key] = value
+	}
+}
+
+// isApply flags if the kubectl args create objects from manifests
+func isApply(args []string) bool {
+	for _, a := range args {
+		if strings.HasPrefix(a, "-") {
+			continue
+		}
+		return a == "apply" || a == "create"
+	}
+	return false
+}
+
+// argValue returns the value of the first flag that matches any of the
+// provided names e.g. '-n litmus' or '--namespace=litmus'
+func argValue(args []string, names ...string) string {
+	values := argValues(args, names...)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// argValues returns the values of the flags that match any of the provided
+// names
+func argValues(args []string, names ...string) (values []string) {
+	for i := 0; i < len(args); i++ {
+		for _, n := range names {
+			switch {
+			case args[i] == n && i+1 < len(args):
+				values = append(values, args[i+1])
+			case strings.HasPrefix(args[i], n+"="):
+				values = append(values, strings.TrimPrefix(args[i], n+"="))
+			}
+		}
+	}
+	return
+}
+
+// manifestObjects returns the objects of the applied manifests keyed by their
+// lower cased kind & name. Manifests that can not be read e.g. urls &
+// directories are skipped.
+func manifestObjects(args []string, stdin []byte) map[string]Object {
+	objs := map[string]Object{}
+	for _, f := range argValues(args, "-f", "--filename") {
+		docs, err := readManifest(f, stdin)
+		if err != nil {
+			continue
+		}
+		for _, doc := range docs {
+			if o := toObject(doc); len(o.Metadata.Name) != 0 {
+				objs[manifestKey(o)] = o
+			}
+		}
+	}
+	return objs
+}
+
+// manifestKey returns the lower cased kind & name of the object
+func manifestKey(o Object) string {
+	return strings.ToLower(o.Kind) + "/" + o.Metadata.Name
+}
+
+// toObject returns the typed form of the object. An empty object is returned
+// if the object is not a kubernetes object.
+func toObject(obj map[string]interface{}) (o Object) {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return
+	}
+	yaml.Unmarshal(data, &o)
+	return
+}
+
+// readManifests returns the objects of the manifests applied via the args.
+// An error is returned if there are no manifests or if any manifest can not
+// be read e.g. urls & directories.
+func readManifests(args []string, stdin []byte) (objs []map[string]interface{}, err error) {
+	files := argValues(args, "-f", "--filename")
+	if len(files) == 0 {
+		err = fmt.Errorf("failed to read manifests: no manifest in args '%v'", args)
+		return
+	}
+
+	for _, f := range files {
+		var docs []map[string]interface{}
+		docs, err = readManifest(f, stdin)
+		if err != nil {
+			return
+		}
+		objs = append(objs, docs...)
+	}
+	return
+}
+
+// readManifest returns the objects of the manifest file. The manifest is read
+// from stdin if the file is '-'.
+func readManifest(file string, stdin []byte) (objs []map[string]interface{}, err error) {
+	data := stdin
+	if file != "-" {
+		data, err = ioutil.ReadFile(file)
+		if err != nil {
+			return
+		}
+	}
+
+	for _, doc := range strings.Split(string(data), "\n---") {
+		var obj map[string]interface{}
+		err = yaml.Unmarshal([]byte(doc), &obj)
+		if err != nil {
+			return
+		}
+		if len(obj) != 0 {
+			objs = append(objs, obj)
+		}
+	}
+	return
+}
+
+// stdinManifests returns the args that apply the provided objects from stdin
+// instead of the manifests set in the args & the manifest of these objects
+func stdinManifests(args []string, objs []map[string]interface{}) (rewritten []string, data []byte, err error) {
+	var docs []string
+	for _, obj := range objs {
+		var out []byte
+		out, err = yaml.Marshal(obj)
+		if err != nil {
+			return
+		}
+		docs = append(docs, string(out))
+	}
+
+	rewritten = withoutFlags(args, "-f", "--filename")
+	rewritten = append(rewritten, "-f", "-")
+	data = []byte(strings.Join(docs, "---\n"))
+	return
+}
+
+// Teardown deletes the tracked objects in the reverse order of their
+// dependencies & waits for each deletion to complete. The objects that could
+// not be deleted are reported in the error & remain tracked.
+func (t *ResourceTracker) Teardown(k *Kubectl, timeout time.Duration) (err error) {
+	if timeout <= 0 {
+		timeout = DefaultTeardownTimeout
+	}
+
+	objs := t.Objects()
+	// reverse the creation order before sorting the kinds by their rank
+	for i, j := 0, len(objs)-1; i < j; i, j = i+1, j-1 {
+		objs[i], objs[j] = objs[j], objs[i]
+	}
+	sort.SliceStable(objs, func(i, j int) bool {
+		return createRank(objs[i].Kind) > createRank(objs[j].Kind)
+	})
+
+	var failed []string
+	for _, o := range objs {
+		derr := deleteObject(k.Namespace(o.Namespace), o, timeout)
+		if derr != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", o, derr))
+			continue
+		}
+		t.forget(o)
+	}
+
+	if len(failed) != 0 {
+		err = fmt.Errorf("failed to delete '%d' tracked object(s):\n  %s", len(failed), strings.Join(failed, "\n  "))
+	}
+	return
+}
+
+// deleteObject deletes the object & waits till it is gone
+func deleteObject(k KubeRunner, o TrackedObject, timeout time.Duration) (err error) {
+	_, err = k.Run([]string{"delete", o.Kind, o.Name, "--ignore-not-found"})
+	if err != nil {
+		return
+	}
+
+	return wait.NewWaiter(nil).Eventually(timeout, teardownPollInterval, func() (bool, error) {
+		_, err := GetObject(k, o.Kind, o.Name)
+		if IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// TeardownResources deletes the objects created by the applies of every
+// kubectl instance. The namespace of the provided instance is overridden by
+// that of each object.
+func TeardownResources(k *Kubectl) error {
+	return defaultResourceTracker.Teardown(k, DefaultTeardownTimeout)
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTrackApply(t *testing.T) {
+	manifest := []byte(`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: openebs
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: maya-apiserver
+  namespace: openebs
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: minio-config
+`)
+
+	tests := map[string]struct {
+		args     []string
+		stdin    []byte
+		output   string
+		expected []TrackedObject
+	}{
+		"track apply - +ve test case - created objects with manifest namespace": {
+			args:   []string{"apply", "-f", "-"},
+			stdin:  manifest,
+			output: "namespace/openebs created\ndeployment.apps/maya-apiserver created\nconfigmap/minio-config created",
+			expected: []TrackedObject{
+				{Kind: "namespace", Name: "openebs", Namespace: "litmus", RunID: RunID()},
+				{Kind: "deployment.apps", Name: "maya-apiserver", Namespace: "openebs", RunID: RunID()},
+				{Kind: "configmap", Name: "minio-config", Namespace: "litmus", RunID: RunID()},
+			},
+		},
+		"track apply - +ve test case - configured & unchanged objects are not tracked": {
+			args:   []string{"apply", "-f", "-"},
+			stdin:  manifest,
+			output: "namespace/openebs unchanged\ndeployment.apps/maya-apiserver configured\nconfigmap/minio-config created",
+			expected: []TrackedObject{
+				{Kind: "configmap", Name: "minio-config", Namespace: "litmus", RunID: RunID()},
+			},
+		},
+		"track apply - +ve test case - namespace flag": {
+			args:   []string{"create", "-n", "test", "-f", "/missing/app.yaml"},
+			output: "service/minio created",
+			expected: []TrackedObject{
+				{Kind: "service", Name: "minio", Namespace: "test", RunID: RunID()},
+			},
+		},
+		"track apply - +ve test case - retained objects are not tracked": {
+			args:   []string{"apply", "-f", "-"},
+			stdin:  []byte("kind: ConfigMap\nmetadata:\n  name: diagnostics\n  labels:\n    litmus.io/retain: \"true\"\n"),
+			output: "configmap/diagnostics created",
+		},
+		"track apply - +ve test case - dry run plan": {
+			args:   []string{"apply", "-f", "-"},
+			stdin:  manifest,
+			output: "[dry-run] kubectl apply -f -",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := NewResourceTracker()
+			tracker.trackApply("litmus", mock.args, mock.stdin, mock.output)
+			// an object is tracked once
+			tracker.trackApply("litmus", mock.args, mock.stdin, mock.output)
+
+			if objs := tracker.Objects(); !reflect.DeepEqual(objs, mock.expected) {
+				t.Fatalf("failed to track apply: expected '%v': actual '%v'", mock.expected, objs)
+			}
+		})
+	}
+}
+
+// lookupExec is an executor that reports the objects in existing as the
+// output of a get & fails the get if err is set
+type lookupExec struct {
+	echoExec
+	existing string
+	err      error
+}
+
+func (e lookupExec) StdinExecute(args []string, stdin []byte) (string, error) {
+	if args[0] == "get" {
+		return e.existing, e.err
+	}
+	return e.echoExec.StdinExecute(args, stdin)
+}
+
+func (e lookupExec) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (string, error) {
+	return e.StdinExecute(args, stdin)
+}
+
+func TestLabelManifests(t *testing.T) {
+	manifest := []byte(`
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: minio
+spec:
+  template:
+    metadata:
+      labels:
+        app: minio
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: minio-put
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: diagnostics
+  labels:
+    litmus.io/retain: "true"
+`)
+	run := "litmus.io/run-id: " + LabelValue(RunID())
+
+	tests := map[string]struct {
+		args        []string
+		executor    lookupExec
+		isRewritten bool
+		labels      map[string]int
+	}{
+		"label manifests - +ve test case - objects, templates & claims": {
+			args:        []string{"apply", "-f", "-"},
+			isRewritten: true,
+			// the statefulset, its pod & claim templates and the cron job, its
+			// job & pod templates
+			labels: map[string]int{run: 6, "app: minio": 1},
+		},
+		"label manifests - +ve test case - existing objects are not labelled": {
+			args:        []string{"apply", "-f", "-"},
+			executor:    lookupExec{existing: "statefulset.apps/minio\n"},
+			isRewritten: true,
+			labels:      map[string]int{run: 3},
+		},
+		"label manifests - +ve test case - failed lookup labels all objects": {
+			args:        []string{"apply", "-f", "-"},
+			executor:    lookupExec{existing: "statefulset.apps/minio\n", err: fmt.Errorf("error: the server doesn't have a resource type")},
+			isRewritten: true,
+			labels:      map[string]int{run: 6},
+		},
+		"label manifests - -ve test case - unreadable manifest": {
+			args:   []string{"apply", "-f", "https://litmus.io/minio.yaml"},
+			labels: map[string]int{run: 0},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			args, data, ok := New().Executor(mock.executor).labelManifests(mock.args, manifest)
+			if ok != mock.isRewritten {
+				t.Fatalf("failed to label manifests: expected '%t': actual '%t' '%v'", mock.isRewritten, ok, args)
+			}
+			if ok && !reflect.DeepEqual(args, []string{"apply", "-f", "-"}) {
+				t.Fatalf("failed to label manifests: expected 'apply from stdin': actual '%v'", args)
+			}
+			for label, count := range mock.labels {
+				if actual := strings.Count(string(data), label); actual != count {
+					t.Fatalf("failed to label manifests: expected '%d' '%s': actual '%d': '%s'", count, label, actual, data)
+				}
+			}
+		})
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected string
+	}{
+		"label value - +ve test case - valid value":        {value: "ha-on-minio", expected: "ha-on-minio"},
+		"label value - +ve test case - spaces":             {value: " Test the HA of minio ", expected: "Test-the-HA-of-minio"},
+		"label value - +ve test case - invalid boundaries": {value: "(minio) deploy!", expected: "minio--deploy"},
+		"label value - +ve test case - long value":         {value: strings.Repeat("a", 62) + " b", expected: strings.Repeat("a", 62)},
+		"label value - +ve test case - empty value":        {value: "", expected: ""},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := LabelValue(mock.value); actual != mock.expected {
+				t.Fatalf("failed to get label value: expected '%s': actual '%s'", mock.expected, actual)
+			}
+		})
+	}
+}
+
+// teardownExec is an executor that records the deletions & reports every
+// object as not found. Deletion of the objects in failing fails.
+type teardownExec struct {
+	echoExec
+	deleted *[]string
+	failing map[string]bool
+}
+
+func (e teardownExec) Execute(args []string) (string, error) {
+	switch args[0] {
+	case "delete":
+		if e.failing[args[2]] {
+			return "", fmt.Errorf("Error from server (Forbidden): %s \"%s\" is forbidden", args[1], args[2])
+		}
+		*e.deleted = append(*e.deleted, args[1]+"/"+args[2])
+		return fmt.Sprintf("%s \"%s\" deleted", args[1], args[2]), nil
+	case "get":
+		return "", fmt.Errorf("Error from server (NotFound): %s \"%s\" not found", args[1], args[2])
+	default:
+		return "deployment.apps/minio created", nil
+	}
+}
+
+func (e teardownExec) ExecuteContext(ctx context.Context, args []string) (string, error) {
+	return e.Execute(args)
+}
+
+func TestTeardown(t *testing.T) {
+	var deleted []string
+	k := New().Executor(teardownExec{deleted: &deleted, failing: map[string]bool{"leaked": true}})
+
+	tracker := NewResourceTracker()
+	for _, o := range []TrackedObject{
+		{Kind: "namespace", Name: "openebs"},
+		{Kind: "storageclass.storage.k8s.io", Name: "openebs-standard"},
+		{Kind: "persistentvolumeclaim", Name: "minio-pvc", Namespace: "litmus"},
+		{Kind: "deployment.apps", Name: "minio", Namespace: "litmus"},
+		{Kind: "service", Name: "minio", Namespace: "litmus"},
+		{Kind: "configmap", Name: "leaked", Namespace: "litmus"},
+		{Kind: "storagepoolclaim.openebs.io", Name: "cstor-pool"},
+		{Kind: "job.batch", Name: "minio-put", Namespace: "litmus"},
+	} {
+		tracker.Track(o)
+	}
+
+	err := tracker.Teardown(k, 0)
+	if err == nil || !strings.Contains(err.Error(), "configmap/leaked in namespace 'litmus'") {
+		t.Fatalf("failed to teardown: expected 'leaked configmap error': actual '%v'", err)
+	}
+
+	expected := []string{
+		"storagepoolclaim.openebs.io/cstor-pool",
+		"job.batch/minio-put",
+		"deployment.apps/minio",
+		"service/minio",
+		"persistentvolumeclaim/minio-pvc",
+		"storageclass.storage.k8s.io/openebs-standard",
+		"namespace/openebs",
+	}
+	if !reflect.DeepEqual(deleted, expected) {
+		t.Fatalf("failed to teardown in reverse dependency order: expected '%v': actual '%v'", expected, deleted)
+	}
+
+	objs := tracker.Objects()
+	if len(objs) != 1 || objs[0].Name != "leaked" {
+		t.Fatalf("failed to teardown: expected 'only leaked configmap tracked': actual '%v'", objs)
+	}
+}
+
+func TestRunTracksApply(t *testing.T) {
+	defer func() { defaultResourceTracker = NewResourceTracker() }()
+
+	var deleted []string
+	k := New().Executor(teardownExec{deleted: &deleted}).Namespace("litmus")
+
+	if _, err := k.Run([]string{"apply", "-f", "/etc/e2e/minio.yaml"}); err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+	if _, err := k.Run([]string{"get", "pods", "minio"}); err == nil {
+		t.Fatalf("failed to get: expected 'not found error': actual 'no error'")
+	}
+
+	expected := []TrackedObject{{Kind: "deployment.apps", Name: "minio", Namespace: "litmus", RunID: RunID()}}
+	if objs := DefaultResourceTracker().Objects(); !reflect.DeepEqual(objs, expected) {
+		t.Fatalf("failed to track apply: expected '%v': actual '%v'", expected, objs)
+	}
+
+	if err := TeardownResources(k); err != nil {
+		t.Fatalf("failed to teardown: expected 'no error': actual '%s'", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"deployment.apps/minio"}) || len(DefaultResourceTracker().Objects()) != 0 {
+		t.Fatalf("failed to teardown: expected 'deleted deployment': actual '%v'", deleted)
+	}
+}
@@ -0,0 +1,382 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"time"
+)
+
+// The types in this file are a minimal subset of the kubernetes api objects.
+// They carry only those fields that litmus makes use of & are decoded from
+// the json representation of these objects.
+
+const (
+	// PodPending is the phase of a pod that is accepted but whose containers
+	// are not yet running
+	PodPending = "Pending"
+	// PodRunning is the phase of a pod that is bound to a node & has all
+	// its containers created
+	PodRunning = "Running"
+	// PodSucceeded is the phase of a pod whose containers have all terminated
+	// successfully
+	PodSucceeded = "Succeeded"
+	// PodFailed is the phase of a pod whose containers have all terminated
+	// & at least one of them has failed
+	PodFailed = "Failed"
+)
+
+// ObjectMeta is the metadata common to all kubernetes objects
+type ObjectMeta struct {
+	// Name of the object
+	Name string `json:"name"`
+	// Namespace of the object
+	Namespace string `json:"namespace,omitempty"`
+	// UID is the unique id of the object
+	UID string `json:"uid,omitempty"`
+	// Labels of the object
+	Labels map[string]string `json:"labels,omitempty"`
+	// CreationTimestamp is the time when this object was created
+	CreationTimestamp time.Time `json:"creationTimestamp,omitempty"`
+}
+
+// ContainerStatus is the status of a container in a pod
+type ContainerStatus struct {
+	// Name of the container
+	Name string `json:"name"`
+	// Ready flags if the container has passed its readiness probe
+	Ready bool `json:"ready"`
+	// RestartCount is the number of times the container has been restarted
+	RestartCount int `json:"restartCount"`
+}
+
+// Container is a container of a pod
+type Container struct {
+	// Name of the container
+	Name string `json:"name"`
+	// Image run by the container
+	Image string `json:"image,omitempty"`
+}
+
+// PodSpec is the specification of a pod
+type PodSpec struct {
+	// NodeName is the node where this pod is scheduled
+	NodeName string `json:"nodeName,omitempty"`
+	// Containers of the pod
+	Containers []Container `json:"containers,omitempty"`
+}
+
+// PodTemplateSpec is the template of the pods created by a workload
+type PodTemplateSpec struct {
+	// Spec of the pods
+	Spec PodSpec `json:"spec"`
+}
+
+// PodStatus is the observed state of a pod
+type PodStatus struct {
+	// Phase of the pod e.g. Pending, Running, Succeeded, Failed
+	Phase string `json:"phase,omitempty"`
+	// InitContainerStatuses are the statuses of this pod's init containers
+	InitContainerStatuses []ContainerStatus `json:"initContainerStatuses,omitempty"`
+	// ContainerStatuses are the statuses of this pod's containers
+	ContainerStatuses []ContainerStatus `json:"containerStatuses,omitempty"`
+}
+
+// Pod is a kubernetes pod
+type Pod struct {
+	// Metadata of the pod
+	Metadata ObjectMeta `json:"metadata"`
+	// Spec of the pod
+	Spec PodSpec `json:"spec"`
+	// Status of the pod
+	Status PodStatus `json:"status"`
+}
+
+// ObjectSpec is the part of the specification of any kubernetes object that
+// is of interest e.g. while verifying the expected state of the object
+type ObjectSpec struct {
+	// Replicas is the desired replica count of a workload
+	Replicas *int `json:"replicas,omitempty"`
+	// Template is the pod template of a workload
+	Template *PodTemplateSpec `json:"template,omitempty"`
+	// Containers of a pod
+	Containers []Container `json:"containers,omitempty"`
+	// StorageClassName is the storage class of a pvc
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
+// ObjectCondition is a status condition reported by any kubernetes object
+type ObjectCondition struct {
+	// Type of the condition e.g. Ready, Available
+	Type string `json:"type"`
+	// Status of the condition i.e. True, False or Unknown
+	Status string `json:"status"`
+	// Reason for the condition's last transition
+	Reason string `json:"reason,omitempty"`
+	// Message is the human readable detail of the condition
+	Message string `json:"message,omitempty"`
+}
+
+// ObjectStatus is the part of the observed state of any kubernetes object
+// that is of interest e.g. while verifying the expected state of the object
+type ObjectStatus struct {
+	// Phase of a pod or a pvc
+	Phase string `json:"phase,omitempty"`
+	// ReadyReplicas is the number of ready replicas of a workload
+	ReadyReplicas int `json:"readyReplicas,omitempty"`
+	// Conditions reported by the object
+	Conditions []ObjectCondition `json:"conditions,omitempty"`
+	// ContainerStatuses are the statuses of a pod's containers
+	ContainerStatuses []ContainerStatus `json:"containerStatuses,omitempty"`
+	// Capacity is the actual capacity of a pvc
+	Capacity map[string]string `json:"capacity,omitempty"`
+}
+
+// Object is any kubernetes object of which only the metadata is of interest
+type Object struct {
+	// Kind of the object e.g. Deployment, Service, etc
+	Kind string `json:"kind"`
+	// Metadata of the object
+	Metadata ObjectMeta `json:"metadata"`
+}
+
+// ObjectState is any kubernetes object of which the metadata & few of the
+// fields of its specification & status are of interest
+type ObjectState struct {
+	// Kind of the object e.g. Deployment, Service, etc
+	Kind string `json:"kind"`
+	// Metadata of the object
+	Metadata ObjectMeta `json:"metadata"`
+	// Spec of the object
+	Spec ObjectSpec `json:"spec"`
+	// Status of the object
+	Status ObjectStatus `json:"status"`
+}
+
+// ObjectStateList is a list of kubernetes objects of any kind along with
+// their state
+type ObjectStateList struct {
+	// Items are the objects in this list
+	Items []ObjectState `json:"items"`
+}
+
+// ConfigMap is a kubernetes config map
+type ConfigMap struct {
+	// Metadata of the config map
+	Metadata ObjectMeta `json:"metadata"`
+	// Data of the config map
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// ObjectList is a list of kubernetes objects of any kind
+type ObjectList struct {
+	// Items are the objects in this list
+	Items []Object `json:"items"`
+}
+
+// PodList is a list of kubernetes pods
+type PodList struct {
+	// Items are the pods in this list
+	Items []Pod `json:"items"`
+}
+
+// Taint is a kubernetes node taint
+type Taint struct {
+	// Key of the taint
+	Key string `json:"key"`
+	// Value of the taint
+	Value string `json:"value,omitempty"`
+	// Effect of the taint e.g. NoSchedule
+	Effect string `json:"effect"`
+}
+
+// NodeSpec is the specification of a node
+type NodeSpec struct {
+	// Unschedulable flags if the node is cordoned
+	Unschedulable bool `json:"unschedulable,omitempty"`
+	// Taints of the node
+	Taints []Taint `json:"taints,omitempty"`
+}
+
+// NodeCondition is a condition of a node e.g. Ready, DiskPressure
+type NodeCondition struct {
+	// Type of the condition
+	Type string `json:"type"`
+	// Status of the condition i.e. True, False or Unknown
+	Status string `json:"status"`
+	// Reason is a short machine understood reason of the condition's status
+	Reason string `json:"reason,omitempty"`
+	// Message is the human readable description of the condition's status
+	Message string `json:"message,omitempty"`
+}
+
+// NodeStatus is the observed state of a node
+type NodeStatus struct {
+	// Conditions of the node
+	Conditions []NodeCondition `json:"conditions,omitempty"`
+}
+
+// Node is a kubernetes node
+type Node struct {
+	// Metadata of the node
+	Metadata ObjectMeta `json:"metadata"`
+	// Spec of the node
+	Spec NodeSpec `json:"spec"`
+	// Status of the node
+	Status NodeStatus `json:"status"`
+}
+
+// NodeList is a list of kubernetes nodes
+type NodeList struct {
+	// Items are the nodes in this list
+	Items []Node `json:"items"`
+}
+
+// ServiceSpec is the specification of a service
+type ServiceSpec struct {
+	// ClusterIP is the ip address of the service within the cluster
+	ClusterIP string `json:"clusterIP,omitempty"`
+}
+
+// Service is a kubernetes service
+type Service struct {
+	// Metadata of the service
+	Metadata ObjectMeta `json:"metadata"`
+	// Spec of the service
+	Spec ServiceSpec `json:"spec"`
+}
+
+// JobStatus is the observed state of a job
+type JobStatus struct {
+	// Active is the number of running pods of this job
+	Active int `json:"active,omitempty"`
+	// Succeeded is the number of pods of this job that completed successfully
+	Succeeded int `json:"succeeded,omitempty"`
+	// Failed is the number of pods of this job that failed
+	Failed int `json:"failed,omitempty"`
+}
+
+// Job is a kubernetes job
+type Job struct {
+	// Metadata of the job
+	Metadata ObjectMeta `json:"metadata"`
+	// Status of the job
+	Status JobStatus `json:"status"`
+}
+
+// PersistentVolumeClaimSpec is the specification of a persistent volume claim
+type PersistentVolumeClaimSpec struct {
+	// VolumeName is the persistent volume bound to this claim
+	VolumeName string `json:"volumeName,omitempty"`
+	// StorageClassName is the storage class requested by this claim
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
+// PersistentVolumeClaimStatus is the observed state of a persistent volume
+// claim
+type PersistentVolumeClaimStatus struct {
+	// Phase of the claim e.g. Pending, Bound, Lost
+	Phase string `json:"phase,omitempty"`
+}
+
+// PersistentVolumeClaim is a kubernetes persistent volume claim
+type PersistentVolumeClaim struct {
+	// Metadata of the claim
+	Metadata ObjectMeta `json:"metadata"`
+	// Spec of the claim
+	Spec PersistentVolumeClaimSpec `json:"spec"`
+	// Status of the claim
+	Status PersistentVolumeClaimStatus `json:"status"`
+}
+
+// PersistentVolumeSpec is the specification of a persistent volume
+type PersistentVolumeSpec struct {
+	// ClaimRef refers to the claim bound to this volume
+	ClaimRef *ObjectReference `json:"claimRef,omitempty"`
+	// StorageClassName is the storage class of this volume
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// PersistentVolumeReclaimPolicy is what happens to this volume when its
+	// claim is deleted e.g. Retain, Delete
+	PersistentVolumeReclaimPolicy string `json:"persistentVolumeReclaimPolicy,omitempty"`
+}
+
+// PersistentVolumeStatus is the observed state of a persistent volume
+type PersistentVolumeStatus struct {
+	// Phase of the volume e.g. Available, Bound, Released, Failed
+	Phase string `json:"phase,omitempty"`
+}
+
+// PersistentVolume is a kubernetes persistent volume
+type PersistentVolume struct {
+	// Metadata of the volume
+	Metadata ObjectMeta `json:"metadata"`
+	// Spec of the volume
+	Spec PersistentVolumeSpec `json:"spec"`
+	// Status of the volume
+	Status PersistentVolumeStatus `json:"status"`
+}
+
+// PersistentVolumeList is a list of kubernetes persistent volumes
+type PersistentVolumeList struct {
+	// Items are the volumes in this list
+	Items []PersistentVolume `json:"items"`
+}
+
+const (
+	// EventNormal is the type of an event that is informational
+	EventNormal = "Normal"
+	// EventWarning is the type of an event that reports a problem
+	EventWarning = "Warning"
+)
+
+// ObjectReference refers to a kubernetes object
+type ObjectReference struct {
+	// Kind of the object e.g. Pod
+	Kind string `json:"kind,omitempty"`
+	// Namespace of the object
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the object
+	Name string `json:"name,omitempty"`
+}
+
+// Event is a kubernetes event
+type Event struct {
+	// Metadata of the event
+	Metadata ObjectMeta `json:"metadata"`
+	// InvolvedObject is the object this event is about
+	InvolvedObject ObjectReference `json:"involvedObject"`
+	// Type of the event i.e. Normal or Warning
+	Type string `json:"type,omitempty"`
+	// Reason is a short machine understood reason e.g. FailedScheduling
+	Reason string `json:"reason,omitempty"`
+	// Message is the human readable description of the event
+	Message string `json:"message,omitempty"`
+	// Count is the number of times this event has occurred
+	Count int `json:"count,omitempty"`
+	// FirstTimestamp is the time when this event was first recorded
+	FirstTimestamp time.Time `json:"firstTimestamp,omitempty"`
+	// LastTimestamp is the time when this event was last recorded
+	LastTimestamp time.Time `json:"lastTimestamp,omitempty"`
+	// EventTime is the time when this event was observed. This is set
+	// instead of the timestamps by the newer event reporters.
+	EventTime time.Time `json:"eventTime,omitempty"`
+}
+
+// EventList is a list of kubernetes events
+type EventList struct {
+	// Items are the events in this list
+	Items []Event `json:"items"`
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+// catalog are the install files embedded in litmus keyed by their name. These
+// can be included by the install files of the tests; see Include.
+//
+// NOTE:
+//  A name is of the form <provider>/<installation>-<version> so that each
+// version of a shared installation is defined in one place
+var catalog = map[string]string{
+	"openebs/operator-v0.5.3": `
+version: 0.5.3
+components:
+- kind: serviceaccount
+  name: openebs-maya-operator
+  namespace: default
+  alias: service-account
+- kind: clusterrole
+  name: openebs-maya-operator
+  namespace: default
+  alias: cluster-role
+- kind: clusterrolebinding
+  name: openebs-maya-operator
+  namespace: default
+  alias: cluster-role-binding
+  dependsOn:
+  - service-account
+  - cluster-role
+- kind: pod
+  labels: name=maya-apiserver
+  namespace: default
+  alias: apiserver
+  dependsOn:
+  - cluster-role-binding
+- kind: service
+  name: maya-apiserver-service
+  namespace: default
+  alias: apiserver-service
+  dependsOn:
+  - apiserver
+- kind: pod
+  labels: name=openebs-provisioner
+  namespace: default
+  alias: provisioner
+  dependsOn:
+  - cluster-role-binding
+`,
+}
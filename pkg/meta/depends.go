@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ordered returns the components of the installation in the order of their
+// dependencies i.e. a component is placed after the components it depends
+// on. Components that do not depend on each other retain their order in the
+// install file.
+func (i *Installation) Ordered() (components []Component, err error) {
+	order, cycles := i.dependencyOrder()
+	if len(cycles) != 0 {
+		err = fmt.Errorf("failed to order components: dependency cycle %s", formatCycle(i.Components, cycles[0]))
+		return
+	}
+
+	for _, idx := range order {
+		components = append(components, i.Components[idx])
+	}
+	return
+}
+
+// aliasIndexes returns the index of the components keyed by their alias
+func (i *Installation) aliasIndexes() map[string]int {
+	indexes := map[string]int{}
+	for idx, c := range i.Components {
+		if _, ok := indexes[c.Alias]; len(c.Alias) != 0 && !ok {
+			indexes[c.Alias] = idx
+		}
+	}
+	return indexes
+}
+
+// dependencyOrder returns the indexes of the components in the order of their
+// dependencies along with the cycles that prevent the remaining components
+// from being ordered. Each cycle is a list of component indexes that begins
+// with its lowest index. Dependencies on unknown aliases are ignored.
+func (i *Installation) dependencyOrder() (order []int, cycles [][]int) {
+	indexes := i.aliasIndexes()
+	deps := func(idx int) (d []int) {
+		for _, alias := range i.Components[idx].DependsOn {
+			if dep, ok := indexes[alias]; ok {
+				d = append(d, dep)
+			}
+		}
+		return
+	}
+
+	placed := make([]bool, len(i.Components))
+	for len(order) < len(i.Components) {
+		next := -1
+		for idx := range i.Components {
+			if placed[idx] {
+				continue
+			}
+			ready := true
+			for _, dep := range deps(idx) {
+				if !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = idx
+				break
+			}
+		}
+		if next == -1 {
+			break
+		}
+		placed[next] = true
+		order = append(order, next)
+	}
+
+	// every component that is not placed leads to a cycle; the walk from it
+	// along its unplaced dependencies ends up in a cycle
+	inCycle := make([]bool, len(i.Components))
+	for idx := range i.Components {
+		if placed[idx] || inCycle[idx] {
+			continue
+		}
+
+		var path []int
+		seen := map[int]int{}
+		cur := idx
+		for {
+			if at, ok := seen[cur]; ok {
+				cycles = append(cycles, rotate(path[at:]))
+				for _, c := range path[at:] {
+					inCycle[c] = true
+				}
+				break
+			}
+			if inCycle[cur] {
+				break
+			}
+			seen[cur] = len(path)
+			path = append(path, cur)
+			for _, dep := range deps(cur) {
+				if !placed[dep] {
+					cur = dep
+					break
+				}
+			}
+		}
+	}
+	return
+}
+
+// rotate returns the cycle such that it begins with its lowest index
+func rotate(cycle []int) []int {
+	lowest := 0
+	for n, idx := range cycle {
+		if idx < cycle[lowest] {
+			lowest = n
+		}
+	}
+	return append(append([]int{}, cycle[lowest:]...), cycle[:lowest]...)
+}
+
+// formatCycle returns the aliases of the cycle e.g. 'a' -> 'b' -> 'a'
+func formatCycle(components []Component, cycle []int) string {
+	var aliases []string
+	for _, idx := range append(cycle, cycle[0]) {
+		aliases = append(aliases, fmt.Sprintf("'%s'", components[idx].Alias))
+	}
+	return strings.Join(aliases, " -> ")
+}
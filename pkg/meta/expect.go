@@ -0,0 +1,160 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+)
+
+// podPhases are the phases of a pod
+var podPhases = []string{"Pending", "Running", "Succeeded", "Failed", "Unknown"}
+
+// pvcPhases are the phases of a persistent volume claim
+var pvcPhases = []string{"Pending", "Bound", "Lost"}
+
+// conditionStatuses are the statuses of a status condition
+var conditionStatuses = []string{"True", "False", "Unknown"}
+
+// quantitySuffixes are the multipliers of the suffixes of a quantity
+var quantitySuffixes = map[string]float64{
+	"":   1,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// ParseQuantity converts a kubernetes quantity e.g. 5G or 512Mi into its
+// value in bytes
+func ParseQuantity(quantity string) (value int64, err error) {
+	q := strings.TrimSpace(quantity)
+	idx := strings.IndexFunc(q, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if idx == -1 {
+		idx = len(q)
+	}
+
+	multiplier, ok := quantitySuffixes[q[idx:]]
+	if !ok || idx == 0 {
+		err = fmt.Errorf("invalid quantity '%s'", quantity)
+		return
+	}
+
+	f, err := strconv.ParseFloat(q[:idx], 64)
+	if err != nil {
+		err = fmt.Errorf("invalid quantity '%s'", quantity)
+		return
+	}
+
+	value = int64(math.Ceil(f * multiplier))
+	return
+}
+
+// validate flags the fields of the expectation that do not apply to the kind
+// of the component or have invalid values
+func (e *Expectation) validate(kind string) (errs []string) {
+	if e == nil {
+		return
+	}
+
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	pod, replicated, pvc := util.IsBarePod(kind), util.IsReplicated(kind), util.IsPVC(kind)
+
+	if e.Replicas != nil {
+		if !pod && !replicated {
+			invalid("expect: replicas does not apply to kind '%s'", kind)
+		} else if *e.Replicas < 0 {
+			invalid("expect: replicas '%d' should not be negative", *e.Replicas)
+		}
+	}
+	if e.MinReady != nil {
+		if !pod && !replicated {
+			invalid("expect: minReady does not apply to kind '%s'", kind)
+		} else if *e.MinReady < 0 {
+			invalid("expect: minReady '%d' should not be negative", *e.MinReady)
+		} else if e.Replicas != nil && *e.MinReady > *e.Replicas {
+			invalid("expect: minReady '%d' should not be more than replicas '%d'", *e.MinReady, *e.Replicas)
+		}
+	}
+
+	if len(e.Phase) != 0 {
+		switch {
+		case pod:
+			if !contains(podPhases, e.Phase) {
+				invalid("expect: invalid pod phase '%s': expected one of '%s'", e.Phase, strings.Join(podPhases, "', '"))
+			}
+		case pvc:
+			if !contains(pvcPhases, e.Phase) {
+				invalid("expect: invalid pvc phase '%s': expected one of '%s'", e.Phase, strings.Join(pvcPhases, "', '"))
+			}
+		default:
+			invalid("expect: phase does not apply to kind '%s'", kind)
+		}
+	}
+
+	for _, c := range e.Conditions {
+		if len(strings.TrimSpace(c.Type)) == 0 {
+			invalid("expect: condition type is required")
+		}
+		if len(c.Status) != 0 && !contains(conditionStatuses, c.Status) {
+			invalid("expect: invalid status '%s' of condition '%s': expected one of '%s'", c.Status, c.Type, strings.Join(conditionStatuses, "', '"))
+		}
+	}
+
+	if len(e.Images) != 0 && !pod && !util.IsPodTemplated(kind) {
+		invalid("expect: images does not apply to kind '%s'", kind)
+	}
+
+	if len(e.Capacity) != 0 {
+		if !pvc {
+			invalid("expect: capacity does not apply to kind '%s'", kind)
+		} else if _, err := ParseQuantity(e.Capacity); err != nil {
+			invalid("expect: %s", err)
+		}
+	}
+	if len(e.StorageClass) != 0 && !pvc {
+		invalid("expect: storageClass does not apply to kind '%s'", kind)
+	}
+	return
+}
+
+// contains flags if the list contains the provided string
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
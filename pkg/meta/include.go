@@ -0,0 +1,300 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+)
+
+// DefaultIncludeKey is the key of a config map's data that holds the
+// included install file if the key is not set
+const DefaultIncludeKey = "config"
+
+// includer resolves & parses the install files included by an install file
+type includer struct {
+	// dir is the directory against which the relative paths are resolved
+	dir string
+	// offline flags if the includes are resolved without a cluster i.e. the
+	// config maps are not fetched & the files that can not be read are
+	// skipped
+	offline bool
+	// stack are the install files being included; used to detect cycles
+	stack []string
+	// partial flags if any include was skipped
+	partial bool
+}
+
+// inclusion is the result of resolving the includes of an install file
+type inclusion struct {
+	// components of the included install files in the order of includes
+	components []Component
+	// names describe the components e.g. component '2' of catalog 'x'
+	names []string
+	// partial flags if any include was skipped
+	partial bool
+}
+
+// includeAll resolves & parses the includes. The lines are the line numbers
+// of the includes if known.
+func (in *includer) includeAll(includes []Include, lines []int) (result inclusion, errs ValidationErrors) {
+	for n, inc := range includes {
+		line := 0
+		if n < len(lines) {
+			line = lines[n]
+		}
+		invalid := func(format string, args ...interface{}) {
+			msg := fmt.Sprintf("include '%d': %s", n, fmt.Sprintf(format, args...))
+			errs = append(errs, ValidationError{Line: line, Message: msg})
+		}
+
+		data, id, dir, resolved, err := in.resolve(inc)
+		if err != nil {
+			invalid("%s", err)
+			continue
+		}
+		if !resolved {
+			result.partial = true
+			continue
+		}
+
+		child := &includer{dir: dir, offline: in.offline, stack: append(append([]string{}, in.stack...), id)}
+		installation, err := child.parse(data)
+		if err != nil {
+			verrs, ok := err.(ValidationErrors)
+			if !ok {
+				invalid("%s: %s", id, err)
+				continue
+			}
+			for _, v := range verrs {
+				invalid("%s: %s", id, v.Error())
+			}
+			continue
+		}
+		result.partial = result.partial || child.partial
+
+		components := installation.Components
+		if len(inc.As) != 0 {
+			namespaceAliases(components, inc.As)
+		}
+		for idx, c := range components {
+			result.components = append(result.components, c)
+			result.names = append(result.names, fmt.Sprintf("component '%d' of %s", idx, id))
+		}
+	}
+
+	in.partial = in.partial || result.partial
+	return
+}
+
+// resolve returns the rendered install file of the include along with its
+// id & the directory against which its relative paths are resolved. The
+// include is not resolved if it is skipped while offline.
+func (in *includer) resolve(inc Include) (data []byte, id, dir string, resolved bool, err error) {
+	set := 0
+	for _, s := range []string{inc.Path, inc.ConfigMap, inc.Catalog} {
+		if len(strings.TrimSpace(s)) != 0 {
+			set++
+		}
+	}
+	if set != 1 {
+		err = fmt.Errorf("either path, configMap or catalog is required")
+		return
+	}
+
+	dir = in.dir
+	switch {
+	case len(inc.Catalog) != 0:
+		id = fmt.Sprintf("catalog '%s'", inc.Catalog)
+		entry, ok := catalog[inc.Catalog]
+		if !ok {
+			err = fmt.Errorf("unknown catalog '%s': expected one of '%s'", inc.Catalog, strings.Join(catalogNames(), "', '"))
+			return
+		}
+		data = []byte(entry)
+
+	case len(inc.Path) != 0:
+		path := inc.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(in.dir, path)
+		}
+		if abs, aerr := filepath.Abs(path); aerr == nil {
+			path = abs
+		}
+		id, dir = fmt.Sprintf("path '%s'", path), filepath.Dir(path)
+		data, err = ioutil.ReadFile(path)
+		if err != nil && in.offline {
+			return nil, id, dir, false, nil
+		}
+		if err != nil {
+			return
+		}
+
+	default:
+		key := inc.Key
+		if len(key) == 0 {
+			key = DefaultIncludeKey
+		}
+		id = fmt.Sprintf("config map '%s' key '%s'", inc.ConfigMap, key)
+		if in.offline {
+			return nil, id, dir, false, nil
+		}
+
+		k := kubectl.New()
+		if len(inc.Namespace) != 0 {
+			k = k.Namespace(inc.Namespace)
+		}
+		var cm kubectl.ConfigMap
+		cm, err = kubectl.GetConfigMap(k, inc.ConfigMap)
+		if err != nil {
+			return
+		}
+		entry, ok := cm.Data[key]
+		if !ok {
+			err = fmt.Errorf("config map '%s' does not have key '%s'", inc.ConfigMap, key)
+			return
+		}
+		data = []byte(entry)
+	}
+
+	for n, s := range in.stack {
+		if s == id {
+			err = fmt.Errorf("include cycle %s -> %s", strings.Join(in.stack[n:], " -> "), id)
+			return
+		}
+	}
+
+	if in.offline {
+		data, err = render(data, lintVars, false)
+	} else {
+		data, err = Render(data, CurrentVars())
+	}
+	if err != nil {
+		err = fmt.Errorf("%s: %s", id, err)
+		return
+	}
+	return data, id, dir, true, nil
+}
+
+// namespaceAliases prefixes the aliases of the components & the dependencies
+// on these aliases with the provided namespace e.g. apiserver becomes
+// operator.apiserver
+func namespaceAliases(components []Component, namespace string) {
+	aliases := map[string]bool{}
+	for _, c := range components {
+		if len(c.Alias) != 0 {
+			aliases[c.Alias] = true
+		}
+	}
+
+	for idx, c := range components {
+		if len(c.Alias) != 0 {
+			components[idx].Alias = namespace + "." + c.Alias
+		}
+
+		var deps []string
+		for _, d := range c.DependsOn {
+			if aliases[d] {
+				d = namespace + "." + d
+			}
+			deps = append(deps, d)
+		}
+		components[idx].DependsOn = deps
+	}
+}
+
+// merge places the included components before the components of the
+// installation. A component of the installation with the alias of an
+// included component overrides the included component; see override. The
+// line numbers & names of the merged components are returned.
+func (i *Installation) merge(included inclusion, lines []int) (merged []int, names []string) {
+	if len(included.components) == 0 {
+		return lines, nil
+	}
+
+	components := append([]Component{}, included.components...)
+	names = append([]string{}, included.names...)
+	merged = make([]int, len(components))
+
+	indexes := map[string]int{}
+	for idx, c := range components {
+		if _, ok := indexes[c.Alias]; len(c.Alias) != 0 && !ok {
+			indexes[c.Alias] = idx
+		}
+	}
+
+	for idx, c := range i.Components {
+		line := 0
+		if idx < len(lines) {
+			line = lines[idx]
+		}
+		name := fmt.Sprintf("component '%d'", idx)
+
+		if at, ok := indexes[c.Alias]; ok && len(c.Alias) != 0 {
+			components[at] = components[at].override(c)
+			merged[at], names[at] = line, name
+			continue
+		}
+		components = append(components, c)
+		merged = append(merged, line)
+		names = append(names, name)
+	}
+
+	i.Components = components
+	return
+}
+
+// override returns the component with the fields that are set against the
+// provided override replacing those of the component
+func (c Component) override(o Component) Component {
+	for _, f := range []struct{ dst, src *string }{
+		{&c.Name, &o.Name},
+		{&c.Namespace, &o.Namespace},
+		{&c.Kind, &o.Kind},
+		{&c.APIVersion, &o.APIVersion},
+		{&c.Labels, &o.Labels},
+		{&c.Container, &o.Container},
+	} {
+		if len(*f.src) != 0 {
+			*f.dst = *f.src
+		}
+	}
+	if o.AllowedEventReasons != nil {
+		c.AllowedEventReasons = o.AllowedEventReasons
+	}
+	if o.DependsOn != nil {
+		c.DependsOn = o.DependsOn
+	}
+	if o.Expect != nil {
+		c.Expect = o.Expect
+	}
+	return c
+}
+
+// catalogNames returns the sorted names of the catalog entries
+func catalogNames() (names []string) {
+	for name := range catalog {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadIncludes(t *testing.T) {
+	tests := map[string]struct {
+		files     map[string]string
+		aliases   []string
+		dependsOn map[string][]string
+		isErr     string
+	}{
+		"include - +ve test case - catalog with alias namespacing": {
+			files: map[string]string{
+				"install.yaml": `
+includes:
+- catalog: openebs/operator-v0.5.3
+  as: operator
+components:
+- kind: sc
+  name: openebs-standard
+  alias: sc
+  dependsOn: [operator.provisioner]
+`,
+			},
+			aliases: []string{
+				"operator.service-account", "operator.cluster-role", "operator.cluster-role-binding",
+				"operator.apiserver", "operator.apiserver-service", "operator.provisioner", "sc",
+			},
+			dependsOn: map[string][]string{
+				"operator.cluster-role-binding": {"operator.service-account", "operator.cluster-role"},
+				"sc": {"operator.provisioner"},
+			},
+		},
+		"include - +ve test case - nested relative paths": {
+			files: map[string]string{
+				"install.yaml": `
+includes:
+- path: common/app.yaml
+components:
+- kind: pod
+  labels: app=minio
+  alias: app
+  dependsOn: [pvc]
+`,
+				"common/app.yaml": `
+includes:
+- path: ../storage.yaml
+components:
+- kind: svc
+  name: minio
+  alias: svc
+`,
+				"storage.yaml": `
+components:
+- kind: pvc
+  name: minio
+  alias: pvc
+`,
+			},
+			aliases:   []string{"pvc", "svc", "app"},
+			dependsOn: map[string][]string{"app": {"pvc"}},
+		},
+		"include - +ve test case - override the included component": {
+			files: map[string]string{
+				"install.yaml": `
+includes:
+- path: storage.yaml
+  as: storage
+components:
+- alias: storage.pvc
+  dependsOn: []
+  expect:
+    phase: Bound
+`,
+				"storage.yaml": `
+components:
+- kind: sc
+  name: openebs-standard
+  alias: sc
+- kind: pvc
+  name: minio
+  alias: pvc
+  dependsOn: [sc]
+`,
+			},
+			aliases:   []string{"storage.sc", "storage.pvc"},
+			dependsOn: map[string][]string{"storage.pvc": {}},
+		},
+		"include - -ve test case - unknown catalog": {
+			files: map[string]string{
+				"install.yaml": `
+includes:
+- catalog: openebs/operator-v0.0.1
+`,
+			},
+			isErr: "line 3: include '0': unknown catalog 'openebs/operator-v0.0.1'",
+		},
+		"include - -ve test case - more than one source": {
+			files: map[string]string{
+				"install.yaml": `
+includes:
+- catalog: openebs/operator-v0.5.3
+  path: storage.yaml
+`,
+			},
+			isErr: "line 3: include '0': either path, configMap or catalog is required",
+		},
+		"include - -ve test case - missing file": {
+			files: map[string]string{
+				"install.yaml": `
+includes:
+- path: storage.yaml
+`,
+			},
+			isErr: "line 3: include '0': open ",
+		},
+		"include - -ve test case - invalid included file": {
+			files: map[string]string{
+				"install.yaml": `
+includes:
+- path: storage.yaml
+`,
+				"storage.yaml": `
+components:
+- kind: pvcs
+  name: minio
+`,
+			},
+			isErr: "storage.yaml': line 3: component '0': unknown kind 'pvcs'",
+		},
+		"include - -ve test case - include cycle": {
+			files: map[string]string{
+				"install.yaml": `
+includes:
+- path: storage.yaml
+`,
+				"storage.yaml": `
+includes:
+- path: install.yaml
+`,
+			},
+			isErr: "include '0': include cycle path '",
+		},
+		"include - -ve test case - override of an unknown alias": {
+			files: map[string]string{
+				"install.yaml": `
+includes:
+- catalog: openebs/operator-v0.5.3
+  as: operator
+components:
+- alias: apiserver
+  namespace: openebs
+`,
+			},
+			isErr: "line 6: component '0': kind is required",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "meta")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+			}
+			defer os.RemoveAll(dir)
+
+			for f, content := range mock.files {
+				path := filepath.Join(dir, f)
+				if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("failed to create dir: expected 'no error': actual '%s'", err)
+				}
+				if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write install file: expected 'no error': actual '%s'", err)
+				}
+			}
+
+			i, err := Load(InstallFile(filepath.Join(dir, "install.yaml")))
+			if len(mock.isErr) != 0 {
+				if err == nil || !strings.Contains(err.Error(), mock.isErr) {
+					t.Fatalf("failed to load: expected '%s': actual '%v'", mock.isErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load: expected 'no error': actual '%s'", err)
+			}
+
+			var aliases []string
+			for _, c := range i.Components {
+				aliases = append(aliases, c.Alias)
+				deps, ok := mock.dependsOn[c.Alias]
+				if ok && len(deps)+len(c.DependsOn) != 0 && !reflect.DeepEqual(deps, c.DependsOn) {
+					t.Fatalf("failed to load '%s': expected dependsOn '%v': actual '%v'", c.Alias, deps, c.DependsOn)
+				}
+			}
+			if !reflect.DeepEqual(aliases, mock.aliases) {
+				t.Fatalf("failed to load: expected aliases '%v': actual '%v'", mock.aliases, aliases)
+			}
+		})
+	}
+}
+
+func TestOverride(t *testing.T) {
+	replicas := 1
+	included := Component{Kind: "pod", Labels: "name=maya-apiserver", Namespace: "default", Alias: "apiserver", DependsOn: []string{"rbac"}}
+	actual := included.override(Component{Namespace: "openebs", Alias: "apiserver", Expect: &Expectation{Replicas: &replicas}})
+
+	expected := Component{Kind: "pod", Labels: "name=maya-apiserver", Namespace: "openebs", Alias: "apiserver", DependsOn: []string{"rbac"}, Expect: &Expectation{Replicas: &replicas}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("failed to override: expected '%+v': actual '%+v'", expected, actual)
+	}
+}
+
+func TestParseIncludesOffline(t *testing.T) {
+	// config maps are not fetched offline; the components that could
+	// override or depend on the config map's components are not flagged
+	i, err := Parse([]byte(`
+includes:
+- configMap: shared-verify
+  as: shared
+components:
+- alias: shared.apiserver
+  namespace: openebs
+- kind: pod
+  labels: app=minio
+  dependsOn: [shared.provisioner]
+`))
+	if err != nil {
+		t.Fatalf("failed to parse: expected 'no error': actual '%s'", err)
+	}
+	if len(i.Components) != 2 {
+		t.Fatalf("failed to parse: expected '2 components': actual '%d'", len(i.Components))
+	}
+}
@@ -19,9 +19,10 @@ package meta
 import (
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
+	"sync"
 
 	"github.com/AmitKumarDas/elitmus/pkg/util"
-	"github.com/ghodss/yaml"
 )
 
 // InstallFile type defines a yaml file path that represents an installation
@@ -38,9 +39,49 @@ type InstallFile string
 // as directed in the .feature file.
 type Installation struct {
 	// Version of this installation, operator etc
-	Version string `json:"version"`
+	Version string `json:"version" yaml:"version"`
+	// Includes are the other install files whose components are part of this
+	// installation e.g. the operator shared by many installations. The
+	// included components are placed before the components of this
+	// installation.
+	Includes []Include `json:"includes" yaml:"includes"`
 	// Components of this installation
-	Components []Component `json:"components"`
+	Components []Component `json:"components" yaml:"components"`
+}
+
+// Include refers to an install file whose components are included in an
+// installation. Exactly one of path, configMap or catalog is set.
+//
+// Following are some valid sample includes:
+//
+//    includes:
+//    - catalog: openebs/operator-v0.5.3
+//      as: operator
+//    - path: ../common/minio-verify.yaml
+//    - configMap: shared-verify
+//      namespace: litmus
+//      key: operator
+//
+// NOTE:
+//  A component with the alias of an included component overrides the fields
+// it sets against the included component e.g. its namespace or expectation.
+type Include struct {
+	// Path of the install file; a relative path is resolved against the
+	// directory of the including install file
+	Path string `json:"path" yaml:"path"`
+	// ConfigMap is the name of the config map that holds the install file
+	ConfigMap string `json:"configMap" yaml:"configMap"`
+	// Namespace of the config map; defaults to the namespace of kubectl
+	Namespace string `json:"namespace" yaml:"namespace"`
+	// Key of the config map's data that holds the install file; defaults to
+	// config
+	Key string `json:"key" yaml:"key"`
+	// Catalog is the name of an install file embedded in litmus e.g.
+	// openebs/operator-v0.5.3
+	Catalog string `json:"catalog" yaml:"catalog"`
+	// As namespaces the aliases of the included components e.g. the alias
+	// apiserver of a component included as operator becomes operator.apiserver
+	As string `json:"as" yaml:"as"`
 }
 
 // Component is the information about a particular component
@@ -48,45 +89,157 @@ type Installation struct {
 // a component in the overall installation
 type Component struct {
 	// Name of the component
-	Name string `json:"name"`
+	Name string `json:"name" yaml:"name"`
 	// Namespace of the component
-	Namespace string `json:"namespace"`
+	Namespace string `json:"namespace" yaml:"namespace"`
 	// Kind name of the component
 	// e.g. pods, deployments, services, etc
-	Kind string `json:"kind"`
+	Kind string `json:"kind" yaml:"kind"`
 	// APIVersion of the component
-	APIVersion string `json:"apiVersion"`
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
 	// Labels of the component that is used for filtering the components
 	//
 	// Following are some valid sample values for labels:
 	//
 	//    labels: name=app
 	//    labels: name=app,env=prod
-	Labels string `json:"labels"`
+	Labels string `json:"labels" yaml:"labels"`
 	// Alias provides a user understood description used for filtering the
 	// components. This is a single word setting.
 	//
 	// NOTE:
-	//  Ensure unique alias values in an installation
+	//  Alias values need to be unique in an installation; this is validated
+	// when the installation is loaded
 	//
 	// DETAILS:
 	//  This is the text which is typically understood by the end user. This text
 	// which will be set in the installation file against a particular component.
 	// Logic will filter the component based on this alias & run
 	// various checks &/or actions
-	Alias string `json:"alias"`
+	Alias string `json:"alias" yaml:"alias"`
+	// Container is the name of the container that is accessed when a command
+	// is run in or a file is copied to or from a pod component. This is
+	// optional; the pod's default container is accessed if not set.
+	Container string `json:"container" yaml:"container"`
+	// AllowedEventReasons are the reasons of the warning events that are
+	// expected for this component & hence are ignored while verifying the
+	// component has no warning events e.g. FailedScheduling
+	AllowedEventReasons []string `json:"allowedEventReasons" yaml:"allowedEventReasons"`
+	// DependsOn are the aliases of the components this component depends on
+	// e.g. an application pod that depends on its pvc. A component is verified
+	// after the components it depends on & is reported as blocked if any of
+	// them fail their verification.
+	//
+	// NOTE:
+	//  Dependencies on unknown aliases & dependency cycles are flagged when
+	// the installation is loaded
+	DependsOn []string `json:"dependsOn" yaml:"dependsOn"`
+	// Expect is the expected state of the component. This is optional; a
+	// component is deployed if it exists when no expectations are set.
+	//
+	// Following is a sample expectation of a deployment:
+	//
+	//    expect:
+	//      replicas: 3
+	//      minReady: 2
+	//      images:
+	//      - openebs/jiva
+	Expect *Expectation `json:"expect" yaml:"expect"`
 }
 
-// unmarshal takes the raw yaml data and unmarshals it into Installation
-func unmarshal(data []byte) (installation *Installation, err error) {
-	installation = &Installation{}
+// Expectation is the expected state of a component. Only the fields that are
+// set are verified.
+type Expectation struct {
+	// Replicas is the expected replica count of a deployment, statefulset or
+	// replicaset. It is the expected number of pods of a pod component.
+	Replicas *int `json:"replicas" yaml:"replicas"`
+	// MinReady is the minimum number of ready replicas of a deployment,
+	// statefulset or replicaset. It is the minimum number of ready pods of a
+	// pod component.
+	MinReady *int `json:"minReady" yaml:"minReady"`
+	// Phase is the expected phase of the pods of a pod component e.g. Running
+	// or of a pvc e.g. Bound
+	Phase string `json:"phase" yaml:"phase"`
+	// Conditions are the status conditions the component is expected to
+	// report e.g. the Available condition of a deployment
+	Conditions []ExpectedCondition `json:"conditions" yaml:"conditions"`
+	// Images are the container images expected to be run by a pod component.
+	// An image without a tag matches any tag of the image.
+	Images []string `json:"images" yaml:"images"`
+	// Capacity is the minimum storage capacity of a pvc e.g. 5G
+	Capacity string `json:"capacity" yaml:"capacity"`
+	// StorageClass is the expected storage class of a pvc
+	StorageClass string `json:"storageClass" yaml:"storageClass"`
+}
 
-	err = yaml.Unmarshal(data, installation)
-	return
+// ExpectedCondition is a status condition that is expected to be reported by
+// a component
+type ExpectedCondition struct {
+	// Type of the condition e.g. Ready, Available
+	Type string `json:"type" yaml:"type"`
+	// Status of the condition i.e. True, False or Unknown. This defaults to
+	// True.
+	Status string `json:"status" yaml:"status"`
 }
 
-// load converts a verify file into an instance of *Installation
+// IsSet flags if the expectation has any field set
+func (e *Expectation) IsSet() bool {
+	return e != nil && (e.Replicas != nil || e.MinReady != nil || len(e.Phase) != 0 ||
+		len(e.Conditions) != 0 || len(e.Images) != 0 || len(e.Capacity) != 0 || len(e.StorageClass) != 0)
+}
+
+// loaded tracks the installations that were loaded successfully keyed by
+// their install file
+var loaded = struct {
+	sync.Mutex
+	installations map[InstallFile]*Installation
+}{installations: map[InstallFile]*Installation{}}
+
+// namespace is the default namespace of the components that are loaded
+var namespace = struct {
+	sync.RWMutex
+	// base is the namespace that is replaced by the default
+	base string
+	// name of the default namespace
+	name string
+}{}
+
+// SetDefaultNamespace sets the namespace of the components loaded hereafter
+// to the provided namespace if their namespace is not set or is the base
+// namespace. An empty namespace stops this defaulting.
+func SetDefaultNamespace(name, base string) {
+	namespace.Lock()
+	defer namespace.Unlock()
+
+	namespace.name, namespace.base = name, base
+}
+
+// defaultNamespace sets the default namespace against the components of the
+// installation
+func (i *Installation) defaultNamespace() {
+	namespace.RLock()
+	defer namespace.RUnlock()
+
+	if len(namespace.name) == 0 {
+		return
+	}
+	for idx, c := range i.Components {
+		if len(c.Namespace) == 0 || c.Namespace == namespace.base {
+			i.Components[idx].Namespace = namespace.name
+		}
+	}
+}
+
+// Load converts a verify file into an instance of *Installation. The file is
+// rendered with the variables of the run; see Render. It is then strictly
+// decoded & validated along with the install files it includes; see Parse.
 func Load(file InstallFile) (installation *Installation, err error) {
+	return load(file, CurrentVars())
+}
+
+// load converts a verify file into an instance of *Installation after it is
+// rendered with the provided variables
+func load(file InstallFile, vars Vars) (installation *Installation, err error) {
 	if len(file) == 0 {
 		err = fmt.Errorf("failed to load: verify file is not provided")
 		return
@@ -97,7 +250,41 @@ func Load(file InstallFile) (installation *Installation, err error) {
 		return
 	}
 
-	return unmarshal(d)
+	d, err = Render(d, vars)
+	if err != nil {
+		err = fmt.Errorf("failed to load '%s': %s", file, err)
+		return
+	}
+
+	path, err := filepath.Abs(string(file))
+	if err != nil {
+		return
+	}
+	in := &includer{dir: filepath.Dir(path), stack: []string{fmt.Sprintf("path '%s'", path)}}
+	installation, err = in.parse(d)
+	if err != nil {
+		err = fmt.Errorf("failed to load '%s': %s", file, err)
+		return
+	}
+	installation.defaultNamespace()
+
+	loaded.Lock()
+	loaded.installations[file] = installation
+	loaded.Unlock()
+	return
+}
+
+// Loaded returns the installations loaded so far keyed by their install
+// file. The latest load of an install file is returned.
+func Loaded() map[InstallFile]*Installation {
+	loaded.Lock()
+	defer loaded.Unlock()
+
+	installations := map[InstallFile]*Installation{}
+	for f, i := range loaded.installations {
+		installations[f] = i
+	}
+	return installations
 }
 
 // GetMatchingPodComponent returns the pod that matches with alias
@@ -0,0 +1,159 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/ghodss/yaml"
+)
+
+// LintError is a problem found in an install file that is embedded in a
+// config map of a manifest
+type LintError struct {
+	// ConfigMap that embeds the install file
+	ConfigMap string
+	// Key of the config map's data that holds the install file
+	Key string
+	// ValidationError is the problem whose line is that of the manifest
+	ValidationError
+}
+
+// Error returns the line, config map & description of this problem
+func (e LintError) Error() string {
+	return fmt.Sprintf("line %d: config map '%s' key '%s': %s", e.Line, e.ConfigMap, e.Key, e.Message)
+}
+
+// lintVars are the variables with which the install files are rendered
+// while they are linted
+var lintVars = Vars{Namespace: kubectl.DefaultLitmusNamespace, RunID: "lint", Test: "lint"}
+
+// installFileContent matches the content of an install file
+var installFileContent = regexp.MustCompile(`(?m)^(components|includes):`)
+
+// LintManifest validates the install files embedded in the config maps of
+// the manifest. The data of a config map is considered to be an install file
+// if it has the components field. Templated install files are rendered with
+// placeholder variables before they are validated. The lines of the problems
+// are those of the manifest.
+func LintManifest(manifest []byte) (errs []LintError, err error) {
+	lines := strings.Split(string(manifest), "\n")
+
+	start := 0
+	for idx := 0; idx <= len(lines); idx++ {
+		if idx < len(lines) && strings.TrimRight(lines[idx], " ") != "---" {
+			continue
+		}
+
+		doc := lines[start:idx]
+		derrs, derr := lintConfigMap(doc, start)
+		if derr != nil {
+			err = fmt.Errorf("failed to lint document at line '%d': %s", start+1, derr)
+			return
+		}
+		errs = append(errs, derrs...)
+		start = idx + 1
+	}
+	return
+}
+
+// lintConfigMap validates the install files embedded in the config map of
+// the document. The offset is the number of manifest lines prior to the
+// document.
+func lintConfigMap(doc []string, offset int) (errs []LintError, err error) {
+	var cm struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Data map[string]string `json:"data"`
+	}
+	err = yaml.Unmarshal([]byte(strings.Join(doc, "\n")), &cm)
+	if err != nil || cm.Kind != "ConfigMap" {
+		return
+	}
+
+	var keys []string
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !installFileContent.MatchString(cm.Data[k]) {
+			continue
+		}
+
+		// the values of the run are not known & hence the required values are
+		// replaced by placeholders
+		data, perr := render([]byte(cm.Data[k]), lintVars, false)
+		if perr == nil {
+			_, perr = Parse(data)
+		}
+		if perr == nil {
+			continue
+		}
+
+		line := 0
+		if block := blockLine(doc, k); block != -1 {
+			line = offset + block + 1
+		}
+		errs = append(errs, lintErrors(perr, cm.Metadata.Name, k, line)...)
+	}
+	return
+}
+
+// lintErrors converts the error of the install file held by the key of the
+// config map into lint errors. The line is that of the key in the manifest &
+// is 0 if not known. The lines of the validation errors are relative to this
+// line; any other error is reported against this line.
+func lintErrors(err error, configMap, key string, line int) (errs []LintError) {
+	verrs, ok := err.(ValidationErrors)
+	if !ok {
+		verrs = ValidationErrors{{Message: err.Error()}}
+	}
+
+	for _, v := range verrs {
+		switch {
+		case !ok:
+			v.Line = line
+		case v.Line != 0 && line != 0:
+			v.Line += line
+		default:
+			v.Line = 0
+		}
+		errs = append(errs, LintError{ConfigMap: configMap, Key: key, ValidationError: v})
+	}
+	return
+}
+
+// blockLine returns the index of the document line that begins the block
+// scalar of the key e.g. 'config: |-'. This is -1 if the key does not begin
+// a block scalar.
+func blockLine(doc []string, key string) int {
+	block := regexp.MustCompile(`^\s+` + regexp.QuoteMeta(key) + `:\s*[|>][-+]?\s*$`)
+	for idx, line := range doc {
+		if block.MatchString(line) {
+			return idx
+		}
+	}
+	return -1
+}
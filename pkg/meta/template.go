@@ -0,0 +1,216 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+	"text/template"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+)
+
+// Vars are the variables of the run that are available to an install file
+// while it is rendered e.g.
+//
+//    namespace: {{ .Namespace }}
+//    name: {{ .Values.storageClass | default "openebs-standard" }}
+type Vars struct {
+	// Namespace of the run i.e. the ephemeral namespace of the feature if
+	// set, else the litmus namespace
+	Namespace string
+	// RunID is the id of this run of litmus
+	RunID string
+	// Test is the name of the test being run
+	Test string
+	// Values are the values set earlier in the scenario e.g. a fetched
+	// service ip. A value that is not set renders as an empty string.
+	Values map[string]string
+}
+
+// values are the values set in the current scenario
+var values = struct {
+	sync.RWMutex
+	items map[string]string
+}{items: map[string]string{}}
+
+// SetValue sets a value that is available to the install files loaded
+// hereafter as {{ .Values.<key> }}. The templates are rendered again with
+// this value once they are used; see Template.
+func SetValue(key, value string) {
+	values.Lock()
+	defer values.Unlock()
+
+	values.items[key] = value
+}
+
+// ResetValues removes the values set so far e.g. at the start of a scenario
+func ResetValues() {
+	values.Lock()
+	defer values.Unlock()
+
+	values.items = map[string]string{}
+}
+
+// CurrentVars returns the variables of the run
+func CurrentVars() Vars {
+	values.RLock()
+	defer values.RUnlock()
+
+	vals := map[string]string{}
+	for k, v := range values.items {
+		vals[k] = v
+	}
+
+	return Vars{
+		Namespace: kubectl.ResolveNamespace(kubectl.ResolveConfig(kubectl.Config{}).Namespace),
+		RunID:     kubectl.RunID(),
+		Test:      kubectl.TestName(),
+		Values:    vals,
+	}
+}
+
+// Template is an install file that is rendered once it is used rather than
+// once it is loaded. This lets the install file refer to the values set
+// later in the scenario e.g. a service ip fetched after the feature started.
+type Template struct {
+	// file is the install file
+	file InstallFile
+	// mutex guards the rendered installation
+	mutex sync.Mutex
+	// rendered flags if the install file was rendered successfully
+	rendered bool
+	// vars are the variables with which the install file was rendered
+	vars Vars
+	// installation is the rendered install file
+	installation *Installation
+}
+
+// NewTemplate returns the template of the install file. The install file is
+// verified to be readable & to render with placeholders for the required
+// values that are not yet set. It is decoded & validated once it is used;
+// see Installation.
+func NewTemplate(file InstallFile) (t *Template, err error) {
+	if len(file) == 0 {
+		err = fmt.Errorf("failed to load: verify file is not provided")
+		return
+	}
+
+	d, err := ioutil.ReadFile(string(file))
+	if err != nil {
+		return
+	}
+
+	_, err = render(d, CurrentVars(), false)
+	if err != nil {
+		err = fmt.Errorf("failed to load '%s': %s", file, err)
+		return
+	}
+
+	t = &Template{file: file}
+	return
+}
+
+// Installation returns the install file rendered with the current variables
+// of the run; see Load. The install file is rendered again only if these
+// variables changed since it was last rendered e.g. a value was set or reset.
+func (t *Template) Installation() (installation *Installation, err error) {
+	vars := CurrentVars()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.rendered && reflect.DeepEqual(t.vars, vars) {
+		return t.installation, nil
+	}
+
+	installation, err = load(t.file, vars)
+	if err != nil {
+		return
+	}
+	t.installation, t.vars, t.rendered = installation, vars, true
+	return
+}
+
+// templateLine extracts the line number from the errors reported by the
+// template engine
+var templateLine = regexp.MustCompile(`^template: [^:]*:(\d+):(?:\d+:)?\s*(?:executing "[^"]*" at <.*?>: )?(.*)$`)
+
+// Render renders the install file as a text/template with the provided
+// variables. Following functions are available in addition to the built in
+// ones:
+//
+//    env "NAME"                    value of the environment variable
+//    default "value" .Values.x     .Values.x if not empty, else "value"
+//    required "message" .Values.x  .Values.x if not empty, else fails with message
+//
+// Problems are reported as ValidationErrors.
+func Render(data []byte, vars Vars) ([]byte, error) {
+	return render(data, vars, true)
+}
+
+// render renders the install file. The required values that are not set are
+// replaced by a placeholder if strict is not set.
+func render(data []byte, vars Vars, strict bool) (rendered []byte, err error) {
+	funcs := template.FuncMap{
+		"env": os.Getenv,
+		"default": func(def, value string) string {
+			if len(value) == 0 {
+				return def
+			}
+			return value
+		},
+		"required": func(message, value string) (string, error) {
+			if len(value) != 0 {
+				return value, nil
+			}
+			if !strict {
+				return "required", nil
+			}
+			return "", fmt.Errorf("%s", message)
+		},
+	}
+
+	t, err := template.New("install").Funcs(funcs).Option("missingkey=zero").Parse(string(data))
+	if err != nil {
+		return nil, templateErrors(err)
+	}
+
+	var out bytes.Buffer
+	if err = t.Execute(&out, vars); err != nil {
+		return nil, templateErrors(err)
+	}
+	return out.Bytes(), nil
+}
+
+// templateErrors converts the error of the template engine into validation
+// errors
+func templateErrors(err error) ValidationErrors {
+	m := templateLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return ValidationErrors{{Message: err.Error()}}
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	return ValidationErrors{{Line: line, Message: m[2]}}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	os.Setenv("LITMUS_IO_TEST_RENDER", "openebs")
+	defer os.Unsetenv("LITMUS_IO_TEST_RENDER")
+
+	vars := Vars{
+		Namespace: "litmus-1a2b3c4d",
+		RunID:     "run-1",
+		Test:      "ha-on-minio",
+		Values:    map[string]string{"storageClass": "openebs-jiva"},
+	}
+
+	tests := map[string]struct {
+		install  string
+		expected string
+		isErr    string
+	}{
+		"render - +ve test case - no template": {
+			install:  "components:\n- kind: pod\n  name: minio\n",
+			expected: "components:\n- kind: pod\n  name: minio\n",
+		},
+		"render - +ve test case - run variables": {
+			install:  "namespace: {{ .Namespace }}\nlabels: run={{ .RunID }},test={{ .Test }}",
+			expected: "namespace: litmus-1a2b3c4d\nlabels: run=run-1,test=ha-on-minio",
+		},
+		"render - +ve test case - values & env": {
+			install:  `{{ .Values.storageClass }} {{ .Values.app | default "minio" }} {{ env "LITMUS_IO_TEST_RENDER" }}`,
+			expected: "openebs-jiva minio openebs",
+		},
+		"render - +ve test case - required value is set": {
+			install:  `{{ required "storage class is required" .Values.storageClass }}`,
+			expected: "openebs-jiva",
+		},
+		"render - -ve test case - required value is not set": {
+			install: "components:\n- kind: pod\n  name: {{ required \"app is required\" .Values.app }}",
+			isErr:   "line 3: error calling required: app is required",
+		},
+		"render - -ve test case - unknown function": {
+			install: "components:\n- kind: {{ lower .Values.kind }}",
+			isErr:   "line 2: function \"lower\" not defined",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := Render([]byte(mock.install), vars)
+			if len(mock.isErr) != 0 {
+				errs, ok := err.(ValidationErrors)
+				if !ok || len(errs) != 1 || errs[0].Error() != mock.isErr {
+					t.Fatalf("failed to render: expected '%s': actual '%v'", mock.isErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to render: expected 'no error': actual '%s'", err)
+			}
+			if string(actual) != mock.expected {
+				t.Fatalf("failed to render: expected '%s': actual '%s'", mock.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValues(t *testing.T) {
+	defer ResetValues()
+
+	SetValue("ip", "10.0.0.1")
+	if actual := CurrentVars().Values["ip"]; actual != "10.0.0.1" {
+		t.Fatalf("failed to set value: expected '10.0.0.1': actual '%s'", actual)
+	}
+
+	ResetValues()
+	if actual := CurrentVars().Values; len(actual) != 0 {
+		t.Fatalf("failed to reset values: expected 'no values': actual '%v'", actual)
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meta")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	defer ResetValues()
+
+	file := filepath.Join(dir, "install.yaml")
+	err = ioutil.WriteFile(file, []byte(`
+components:
+- kind: pvc
+  name: {{ .Values.pvc }}
+  namespace: {{ .Namespace }}
+`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write install file: expected 'no error': actual '%s'", err)
+	}
+
+	if _, err = Load(InstallFile(file)); err == nil {
+		t.Fatalf("failed to load: expected 'error for empty name': actual 'no error'")
+	}
+
+	SetValue("pvc", "minio-data")
+	i, err := Load(InstallFile(file))
+	if err != nil {
+		t.Fatalf("failed to load: expected 'no error': actual '%s'", err)
+	}
+
+	c := i.Components[0]
+	if c.Name != "minio-data" || c.Namespace != CurrentVars().Namespace || len(c.Namespace) == 0 {
+		t.Fatalf("failed to load: expected 'rendered name & namespace': actual '%s' '%s'", c.Name, c.Namespace)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "meta")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	defer ResetValues()
+
+	files := map[string]string{
+		"install.yaml": "components:\n- kind: service\n  name: {{ required \"app is required\" .Values.app }}\n",
+		"invalid.yaml": "components:\n- kind: {{ lower .Values.kind }}\n",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write install file: expected 'no error': actual '%s'", err)
+		}
+	}
+
+	tests := map[string]struct {
+		file  string
+		isErr bool
+	}{
+		"new template - +ve test case - values are not yet set": {
+			file: "install.yaml",
+		},
+		"new template - -ve test case - invalid template": {
+			file:  "invalid.yaml",
+			isErr: true,
+		},
+		"new template - -ve test case - non existent install file": {
+			file:  "non-existent.yaml",
+			isErr: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewTemplate(InstallFile(filepath.Join(dir, mock.file)))
+			if err != nil && !mock.isErr {
+				t.Fatalf("failed to create template: expected 'no error': actual '%s'", err)
+			}
+			if err == nil && mock.isErr {
+				t.Fatalf("failed to create template: expected 'error': actual 'no error'")
+			}
+		})
+	}
+
+	tmpl, err := NewTemplate(InstallFile(filepath.Join(dir, "install.yaml")))
+	if err != nil {
+		t.Fatalf("failed to create template: expected 'no error': actual '%s'", err)
+	}
+
+	if _, err = tmpl.Installation(); err == nil {
+		t.Fatalf("failed to render template: expected 'error for required app': actual 'no error'")
+	}
+
+	SetValue("app", "minio")
+	first, err := tmpl.Installation()
+	if err != nil {
+		t.Fatalf("failed to render template: expected 'no error': actual '%s'", err)
+	}
+	if first.Components[0].Name != "minio" {
+		t.Fatalf("failed to render template: expected 'minio': actual '%s'", first.Components[0].Name)
+	}
+
+	if again, _ := tmpl.Installation(); again != first {
+		t.Fatalf("failed to render template: expected 'same installation for same values': actual 'rendered again'")
+	}
+
+	SetValue("app", "percona")
+	second, err := tmpl.Installation()
+	if err != nil {
+		t.Fatalf("failed to render template: expected 'no error': actual '%s'", err)
+	}
+	if second.Components[0].Name != "percona" {
+		t.Fatalf("failed to render template: expected 'percona': actual '%s'", second.Components[0].Name)
+	}
+}
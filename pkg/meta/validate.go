@@ -0,0 +1,406 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ValidationError is a problem found in an install file
+type ValidationError struct {
+	// Line of the install file where the problem was found. This is 0 if the
+	// line is not known.
+	Line int
+	// Message describes the problem
+	Message string
+}
+
+// Error returns the line & description of this problem
+func (e ValidationError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ValidationErrors are the problems found in an install file
+type ValidationErrors []ValidationError
+
+// Error returns every problem on a line of its own
+func (e ValidationErrors) Error() string {
+	var msgs []string
+	for _, v := range e {
+		msgs = append(msgs, v.Error())
+	}
+	return fmt.Sprintf("invalid install file:\n  %s", strings.Join(msgs, "\n  "))
+}
+
+// knownKinds are the kinds of components that are understood along with
+// their plural & short names
+var knownKinds = []string{
+	"po", "pod", "pods",
+	"deploy", "deployment", "deployments",
+	"sts", "statefulset", "statefulsets",
+	"ds", "daemonset", "daemonsets",
+	"rs", "replicaset", "replicasets",
+	"job", "jobs",
+	"cj", "cronjob", "cronjobs",
+	"svc", "service", "services",
+	"ep", "endpoints",
+	"ing", "ingress", "ingresses",
+	"pvc", "persistentvolumeclaim", "persistentvolumeclaims",
+	"pv", "persistentvolume", "persistentvolumes",
+	"sc", "storageclass", "storageclasses",
+	"cm", "configmap", "configmaps",
+	"secret", "secrets",
+	"sa", "serviceaccount", "serviceaccounts",
+	"ns", "namespace", "namespaces",
+	"no", "node", "nodes",
+	"clusterrole", "clusterroles",
+	"clusterrolebinding", "clusterrolebindings",
+	"role", "roles",
+	"rolebinding", "rolebindings",
+	"crd", "crds", "customresourcedefinition", "customresourcedefinitions",
+	"pdb", "poddisruptionbudget", "poddisruptionbudgets",
+}
+
+// yamlLine extracts the line number from the errors reported by the yaml
+// decoder
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// unknownField matches the error reported by the yaml decoder for a field
+// that is not known
+var unknownField = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+
+// Parse strictly decodes the install file & validates it. Unknown fields,
+// unknown kinds, invalid label selectors, components without a name or
+// labels, duplicate aliases, expectations that do not apply to the kind of
+// the component, dependencies on unknown aliases & dependency cycles are
+// reported as ValidationErrors.
+//
+// NOTE:
+//  The included install files are parsed offline i.e. config maps are not
+// fetched & local files that can not be read are skipped; see Include
+func Parse(data []byte) (installation *Installation, err error) {
+	return (&includer{dir: ".", offline: true}).parse(data)
+}
+
+// parse strictly decodes the install file, merges the install files it
+// includes & validates the result
+func (in *includer) parse(data []byte) (installation *Installation, err error) {
+	var errs ValidationErrors
+	installation = &Installation{}
+
+	err = yaml.UnmarshalStrict(data, installation)
+	if err != nil {
+		errs = decodeErrors(err)
+		// the components are validated as well if the file can be decoded
+		// leniently so that every problem is reported at once
+		installation = &Installation{}
+		if yaml.Unmarshal(data, installation) != nil {
+			return nil, errs
+		}
+	}
+
+	included, ierrs := in.includeAll(installation.Includes, itemLines(data, "includes"))
+	errs = append(errs, ierrs...)
+
+	lines, names := installation.merge(included, componentLines(data))
+	errs = append(errs, installation.validate(lines, names, included.partial)...)
+	if len(errs) != 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+		return nil, errs
+	}
+	return installation, nil
+}
+
+// decodeErrors converts the errors of the yaml decoder into validation
+// errors
+func decodeErrors(err error) (errs ValidationErrors) {
+	msgs := []string{err.Error()}
+	if terr, ok := err.(*yaml.TypeError); ok {
+		msgs = terr.Errors
+	}
+
+	for _, msg := range msgs {
+		m := yamlLine.FindStringSubmatch(msg)
+		if m == nil {
+			errs = append(errs, ValidationError{Message: msg})
+			continue
+		}
+
+		line, _ := strconv.Atoi(m[1])
+		msg = m[2]
+		if f := unknownField.FindStringSubmatch(msg); f != nil {
+			msg = fmt.Sprintf("unknown field '%s'", f[1])
+		}
+		errs = append(errs, ValidationError{Line: line, Message: msg})
+	}
+	return
+}
+
+// validate validates the components of the installation. The lines are the
+// line numbers of the components if known & the names are the descriptions
+// of the components e.g. component '0' if known. The checks that may fail
+// due to the components of the includes that were not resolved are skipped
+// if partial is set.
+func (i *Installation) validate(lines []int, names []string, partial bool) (errs ValidationErrors) {
+	line := func(idx int) int {
+		if idx < len(lines) {
+			return lines[idx]
+		}
+		return 0
+	}
+	name := func(idx int) string {
+		if idx < len(names) && len(names[idx]) != 0 {
+			return names[idx]
+		}
+		return fmt.Sprintf("component '%d'", idx)
+	}
+
+	indexes := i.aliasIndexes()
+	_, cycles := i.dependencyOrder()
+	cycleAt := map[int][]int{}
+	for _, cycle := range cycles {
+		cycleAt[cycle[0]] = cycle
+	}
+
+	aliases := map[string]int{}
+	for idx, c := range i.Components {
+		invalid := func(format string, args ...interface{}) {
+			msg := fmt.Sprintf("%s: %s", name(idx), fmt.Sprintf(format, args...))
+			errs = append(errs, ValidationError{Line: line(idx), Message: msg})
+		}
+
+		// a component with an alias & without a kind may override a component
+		// of an include that was not resolved
+		override := partial && len(c.Kind) == 0 && len(c.Alias) != 0
+		if err := validateKind(c.Kind); err != nil && !override {
+			invalid("%s", err)
+		}
+		if len(strings.TrimSpace(c.Name)) == 0 && len(strings.TrimSpace(c.Labels)) == 0 && !override {
+			invalid("either name or labels is required")
+		}
+		if err := ValidateSelector(c.Labels); err != nil {
+			invalid("%s", err)
+		}
+		for _, msg := range c.Expect.validate(c.Kind) {
+			invalid("%s", msg)
+		}
+		for _, dep := range c.DependsOn {
+			if _, ok := indexes[dep]; !ok && !partial {
+				invalid("dependsOn '%s' is not the alias of any component", dep)
+			}
+		}
+		if cycle, ok := cycleAt[idx]; ok {
+			invalid("dependency cycle %s", formatCycle(i.Components, cycle))
+		}
+
+		if len(c.Alias) == 0 {
+			continue
+		}
+		if prior, ok := aliases[c.Alias]; ok {
+			invalid("alias '%s' is already used by %s", c.Alias, name(prior))
+			continue
+		}
+		aliases[c.Alias] = idx
+	}
+	return
+}
+
+// validateKind flags an error if the kind is not known. Kinds qualified by
+// their api group e.g. storagepoolclaims.openebs.io are not checked.
+func validateKind(kind string) error {
+	if len(kind) == 0 {
+		return fmt.Errorf("kind is required")
+	}
+	if strings.Contains(kind, ".") {
+		return nil
+	}
+
+	lower := strings.ToLower(kind)
+	for _, k := range knownKinds {
+		if k == kind {
+			return nil
+		}
+		if k == lower {
+			return fmt.Errorf("kind '%s' should be lower case i.e. '%s'", kind, lower)
+		}
+	}
+
+	suggestions := []string{}
+	for _, k := range knownKinds {
+		if distance(lower, k) <= 2 {
+			suggestions = append(suggestions, k)
+		}
+	}
+	if len(suggestions) != 0 {
+		sort.Strings(suggestions)
+		return fmt.Errorf("unknown kind '%s': did you mean '%s'", kind, strings.Join(suggestions, "' or '"))
+	}
+	return fmt.Errorf("unknown kind '%s': custom resources need to be qualified by their api group e.g. storagepoolclaims.openebs.io", kind)
+}
+
+// distance returns the number of edits needed to change a into b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// labelKey matches a label key i.e. an optional dns prefix & a name
+var labelKey = regexp.MustCompile(`^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+
+// labelValue matches a label value
+var labelValue = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?)?$`)
+
+// setRequirement matches a set based requirement e.g. env in (prod, qa)
+var setRequirement = regexp.MustCompile(`^(\S+)\s+(in|notin)\s+\((.*)\)$`)
+
+// ValidateSelector flags an error if the label selector is not valid.
+// Equality, inequality, set based & existence requirements are supported.
+func ValidateSelector(selector string) error {
+	selector = strings.TrimSpace(selector)
+	if len(selector) == 0 {
+		return nil
+	}
+
+	for _, part := range splitSelector(selector) {
+		part = strings.TrimSpace(part)
+
+		var keys, values []string
+		if m := setRequirement.FindStringSubmatch(part); m != nil {
+			keys = []string{m[1]}
+			for _, v := range strings.Split(m[3], ",") {
+				values = append(values, strings.TrimSpace(v))
+			}
+		} else {
+			key, value, found := part, "", false
+			for _, op := range []string{"!=", "==", "="} {
+				if kv := strings.SplitN(part, op, 2); len(kv) == 2 {
+					key, value, found = kv[0], kv[1], true
+					break
+				}
+			}
+			if !found {
+				key = strings.TrimPrefix(part, "!")
+			}
+			keys = []string{strings.TrimSpace(key)}
+			if found {
+				values = []string{strings.TrimSpace(value)}
+			}
+		}
+
+		for _, k := range keys {
+			if !labelKey.MatchString(k) {
+				return fmt.Errorf("invalid label selector '%s': invalid key '%s'", selector, k)
+			}
+		}
+		for _, v := range values {
+			if !labelValue.MatchString(v) {
+				return fmt.Errorf("invalid label selector '%s': invalid value '%s'", selector, v)
+			}
+		}
+	}
+	return nil
+}
+
+// splitSelector splits the selector at the commas that are not within
+// parentheses
+func splitSelector(s string) (parts []string) {
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// componentLines returns the line numbers where the components of the
+// install file begin
+func componentLines(data []byte) []int {
+	return itemLines(data, "components")
+}
+
+// itemLines returns the line numbers where the items of the top level list
+// with the provided key begin
+//
+// NOTE:
+//  This scans the block style yaml of the list; no lines are returned for
+// flow style yaml
+func itemLines(data []byte, key string) (lines []int) {
+	inList, itemIndent := false, -1
+	for n, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) == 0 || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if indent == 0 && !strings.HasPrefix(trimmed, "-") {
+			inList = strings.HasPrefix(trimmed, key+":")
+			itemIndent = -1
+			continue
+		}
+		if !inList || !strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+		if itemIndent == -1 {
+			itemIndent = indent
+		}
+		if indent == itemIndent {
+			lines = append(lines, n+1)
+		}
+	}
+	return
+}
@@ -0,0 +1,442 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		install  string
+		expected []string
+	}{
+		"parse - +ve test case - valid install file": {
+			install: `
+version: 0.5.3
+components:
+- kind: pod
+  labels: app=minio,tier in (web, db)
+  alias: app-pod
+- kind: storagepoolclaims.openebs.io
+  name: cstor-pool
+`,
+		},
+		"parse - -ve test case - unknown field": {
+			install: `
+components:
+- kind: pod
+  lables: app=minio
+`,
+			expected: []string{
+				"line 3: component '0': either name or labels is required",
+				"line 4: unknown field 'lables'",
+			},
+		},
+		"parse - -ve test case - unknown & upper case kinds": {
+			install: `
+components:
+  - kind: deploymnet
+    name: minio
+  - kind: Pod
+    name: minio-0
+  - kind: storagepoolclaim
+    name: cstor-pool
+`,
+			expected: []string{
+				"line 3: component '0': unknown kind 'deploymnet': did you mean 'deployment'",
+				"line 5: component '1': kind 'Pod' should be lower case i.e. 'pod'",
+				"line 7: component '2': unknown kind 'storagepoolclaim': custom resources need to be qualified by their api group e.g. storagepoolclaims.openebs.io",
+			},
+		},
+		"parse - -ve test case - invalid selector & duplicate alias": {
+			install: `
+components:
+- kind: pod
+  labels: app=ha minio
+  alias: app
+- kind: service
+  name: minio
+  alias: app
+`,
+			expected: []string{
+				"line 3: component '0': invalid label selector 'app=ha minio': invalid value 'ha minio'",
+				"line 6: component '1': alias 'app' is already used by component '0'",
+			},
+		},
+		"parse - +ve test case - expectations": {
+			install: `
+components:
+- kind: deploy
+  name: minio
+  expect:
+    replicas: 3
+    minReady: 2
+    images:
+    - minio/minio
+    conditions:
+    - type: Available
+- kind: pvc
+  name: minio
+  expect:
+    phase: Bound
+    capacity: 5G
+    storageClass: openebs-standard
+`,
+		},
+		"parse - -ve test case - invalid expectations": {
+			install: `
+components:
+- kind: service
+  name: minio
+  expect:
+    replicas: 3
+    capacity: 5G
+- kind: pod
+  labels: app=minio
+  expect:
+    replicas: 1
+    minReady: 2
+    phase: Bound
+    conditions:
+    - type: Ready
+      status: "true"
+- kind: pvc
+  name: minio
+  expect:
+    capacity: 5 gigs
+    storageClas: standard
+`,
+			expected: []string{
+				"line 3: component '0': expect: replicas does not apply to kind 'service'",
+				"line 3: component '0': expect: capacity does not apply to kind 'service'",
+				"line 8: component '1': expect: minReady '2' should not be more than replicas '1'",
+				"line 8: component '1': expect: invalid pod phase 'Bound': expected one of 'Pending', 'Running', 'Succeeded', 'Failed', 'Unknown'",
+				"line 8: component '1': expect: invalid status 'true' of condition 'Ready': expected one of 'True', 'False', 'Unknown'",
+				"line 17: component '2': expect: invalid quantity '5 gigs'",
+				"line 21: unknown field 'storageClas'",
+			},
+		},
+		"parse - -ve test case - dangling dependency & cycles": {
+			install: `
+components:
+- kind: pod
+  labels: app=minio
+  alias: app
+  dependsOn: [pvc, svc]
+- kind: pvc
+  name: minio
+  alias: pvc
+  dependsOn: [app]
+- kind: sc
+  name: standard
+  alias: sc
+  dependsOn: [sc]
+`,
+			expected: []string{
+				"line 3: component '0': dependsOn 'svc' is not the alias of any component",
+				"line 3: component '0': dependency cycle 'app' -> 'pvc' -> 'app'",
+				"line 11: component '2': dependency cycle 'sc' -> 'sc'",
+			},
+		},
+		"parse - -ve test case - invalid yaml": {
+			install: `
+components:
+- kind: pod
+ name: minio
+`,
+			expected: []string{
+				"line 3: did not find expected key",
+			},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(mock.install))
+			if len(mock.expected) == 0 {
+				if err != nil {
+					t.Fatalf("failed to parse: expected 'no error': actual '%s'", err)
+				}
+				return
+			}
+
+			errs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("failed to parse: expected 'validation errors': actual '%v'", err)
+			}
+			var actual []string
+			for _, e := range errs {
+				actual = append(actual, e.Error())
+			}
+			if !reflect.DeepEqual(actual, mock.expected) {
+				t.Fatalf("failed to parse: expected '%v': actual '%v'", mock.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOrdered(t *testing.T) {
+	tests := map[string]struct {
+		install  string
+		expected []string
+		isErr    bool
+	}{
+		"ordered - +ve test case - no dependencies": {
+			install: `
+components:
+- {kind: pod, labels: app=minio, alias: app}
+- {kind: pvc, name: minio, alias: pvc}
+`,
+			expected: []string{"app", "pvc"},
+		},
+		"ordered - +ve test case - dependencies": {
+			install: `
+components:
+- {kind: pod, labels: app=minio, alias: app, dependsOn: [pvc, operator]}
+- {kind: pvc, name: minio, alias: pvc, dependsOn: [sc]}
+- {kind: sc, name: standard, alias: sc, dependsOn: [operator]}
+- {kind: pod, labels: name=maya-apiserver, alias: operator, dependsOn: [crd]}
+- {kind: crd, name: storagepools.openebs.io, alias: crd}
+- {kind: service, name: minio, alias: svc}
+`,
+			expected: []string{"crd", "operator", "sc", "pvc", "app", "svc"},
+		},
+		"ordered - -ve test case - cycle": {
+			install: `
+components:
+- {kind: pod, labels: app=minio, alias: app, dependsOn: [pvc]}
+- {kind: pvc, name: minio, alias: pvc, dependsOn: [app]}
+`,
+			isErr: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			// the installation is not validated so that cycles reach Ordered
+			i := &Installation{}
+			if err := yaml.Unmarshal([]byte(mock.install), i); err != nil {
+				t.Fatalf("failed to unmarshal: expected 'no error': actual '%s'", err)
+			}
+
+			components, err := i.Ordered()
+			if mock.isErr != (err != nil) {
+				t.Fatalf("failed to order: expected error '%t': actual '%v'", mock.isErr, err)
+			}
+			var actual []string
+			for _, c := range components {
+				actual = append(actual, c.Alias)
+			}
+			if !reflect.DeepEqual(actual, mock.expected) {
+				t.Fatalf("failed to order: expected '%v': actual '%v'", mock.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := map[string]struct {
+		quantity string
+		expected int64
+		isErr    bool
+	}{
+		"parse quantity - +ve test case - plain":    {quantity: "1024", expected: 1024},
+		"parse quantity - +ve test case - decimal":  {quantity: "5G", expected: 5000000000},
+		"parse quantity - +ve test case - binary":   {quantity: "512Mi", expected: 512 << 20},
+		"parse quantity - +ve test case - fraction": {quantity: "1.5Gi", expected: 3 << 29},
+		"parse quantity - -ve test case - no value": {quantity: "Gi", isErr: true},
+		"parse quantity - -ve test case - bad unit": {quantity: "5GB", isErr: true},
+		"parse quantity - -ve test case - two dots": {quantity: "1.2.3G", isErr: true},
+		"parse quantity - -ve test case - spaced":   {quantity: "5 G", isErr: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ParseQuantity(mock.quantity)
+			if mock.isErr != (err != nil) {
+				t.Fatalf("failed to parse quantity '%s': expected error '%t': actual '%v'", mock.quantity, mock.isErr, err)
+			}
+			if actual != mock.expected {
+				t.Fatalf("failed to parse quantity '%s': expected '%d': actual '%d'", mock.quantity, mock.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateSelector(t *testing.T) {
+	tests := map[string]struct {
+		selector string
+		isErr    bool
+	}{
+		"validate selector - +ve test case - empty":       {selector: ""},
+		"validate selector - +ve test case - equality":    {selector: "name=maya-apiserver,tier==db"},
+		"validate selector - +ve test case - inequality":  {selector: "app!=minio"},
+		"validate selector - +ve test case - set based":   {selector: "env in (prod, qa),tier notin (db)"},
+		"validate selector - +ve test case - existence":   {selector: "openebs.io/replica,!canary"},
+		"validate selector - -ve test case - bad key":     {selector: "-app=minio", isErr: true},
+		"validate selector - -ve test case - bad value":   {selector: "app=minio!", isErr: true},
+		"validate selector - -ve test case - empty part":  {selector: "app=minio,", isErr: true},
+		"validate selector - -ve test case - bad set key": {selector: "a b in (c)", isErr: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateSelector(mock.selector)
+			if mock.isErr && err == nil {
+				t.Fatalf("failed to validate selector '%s': expected 'error': actual 'no error'", mock.selector)
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("failed to validate selector '%s': expected 'no error': actual '%s'", mock.selector, err)
+			}
+		})
+	}
+}
+
+func TestLintManifest(t *testing.T) {
+	manifest := `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-client-scripts
+data:
+  put: |-
+    mc mb minio/mybucket
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ha-minio-app-verify
+data:
+  config: |-
+    components:
+      - kind: service
+        name: ha-minio
+      - kind: pod
+        lables: app=ha-minio
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: templated-verify
+data:
+  config: |-
+    components:
+      - kind: sc
+        name: {{ required "storage class is required" .Values.storageClass }}
+      - kind: pod
+        namespace: {{ .Namespace }}
+        labels: app={{ .Values.app | defualt "minio" }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unknown-include
+data:
+  config: |-
+    includes:
+      - catalog: openebs/unknown
+`
+
+	errs, err := LintManifest([]byte(manifest))
+	if err != nil {
+		t.Fatalf("failed to lint: expected 'no error': actual '%s'", err)
+	}
+
+	var actual []string
+	for _, e := range errs {
+		actual = append(actual, e.Error())
+	}
+	expected := []string{
+		"line 19: config map 'ha-minio-app-verify' key 'config': component '1': either name or labels is required",
+		"line 20: config map 'ha-minio-app-verify' key 'config': unknown field 'lables'",
+		"line 33: config map 'templated-verify' key 'config': function \"defualt\" not defined",
+		"line 42: config map 'unknown-include' key 'config': include '0': unknown catalog 'openebs/unknown': expected one of 'openebs/operator-v0.5.3'",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("failed to lint: expected '%v': actual '%v'", expected, actual)
+	}
+	if lines := strings.Split(manifest, "\n"); !strings.Contains(lines[19], "lables") {
+		t.Fatalf("failed to lint: expected 'line 20 to have the typo': actual '%s'", lines[19])
+	}
+}
+
+func TestLintErrors(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		line     int
+		expected []string
+	}{
+		"lint errors - +ve test case - validation errors relative to the key": {
+			err:      ValidationErrors{{Line: 2, Message: "unknown field 'lables'"}, {Message: "duplicate alias 'app'"}},
+			line:     10,
+			expected: []string{"line 12: config map 'verify' key 'config': unknown field 'lables'", "line 0: config map 'verify' key 'config': duplicate alias 'app'"},
+		},
+		"lint errors - +ve test case - key line is not known": {
+			err:      ValidationErrors{{Line: 2, Message: "unknown field 'lables'"}},
+			expected: []string{"line 0: config map 'verify' key 'config': unknown field 'lables'"},
+		},
+		"lint errors - +ve test case - other error at the key": {
+			err:      fmt.Errorf("failed to read include"),
+			line:     10,
+			expected: []string{"line 10: config map 'verify' key 'config': failed to read include"},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual []string
+			for _, e := range lintErrors(mock.err, "verify", "config", mock.line) {
+				actual = append(actual, e.Error())
+			}
+			if !reflect.DeepEqual(actual, mock.expected) {
+				t.Fatalf("failed to get lint errors: expected '%v': actual '%v'", mock.expected, actual)
+			}
+		})
+	}
+}
+
+// TestLintTests lints the verify files embedded in the manifests of the
+// tests
+func TestLintTests(t *testing.T) {
+	err := filepath.Walk("../../tests", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".yaml" {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		errs, err := LintManifest(data)
+		if err != nil || len(errs) != 0 {
+			t.Fatalf("failed to lint '%s': expected 'no problems': actual '%v' '%v'", path, errs, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to lint tests: expected 'no error': actual '%s'", err)
+	}
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	osexec "os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+)
+
+const (
+	// DefaultReadyTimeout is the maximum duration to wait for a forwarded
+	// local port to accept connections
+	DefaultReadyTimeout = 30 * time.Second
+	// readyInterval is the interval between the checks of a forwarded local
+	// port
+	readyInterval = 200 * time.Millisecond
+	// localHost is the host where the local ports are forwarded
+	localHost = "127.0.0.1"
+)
+
+// Process is a running port forward
+type Process interface {
+	// Stop stops the port forward
+	Stop() error
+	// Done is closed when the port forward has exited
+	Done() <-chan struct{}
+}
+
+// Starter starts a port forward with the provided kubectl args. The output of
+// the port forward is written to out.
+type Starter func(args []string, out io.Writer) (Process, error)
+
+// shellProcess is a port forward run as a shell command
+type shellProcess struct {
+	cmd  *osexec.Cmd
+	done chan struct{}
+}
+
+// Stop kills the port forward command along with any processes started by it
+// & waits for it to exit
+func (p *shellProcess) Stop() error {
+	select {
+	case <-p.done:
+		return nil
+	default:
+	}
+
+	exec.KillProcessGroup(p.cmd)
+	<-p.done
+	return nil
+}
+
+// Done is closed when the port forward command has exited
+func (p *shellProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// ShellStarter returns a Starter that runs the port forward via the provided
+// kubectl binary
+//
+// NOTE:
+//  The port forward runs the kubectl binary directly i.e. it is neither dry
+// run, audited, recorded nor replayed like the other kubectl commands. Hence
+// it fails if these commands are intercepted; see kubectl.IsIntercepted.
+func ShellStarter(binary string) Starter {
+	return func(args []string, out io.Writer) (Process, error) {
+		if kubectl.IsIntercepted() {
+			return nil, fmt.Errorf("port forward is not supported while kubectl commands are dry run, recorded or replayed")
+		}
+
+		cmd := osexec.Command(binary, args...)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		exec.SetProcessGroup(cmd)
+
+		err := cmd.Start()
+		if err != nil {
+			return nil, err
+		}
+
+		p := &shellProcess{cmd: cmd, done: make(chan struct{})}
+		go func() {
+			cmd.Wait()
+			close(p.done)
+		}()
+		return p, nil
+	}
+}
+
+// output is a buffer that is safe to be written by a port forward while it
+// is being read
+type output struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *output) String() string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.buf.String()
+}
+
+// Forward is a port forward from a local port to a port of a pod or service
+type Forward struct {
+	// Target is the forwarded resource e.g. svc/minio, pod/minio-0
+	Target string
+	// RemotePort is the forwarded port of the target
+	RemotePort int
+	// LocalAddress is the address in host:port format that reaches the
+	// forwarded port of the target
+	LocalAddress string
+	// process is the running port forward
+	process Process
+	// out is the output of the port forward
+	out *output
+}
+
+// Output returns the output of the port forward
+func (f *Forward) Output() string {
+	return f.out.String()
+}
+
+// Stop stops the port forward
+func (f *Forward) Stop() error {
+	return f.process.Stop()
+}
+
+// Manager starts port forwards & keeps track of them till they are closed
+type Manager struct {
+	// start starts a port forward
+	start Starter
+	// readyTimeout is the maximum duration to wait for a forwarded local port
+	// to accept connections
+	readyTimeout time.Duration
+	// mutex guards the forwards
+	mutex sync.Mutex
+	// forwards that are running
+	forwards []*Forward
+}
+
+// NewManager returns a new instance of Manager that starts its port forwards
+// via the provided starter. A zero ready timeout implies the default timeout.
+func NewManager(start Starter, readyTimeout time.Duration) *Manager {
+	if readyTimeout <= 0 {
+		readyTimeout = DefaultReadyTimeout
+	}
+
+	return &Manager{
+		start:        start,
+		readyTimeout: readyTimeout,
+	}
+}
+
+// NewShellManager returns a new instance of Manager that runs its port
+// forwards via the kubectl executable
+func NewShellManager() *Manager {
+	return NewManager(ShellStarter(kubectl.GetKubectlPath()), DefaultReadyTimeout)
+}
+
+// Forward forwards a free local port to the remote port of the target e.g.
+// svc/minio, pod/minio-0. It returns once the local port accepts connections.
+// The kubeconfig, context & namespace of the provided kubectl instance are
+// used by the port forward.
+func (m *Manager) Forward(k *kubectl.Kubectl, target string, remotePort int) (f *Forward, err error) {
+	port, err := freePort()
+	if err != nil {
+		err = fmt.Errorf("failed to forward to '%s': %v", target, err)
+		return
+	}
+
+	f = &Forward{
+		Target:       target,
+		RemotePort:   remotePort,
+		LocalAddress: net.JoinHostPort(localHost, strconv.Itoa(port)),
+		out:          &output{},
+	}
+
+	args := k.Command([]string{"port-forward", "--address", localHost, target, fmt.Sprintf("%d:%d", port, remotePort)})
+	f.process, err = m.start(args, f.out)
+	if err != nil {
+		err = fmt.Errorf("failed to forward to '%s': %v", target, err)
+		return nil, err
+	}
+
+	err = m.waitForReady(f)
+	if err != nil {
+		f.Stop()
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	m.forwards = append(m.forwards, f)
+	m.mutex.Unlock()
+	return
+}
+
+// ForwardComponent forwards a free local port to the remote port of the
+// service or pod component. The oldest running pod is forwarded for a pod
+// component that is identified by its labels.
+func (m *Manager) ForwardComponent(c meta.Component, remotePort int) (f *Forward, err error) {
+	k := kubectl.New().Namespace(c.Namespace)
+
+	var target string
+	switch {
+	case util.IsService(c.Kind) && len(c.Name) != 0:
+		target = "svc/" + c.Name
+	case util.IsPod(c.Kind) && len(c.Name) != 0:
+		target = "pod/" + c.Name
+	case util.IsPod(c.Kind):
+		var pod string
+		pod, err = kubectl.GetOldestRunningPod(k.Labels(c.Labels))
+		if err != nil {
+			return
+		}
+		if len(pod) == 0 {
+			err = fmt.Errorf("failed to forward to component '%s': no running pod found", c.Alias)
+			return
+		}
+		target = "pod/" + pod
+	default:
+		err = fmt.Errorf("failed to forward to component '%s': kind '%s' with name '%s' is not supported", c.Alias, c.Kind, c.Name)
+		return
+	}
+
+	return m.Forward(k, target, remotePort)
+}
+
+// waitForReady waits till the local port of the forward accepts connections.
+// It returns early if the port forward exits.
+func (m *Manager) waitForReady(f *Forward) error {
+	deadline := time.After(m.readyTimeout)
+	ticker := time.NewTicker(readyInterval)
+	defer ticker.Stop()
+
+	for {
+		conn, err := net.DialTimeout("tcp", f.LocalAddress, readyInterval)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		select {
+		case <-f.process.Done():
+			return fmt.Errorf("failed to forward '%s' to '%s:%d': port forward exited: '%s'", f.LocalAddress, f.Target, f.RemotePort, f.Output())
+		case <-deadline:
+			return fmt.Errorf("failed to forward '%s' to '%s:%d': not ready within '%s': %v", f.LocalAddress, f.Target, f.RemotePort, m.readyTimeout, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Forwards returns the port forwards that are running
+func (m *Manager) Forwards() []*Forward {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]*Forward(nil), m.forwards...)
+}
+
+// Close stops all the port forwards. The first error, if any, is returned
+// after attempting to stop all of them.
+func (m *Manager) Close() (err error) {
+	m.mutex.Lock()
+	forwards := m.forwards
+	m.forwards = nil
+	m.mutex.Unlock()
+
+	for _, f := range forwards {
+		stopErr := f.Stop()
+		if stopErr != nil && err == nil {
+			err = fmt.Errorf("failed to stop port forward to '%s': %v", f.Target, stopErr)
+		}
+	}
+	return
+}
+
+// freePort returns a local port that is free at the time of the call
+func freePort() (port int, err error) {
+	l, err := net.Listen("tcp", net.JoinHostPort(localHost, "0"))
+	if err != nil {
+		return
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
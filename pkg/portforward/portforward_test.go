@@ -0,0 +1,261 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+)
+
+// fakeProcess mimics a port forward by listening on the forwarded local port
+type fakeProcess struct {
+	listener net.Listener
+	done     chan struct{}
+}
+
+func (p *fakeProcess) Stop() error {
+	select {
+	case <-p.done:
+		return nil
+	default:
+	}
+
+	err := p.listener.Close()
+	close(p.done)
+	return err
+}
+
+func (p *fakeProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// fakeStarter records the args of the port forwards it starts. It listens on
+// the forwarded local port unless it is set to exit or to hang.
+type fakeStarter struct {
+	args [][]string
+	exit bool
+	hang bool
+}
+
+func (s *fakeStarter) start(args []string, out io.Writer) (Process, error) {
+	s.args = append(s.args, args)
+	done := make(chan struct{})
+
+	if s.exit {
+		fmt.Fprint(out, "error: services \"minio\" not found")
+		close(done)
+		return &fakeProcess{done: done}, nil
+	}
+
+	if s.hang {
+		return &fakeProcess{listener: &nopListener{}, done: done}, nil
+	}
+
+	// the ports arg is of the form local:remote
+	var local string
+	for _, arg := range args {
+		if strings.Contains(arg, ":") && !strings.HasPrefix(arg, "-") {
+			local = strings.Split(arg, ":")[0]
+		}
+	}
+
+	l, err := net.Listen("tcp", net.JoinHostPort(localHost, local))
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	return &fakeProcess{listener: l, done: done}, nil
+}
+
+// nopListener is a listener that is never listening
+type nopListener struct {
+	net.Listener
+}
+
+func (l *nopListener) Close() error {
+	return nil
+}
+
+func TestForward(t *testing.T) {
+	s := &fakeStarter{}
+	m := NewManager(s.start, time.Second)
+
+	f, err := m.Forward(kubectl.New().KubeConfig("/tmp/config").Namespace("litmus"), "svc/minio", 9000)
+	if err != nil {
+		t.Fatalf("failed to forward: expected 'no error': actual '%v'", err)
+	}
+
+	args := strings.Join(s.args[0], " ")
+	if !strings.HasPrefix(args, "port-forward --address 127.0.0.1 svc/minio ") ||
+		!strings.Contains(args, ":9000 --kubeconfig=/tmp/config --namespace=litmus") {
+		t.Fatalf("failed to forward: expected 'port-forward args': actual '%s'", args)
+	}
+
+	conn, err := net.Dial("tcp", f.LocalAddress)
+	if err != nil {
+		t.Fatalf("failed to dial forwarded address '%s': %v", f.LocalAddress, err)
+	}
+	conn.Close()
+
+	if len(m.Forwards()) != 1 {
+		t.Fatalf("failed to forward: expected '1 forward': actual '%d'", len(m.Forwards()))
+	}
+
+	err = m.Close()
+	if err != nil {
+		t.Fatalf("failed to close: expected 'no error': actual '%v'", err)
+	}
+
+	if len(m.Forwards()) != 0 {
+		t.Fatalf("failed to close: expected '0 forwards': actual '%d'", len(m.Forwards()))
+	}
+
+	_, err = net.DialTimeout("tcp", f.LocalAddress, time.Second)
+	if err == nil {
+		t.Fatalf("failed to close: expected 'closed local port': actual 'port accepts connections'")
+	}
+}
+
+func TestForwardFailure(t *testing.T) {
+	tests := map[string]struct {
+		starter  *fakeStarter
+		contains string
+	}{
+		"forward - -ve test case - port forward exits": {
+			starter:  &fakeStarter{exit: true},
+			contains: "services \"minio\" not found",
+		},
+		"forward - -ve test case - port is never ready": {
+			starter:  &fakeStarter{hang: true},
+			contains: "not ready within",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := NewManager(mock.starter.start, 500*time.Millisecond)
+			_, err := m.Forward(kubectl.New(), "svc/minio", 9000)
+			if err == nil || !strings.Contains(err.Error(), mock.contains) {
+				t.Fatalf("failed to forward: expected error containing '%s': actual '%v'", mock.contains, err)
+			}
+
+			if len(m.Forwards()) != 0 {
+				t.Fatalf("failed to forward: expected '0 forwards': actual '%d'", len(m.Forwards()))
+			}
+		})
+	}
+}
+
+func TestForwardComponent(t *testing.T) {
+	tests := map[string]struct {
+		component meta.Component
+		target    string
+		isErr     bool
+	}{
+		"forward component - +ve test case - service": {
+			component: meta.Component{Kind: "service", Name: "minio", Alias: "app-service"},
+			target:    "svc/minio",
+		},
+		"forward component - +ve test case - named pod": {
+			component: meta.Component{Kind: "po", Name: "minio-0", Alias: "app-pod"},
+			target:    "pod/minio-0",
+		},
+		"forward component - -ve test case - service without name": {
+			component: meta.Component{Kind: "svc", Labels: "app=minio", Alias: "app-service"},
+			isErr:     true,
+		},
+		"forward component - -ve test case - unsupported kind": {
+			component: meta.Component{Kind: "pvc", Name: "minio", Alias: "pvc"},
+			isErr:     true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &fakeStarter{}
+			m := NewManager(s.start, time.Second)
+			defer m.Close()
+
+			f, err := m.ForwardComponent(mock.component, 9000)
+			if mock.isErr != (err != nil) {
+				t.Fatalf("failed to forward component: expected error '%t': actual error '%v'", mock.isErr, err)
+			}
+
+			if !mock.isErr && f.Target != mock.target {
+				t.Fatalf("failed to forward component: expected target '%s': actual target '%s'", mock.target, f.Target)
+			}
+		})
+	}
+}
+
+func TestShellStarter(t *testing.T) {
+	out := &output{}
+	p, err := ShellStarter("sh")([]string{"-c", "echo forwarding; sleep 10"}, out)
+	if err != nil {
+		t.Fatalf("failed to start: expected 'no error': actual '%v'", err)
+	}
+
+	// wait for the output before stopping the process
+	for i := 0; i < 50 && !strings.Contains(out.String(), "forwarding"); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	p.Stop()
+	select {
+	case <-p.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("failed to stop: expected 'exited process': actual 'running process'")
+	}
+
+	if !strings.Contains(out.String(), "forwarding") {
+		t.Fatalf("failed to start: expected output 'forwarding': actual '%s'", out.String())
+	}
+
+	// stopping an exited process is a no-op
+	err = p.Stop()
+	if err != nil {
+		t.Fatalf("failed to stop: expected 'no error': actual '%v'", err)
+	}
+}
+
+func TestShellStarterWhenIntercepted(t *testing.T) {
+	// a port forward can not be replayed
+	kubectl.SetDefaultExecutor(exec.NewReplayExec(nil, exec.ReplayInOrderMode))
+	defer kubectl.SetDefaultExecutor(nil)
+
+	_, err := ShellStarter("sh")([]string{"-c", "sleep 10"}, &output{})
+	if err == nil {
+		t.Fatalf("failed to refuse port forward: expected 'error': actual 'no error'")
+	}
+}
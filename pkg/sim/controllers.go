@@ -0,0 +1,527 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sim
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+)
+
+// maxReconcileRounds bounds the number of rounds the controllers run after a
+// change. A round may result in changes that need further rounds e.g. a
+// provisioned volume creates a deployment that creates pods.
+const maxReconcileRounds = 10
+
+// reconcile runs the controllers till the cluster settles
+func (c *Cluster) reconcile() {
+	for i := 0; i < maxReconcileRounds; i++ {
+		changed := c.collectGarbage()
+		changed = c.reconcileServices() || changed
+		changed = c.reconcileClaims() || changed
+		changed = c.reconcileWorkloads() || changed
+		changed = c.reconcileJobs() || changed
+		changed = c.schedule() || changed
+		c.updateWorkloadStatus()
+
+		if !changed {
+			return
+		}
+	}
+}
+
+// collectGarbage deletes the objects whose owner no longer exists
+func (c *Cluster) collectGarbage() (changed bool) {
+	for _, o := range c.objects {
+		kind, name := o.owner()
+		if len(kind) == 0 {
+			continue
+		}
+
+		if _, ok := c.objects[c.key(lookupKind(kind), o.namespace(), name)]; !ok {
+			c.delete(o)
+			changed = true
+		}
+	}
+	return
+}
+
+// reconcileServices assigns a cluster ip to the services that do not have one
+func (c *Cluster) reconcileServices() (changed bool) {
+	for _, svc := range c.list(lookupKind("Service"), "", nil) {
+		if len(svc.str("spec", "clusterIP")) != 0 || svc.str("spec", "type") == "ExternalName" {
+			continue
+		}
+
+		c.seq++
+		svc.set(fmt.Sprintf("10.0.%d.%d", c.seq/250, c.seq%250+1), "spec", "clusterIP")
+		changed = true
+	}
+	return
+}
+
+// reconcileClaims binds the pending claims to newly provisioned volumes. A
+// claim that refers to a missing storage class stays pending.
+func (c *Cluster) reconcileClaims() (changed bool) {
+	for _, pvc := range c.list(lookupKind("PersistentVolumeClaim"), "", nil) {
+		if pvc.str("status", "phase") == "Bound" {
+			continue
+		}
+
+		var sc object
+		if name := pvc.str("spec", "storageClassName"); len(name) != 0 {
+			var ok bool
+			if sc, ok = c.objects[c.key(lookupKind("StorageClass"), "", name)]; !ok {
+				pvc.set("Pending", "status", "phase")
+				continue
+			}
+		}
+
+		policy := sc.str("reclaimPolicy")
+		if len(policy) == 0 {
+			policy = "Delete"
+		}
+
+		pv := object{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolume",
+			"metadata":   map[string]interface{}{"name": "pvc-" + pvc.str("metadata", "uid")},
+			"spec": map[string]interface{}{
+				"accessModes": pvc.get("spec", "accessModes"),
+				"capacity":    map[string]interface{}{"storage": pvc.get("spec", "resources", "requests", "storage")},
+				"claimRef": map[string]interface{}{
+					"kind":      "PersistentVolumeClaim",
+					"namespace": pvc.namespace(),
+					"name":      pvc.name(),
+					"uid":       pvc.str("metadata", "uid"),
+				},
+				"persistentVolumeReclaimPolicy": policy,
+				"storageClassName":              pvc.str("spec", "storageClassName"),
+			},
+			"status": map[string]interface{}{"phase": "Bound"},
+		}
+		c.create(pv)
+
+		pvc.set(pv.name(), "spec", "volumeName")
+		pvc.set("Bound", "status", "phase")
+		pvc.set(pv.get("spec", "accessModes"), "status", "accessModes")
+		pvc.set(pv.get("spec", "capacity"), "status", "capacity")
+		changed = true
+
+		if p, ok := c.provisioners[sc.str("provisioner")]; ok {
+			c.provision(p, pvc, pv, sc)
+		}
+	}
+	return
+}
+
+// provision creates the objects that make up the volume. These objects are
+// owned by the volume & are hence deleted along with the volume.
+func (c *Cluster) provision(p Provisioner, pvc, pv, sc object) {
+	manifest, err := p(pvc.copy(), pv.copy(), sc.copy())
+	if err != nil {
+		pv.set("Failed", "status", "phase")
+		pv.set(err.Error(), "status", "message")
+		return
+	}
+
+	objs, err := decodeManifest(manifest)
+	if err != nil {
+		pv.set("Failed", "status", "phase")
+		pv.set(err.Error(), "status", "message")
+		return
+	}
+
+	for _, o := range objs {
+		if lookupKind(o.kind()).namespaced && len(o.namespace()) == 0 {
+			o.set(pvc.namespace(), "metadata", "namespace")
+		}
+		o.set([]interface{}{ownerRef(pv)}, "metadata", "ownerReferences")
+		c.create(o)
+	}
+}
+
+// releaseVolume deletes or releases the volume of the deleted claim based on
+// the volume's reclaim policy
+func (c *Cluster) releaseVolume(pvc object) {
+	pv, ok := c.objects[c.key(lookupKind("PersistentVolume"), "", pvc.str("spec", "volumeName"))]
+	if !ok {
+		return
+	}
+
+	if pv.str("spec", "persistentVolumeReclaimPolicy") == "Delete" {
+		c.delete(pv)
+		return
+	}
+	pv.set("Released", "status", "phase")
+}
+
+// ownerRef returns the owner reference to the provided object
+func ownerRef(o object) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": o.str("apiVersion"),
+		"kind":       o.kind(),
+		"name":       o.name(),
+		"uid":        o.str("metadata", "uid"),
+		"controller": true,
+	}
+}
+
+// ownedPods returns the pods owned by the provided object sorted by their age
+func (c *Cluster) ownedPods(owner object) (pods []object) {
+	for _, p := range c.list(lookupKind("Pod"), owner.namespace(), nil) {
+		if kind, name := p.owner(); kind == owner.kind() && name == owner.name() {
+			pods = append(pods, p)
+		}
+	}
+
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[i].str("metadata", "creationTimestamp") < pods[j].str("metadata", "creationTimestamp")
+	})
+	return
+}
+
+// reconcileWorkloads creates or deletes the pods of deployments &
+// statefulsets to match their replicas
+func (c *Cluster) reconcileWorkloads() (changed bool) {
+	for _, kind := range []string{"Deployment", "StatefulSet"} {
+		for _, w := range c.list(lookupKind(kind), "", nil) {
+			pods := c.ownedPods(w)
+			replicas := w.num(1, "spec", "replicas")
+
+			for len(pods) < replicas {
+				pods = append(pods, c.createPod(w, pods))
+				changed = true
+			}
+
+			// scale down by deleting the newest pods
+			for len(pods) > replicas {
+				c.delete(pods[len(pods)-1])
+				pods = pods[:len(pods)-1]
+				changed = true
+			}
+		}
+	}
+	return
+}
+
+// reconcileJobs creates the pods of jobs that are yet to complete
+func (c *Cluster) reconcileJobs() (changed bool) {
+	for _, job := range c.list(lookupKind("Job"), "", nil) {
+		completions := job.num(1, "spec", "completions")
+
+		succeeded, active := 0, 0
+		for _, p := range c.ownedPods(job) {
+			switch p.str("status", "phase") {
+			case "Succeeded":
+				succeeded++
+			case "Failed":
+			default:
+				active++
+			}
+		}
+
+		if prev := job.num(0, "status", "succeeded"); prev > succeeded {
+			succeeded = prev
+		}
+
+		if succeeded+active < completions {
+			c.createPod(job, nil)
+			active++
+			changed = true
+		}
+
+		job.set(float64(succeeded), "status", "succeeded")
+		job.set(float64(active), "status", "active")
+		if succeeded >= completions && job.get("status", "completionTime") == nil {
+			job.set(c.now.Format(time.RFC3339), "status", "completionTime")
+			job.set([]interface{}{map[string]interface{}{"type": "Complete", "status": "True"}}, "status", "conditions")
+		}
+	}
+	return
+}
+
+// createPod creates a pod from the template of the provided workload
+func (c *Cluster) createPod(owner object, existing []object) object {
+	labels := map[string]interface{}{}
+	for k, v := range owner.dict("spec", "template", "metadata", "labels") {
+		labels[k] = v
+	}
+
+	var name string
+	switch owner.kind() {
+	case "StatefulSet":
+		// the lowest ordinal that is not in use
+		used := map[string]bool{}
+		for _, p := range existing {
+			used[p.name()] = true
+		}
+		for i := 0; ; i++ {
+			if name = fmt.Sprintf("%s-%d", owner.name(), i); !used[name] {
+				break
+			}
+		}
+		labels["statefulset.kubernetes.io/pod-name"] = name
+	case "Job":
+		name = fmt.Sprintf("%s-%s", owner.name(), c.suffix())
+		labels["job-name"] = owner.name()
+		labels["controller-uid"] = owner.str("metadata", "uid")
+	default:
+		hash := templateHash(owner)
+		name = fmt.Sprintf("%s-%s-%s", owner.name(), hash, c.suffix())
+		labels["pod-template-hash"] = hash
+	}
+
+	spec := object{}
+	if s := owner.dict("spec", "template", "spec"); s != nil {
+		spec = object(s).copy()
+	}
+
+	pod := object{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":            name,
+			"namespace":       owner.namespace(),
+			"labels":          labels,
+			"ownerReferences": []interface{}{ownerRef(owner)},
+		},
+		"spec":   map[string]interface{}(spec),
+		"status": map[string]interface{}{"phase": "Pending"},
+	}
+	c.create(pod)
+	return pod
+}
+
+// suffix returns a random looking suffix similar to the ones generated by
+// kubernetes
+func (c *Cluster) suffix() string {
+	const alphabet = "bcdfghjklmnpqrstvwxz2456789"
+
+	h := fnv.New32a()
+	fmt.Fprint(h, c.seq)
+	n := int(h.Sum32())
+
+	b := make([]byte, 5)
+	for i := range b {
+		b[i] = alphabet[n%len(alphabet)]
+		n /= len(alphabet)
+	}
+	return string(b)
+}
+
+// templateHash returns the hash of the workload's pod template
+func templateHash(owner object) string {
+	h := fnv.New32a()
+	fmt.Fprint(h, owner.namespace(), owner.name(), owner.get("spec", "template"))
+	return fmt.Sprintf("%x", h.Sum32())
+}
+
+// schedule binds the pending pods to the schedulable nodes & starts them
+func (c *Cluster) schedule() (changed bool) {
+	for _, p := range c.list(lookupKind("Pod"), "", nil) {
+		if p.str("status", "phase") != "Pending" {
+			continue
+		}
+
+		node := p.str("spec", "nodeName")
+		if len(node) == 0 {
+			node = c.pickNode(p)
+		}
+
+		if len(node) == 0 {
+			if len(p.list("status", "conditions")) == 0 {
+				c.recordEvent(p, "Warning", "FailedScheduling", "0 nodes are available to schedule this pod")
+			}
+			p.set([]interface{}{map[string]interface{}{
+				"type":    "PodScheduled",
+				"status":  "False",
+				"reason":  "Unschedulable",
+				"message": "0 nodes are available to schedule this pod",
+			}}, "status", "conditions")
+			continue
+		}
+
+		p.set(node, "spec", "nodeName")
+		c.recordEvent(p, "Normal", "Scheduled", fmt.Sprintf("Successfully assigned %s/%s to %s", p.namespace(), p.name(), node))
+		c.start(p)
+		changed = true
+	}
+	return
+}
+
+// pickNode returns the schedulable node that runs the least number of pods.
+// An empty node name implies the pod can not be scheduled.
+func (c *Cluster) pickNode(p object) (picked string) {
+	load := map[string]int{}
+	for _, other := range c.list(lookupKind("Pod"), "", nil) {
+		if phase := other.str("status", "phase"); phase != "Succeeded" && phase != "Failed" {
+			load[other.str("spec", "nodeName")]++
+		}
+	}
+
+	nodeSelector := toStringMap(p.dict("spec", "nodeSelector"))
+	for _, n := range c.list(lookupKind("Node"), "", nil) {
+		if n.bool("spec", "unschedulable") || !matchLabels(n.labels(), nodeSelector) || !tolerates(p, n) || c.repels(p, n) {
+			continue
+		}
+
+		if len(picked) == 0 || load[n.name()] < load[picked] {
+			picked = n.name()
+		}
+	}
+	return
+}
+
+// tolerates flags if the pod tolerates the NoSchedule & NoExecute taints of
+// the node
+func tolerates(p, n object) bool {
+	for _, t := range n.list("spec", "taints") {
+		taint := object(toMap(t))
+		effect := taint.str("effect")
+		if effect != "NoSchedule" && effect != "NoExecute" {
+			continue
+		}
+
+		tolerated := false
+		for _, tol := range p.list("spec", "tolerations") {
+			tl := object(toMap(tol))
+			if len(tl.str("effect")) != 0 && tl.str("effect") != effect {
+				continue
+			}
+
+			switch {
+			case tl.str("operator") == "Exists" && (len(tl.str("key")) == 0 || tl.str("key") == taint.str("key")):
+				tolerated = true
+			case tl.str("key") == taint.str("key") && tl.str("value") == taint.str("value"):
+				tolerated = true
+			}
+		}
+
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// repels flags if the node runs a pod that the provided pod has a required
+// anti affinity to. Only the hostname topology is considered.
+func (c *Cluster) repels(p, n object) bool {
+	for _, term := range p.list("spec", "affinity", "podAntiAffinity", "requiredDuringSchedulingIgnoredDuringExecution") {
+		t := object(toMap(term))
+		if key := t.str("topologyKey"); key != "kubernetes.io/hostname" {
+			continue
+		}
+
+		match := toStringMap(t.dict("labelSelector", "matchLabels"))
+		for _, other := range c.list(lookupKind("Pod"), p.namespace(), nil) {
+			if other.str("spec", "nodeName") == n.name() && matchLabels(other.labels(), match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toMap returns the value as a map if it is one
+func toMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// start starts the containers of the scheduled pod. Pods of jobs run to
+// completion at once.
+func (c *Cluster) start(p object) {
+	now := c.now.Format(time.RFC3339)
+	kind, _ := p.owner()
+	completed := kind == "Job"
+
+	var inits []interface{}
+	for _, ct := range p.list("spec", "initContainers") {
+		ct := object(toMap(ct))
+		inits = append(inits, map[string]interface{}{
+			"name":         ct.str("name"),
+			"image":        ct.str("image"),
+			"ready":        true,
+			"restartCount": 0,
+			"state":        map[string]interface{}{"terminated": map[string]interface{}{"exitCode": 0, "reason": "Completed"}},
+		})
+	}
+
+	var statuses []interface{}
+	for _, ct := range p.list("spec", "containers") {
+		ct := object(toMap(ct))
+		state := map[string]interface{}{"running": map[string]interface{}{"startedAt": now}}
+		if completed {
+			state = map[string]interface{}{"terminated": map[string]interface{}{"exitCode": 0, "reason": "Completed"}}
+		}
+		statuses = append(statuses, map[string]interface{}{
+			"name":         ct.str("name"),
+			"image":        ct.str("image"),
+			"ready":        !completed,
+			"restartCount": 0,
+			"state":        state,
+		})
+	}
+
+	ready := "True"
+	if completed {
+		ready = "False"
+	}
+
+	c.seq++
+	status := map[string]interface{}{
+		"phase":             "Running",
+		"podIP":             fmt.Sprintf("172.17.%d.%d", c.seq/250, c.seq%250+1),
+		"startTime":         now,
+		"containerStatuses": statuses,
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "PodScheduled", "status": "True"},
+			map[string]interface{}{"type": "Ready", "status": ready},
+		},
+	}
+	if len(inits) != 0 {
+		status["initContainerStatuses"] = inits
+	}
+	if completed {
+		status["phase"] = "Succeeded"
+	}
+	p["status"] = status
+}
+
+// updateWorkloadStatus updates the replica counts of the workloads
+func (c *Cluster) updateWorkloadStatus() {
+	for _, kind := range []string{"Deployment", "StatefulSet"} {
+		for _, w := range c.list(lookupKind(kind), "", nil) {
+			pods := c.ownedPods(w)
+
+			ready := 0
+			for _, p := range pods {
+				if p.str("status", "phase") == "Running" {
+					ready++
+				}
+			}
+
+			w.set(float64(len(pods)), "status", "replicas")
+			w.set(float64(ready), "status", "readyReplicas")
+			w.set(float64(ready), "status", "availableReplicas")
+			w.set(float64(len(pods)), "status", "updatedReplicas")
+		}
+	}
+}
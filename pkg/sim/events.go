@@ -0,0 +1,72 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sim
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// recordEvent records an event about the involved object. An event with the
+// same reason & message about the same object is counted instead of being
+// recorded again.
+func (c *Cluster) recordEvent(involved object, eventType, reason, message string) {
+	now := c.now.Format(time.RFC3339)
+	for _, e := range c.list(lookupKind("Event"), involved.namespace(), nil) {
+		if e.str("involvedObject", "uid") == involved.str("metadata", "uid") &&
+			e.str("reason") == reason && e.str("message") == message {
+			e.set(float64(e.num(1, "count")+1), "count")
+			e.set(now, "lastTimestamp")
+			return
+		}
+	}
+
+	c.create(object{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata": map[string]interface{}{
+			"name":      fmt.Sprintf("%s.%08d", involved.name(), c.seq+1),
+			"namespace": involved.namespace(),
+		},
+		"involvedObject": map[string]interface{}{
+			"kind":      involved.kind(),
+			"namespace": involved.namespace(),
+			"name":      involved.name(),
+			"uid":       involved.str("metadata", "uid"),
+		},
+		"type":           eventType,
+		"reason":         reason,
+		"message":        message,
+		"count":          float64(1),
+		"firstTimestamp": now,
+		"lastTimestamp":  now,
+		"source":         map[string]interface{}{"component": "sim"},
+	})
+}
+
+// matchFields flags if the object satisfies the field selector e.g.
+// involvedObject.name=minio-0,type!=Normal
+func matchFields(o object, sel selector) bool {
+	fields := map[string]string{}
+	for _, r := range sel {
+		if v := o.get(strings.Split(r.key, ".")...); v != nil {
+			fields[r.key] = fmt.Sprint(v)
+		}
+	}
+	return sel.matches(fields)
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetLogs sets the logs that are returned by 'kubectl logs' for the pod
+func (c *Cluster) SetLogs(namespace, pod, logs string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.logs[namespace+"/"+pod] = logs
+}
+
+// runLogs prints the logs of a pod. The containers of this cluster never
+// restart & hence have no previous logs.
+func (c *Cluster) runLogs(cmd command, out *bytes.Buffer) (err error) {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: expected 'logs POD_NAME'")
+	}
+
+	name := strings.TrimPrefix(strings.TrimPrefix(cmd.args[0], "pods/"), "pod/")
+	k := lookupKind("pod")
+	p, ok := c.objects[c.key(k, cmd.namespace, name)]
+	if !ok {
+		return notFound(k, name)
+	}
+
+	if cmd.previous {
+		container := ""
+		if cts := p.list("spec", "containers"); len(cts) != 0 {
+			container = object(toMap(cts[0])).str("name")
+		}
+		return fmt.Errorf("Error from server (BadRequest): previous terminated container \"%s\" in pod \"%s\" not found", container, name)
+	}
+
+	out.WriteString(c.logs[cmd.namespace+"/"+name])
+	return
+}
+
+// runExec runs a command inside the container of a running pod. The pods of
+// this cluster support reading & writing files only i.e. 'cat FILE' &
+// 'sh -c "cat > \"$0\"" FILE' with the content as stdin.
+//
+// NOTE:
+//  A file written to a path mounted from a claim outlives the pod similar to
+// the data of a persistent volume. Other files are lost with the pod.
+func (c *Cluster) runExec(cmd command, out *bytes.Buffer) (err error) {
+	if len(cmd.args) != 1 || len(cmd.command) == 0 {
+		return fmt.Errorf("error: expected 'exec POD_NAME -- COMMAND [args...]'")
+	}
+
+	name := strings.TrimPrefix(strings.TrimPrefix(cmd.args[0], "pods/"), "pod/")
+	k := lookupKind("pod")
+	p, ok := c.objects[c.key(k, cmd.namespace, name)]
+	if !ok {
+		return notFound(k, name)
+	}
+	if phase := p.str("status", "phase"); phase != "Running" {
+		return fmt.Errorf("error: cannot exec into a container in a completed pod; current phase is %s", phase)
+	}
+
+	args := cmd.command
+	switch {
+	case len(args) == 2 && args[0] == "cat":
+		data, ok := c.volumes[c.volumePath(p, cmd.container, args[1])]
+		if !ok {
+			return fmt.Errorf("cat: %s: No such file or directory\ncommand terminated with exit code 1", args[1])
+		}
+		out.WriteString(data)
+	case len(args) == 4 && args[0] == "sh" && args[1] == "-c" && args[2] == `cat > "$0"`:
+		c.volumes[c.volumePath(p, cmd.container, args[3])] = string(cmd.stdin)
+	default:
+		return fmt.Errorf("error: command '%s' is not supported by the simulated pod", strings.Join(args, " "))
+	}
+	return
+}
+
+// volumePath returns the key of the file at the path inside the container of
+// the pod. The path is keyed by its claim if it is mounted from one.
+func (c *Cluster) volumePath(p object, container, path string) string {
+	claims := map[string]string{}
+	for _, v := range p.list("spec", "volumes") {
+		v := object(toMap(v))
+		if claim := v.str("persistentVolumeClaim", "claimName"); len(claim) != 0 {
+			claims[v.str("name")] = claim
+		}
+	}
+
+	for idx, ct := range p.list("spec", "containers") {
+		ct := object(toMap(ct))
+		if ct.str("name") != container && (len(container) != 0 || idx != 0) {
+			continue
+		}
+		for _, m := range ct.list("volumeMounts") {
+			m := object(toMap(m))
+			mount := strings.TrimSuffix(m.str("mountPath"), "/")
+			claim, ok := claims[m.str("name")]
+			if ok && strings.HasPrefix(path, mount+"/") {
+				return fmt.Sprintf("pvc/%s/%s%s", p.namespace(), claim, strings.TrimPrefix(path, mount))
+			}
+		}
+	}
+	return fmt.Sprintf("pod/%s/%s%s", p.namespace(), p.name(), path)
+}
+
+// runDescribe prints a summary of the objects along with their events
+func (c *Cluster) runDescribe(cmd command, out *bytes.Buffer) (err error) {
+	k, names, err := resourceArgs(cmd.args)
+	if err != nil {
+		return
+	}
+
+	var objs []object
+	if len(names) != 0 {
+		for _, n := range names {
+			o, ok := c.objects[c.key(k, cmd.namespace, n)]
+			if !ok {
+				return notFound(k, n)
+			}
+			objs = append(objs, o)
+		}
+	} else {
+		sel, err := parseSelector(cmd.selector)
+		if err != nil {
+			return err
+		}
+		objs = c.list(k, cmd.namespace, sel)
+	}
+
+	for i, o := range objs {
+		if i != 0 {
+			fmt.Fprintln(out)
+		}
+		c.describe(o, out)
+	}
+	return
+}
+
+// describe prints the summary of the object
+func (c *Cluster) describe(o object, out *bytes.Buffer) {
+	fmt.Fprintf(out, "Name:         %s\n", o.name())
+	if len(o.namespace()) != 0 {
+		fmt.Fprintf(out, "Namespace:    %s\n", o.namespace())
+	}
+
+	var labels []string
+	for k, v := range o.labels() {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	fmt.Fprintf(out, "Labels:       %s\n", strings.Join(labels, ","))
+	fmt.Fprintf(out, "Kind:         %s\n", o.kind())
+
+	switch o.kind() {
+	case "Pod":
+		fmt.Fprintf(out, "Node:         %s\n", o.str("spec", "nodeName"))
+		fmt.Fprintf(out, "Status:       %s\n", o.str("status", "phase"))
+	case "Node":
+		fmt.Fprintf(out, "Unschedulable: %t\n", o.bool("spec", "unschedulable"))
+		fmt.Fprintln(out, "Conditions:")
+		for _, cond := range o.list("status", "conditions") {
+			cond := object(toMap(cond))
+			fmt.Fprintf(out, "  %s  %s\n", cond.str("type"), cond.str("status"))
+		}
+	}
+
+	var events []string
+	for _, e := range c.list(lookupKind("Event"), o.namespace(), nil) {
+		if e.str("involvedObject", "uid") == o.str("metadata", "uid") {
+			events = append(events, fmt.Sprintf("  %s  %s  %s", e.str("type"), e.str("reason"), e.str("message")))
+		}
+	}
+	if len(events) == 0 {
+		fmt.Fprintln(out, "Events:       <none>")
+		return
+	}
+	fmt.Fprintln(out, "Events:")
+	fmt.Fprintln(out, strings.Join(events, "\n"))
+}
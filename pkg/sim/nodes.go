@@ -0,0 +1,258 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// runDrain cordons the node & evicts its pods. An eviction that would violate
+// a pod disruption budget fails the drain since nothing changes in this
+// cluster while kubectl would retry it.
+func (c *Cluster) runDrain(cmd command, out *bytes.Buffer) (err error) {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: USAGE: drain NODE [flags]")
+	}
+
+	k := lookupKind("node")
+	node, ok := c.objects[c.key(k, "", cmd.args[0])]
+	if !ok {
+		return notFound(k, cmd.args[0])
+	}
+
+	sel, err := parseSelector(cmd.podSelector)
+	if err != nil {
+		return
+	}
+
+	// verify all the pods can be deleted before evicting any of them
+	var evict []object
+	for _, p := range c.list(lookupKind("Pod"), "", sel) {
+		if p.str("spec", "nodeName") != node.name() {
+			continue
+		}
+
+		kind, _ := p.owner()
+		switch {
+		case kind == "DaemonSet" && cmd.ignoreDaemonSets:
+			continue
+		case kind == "DaemonSet":
+			return fmt.Errorf("error: cannot delete DaemonSet-managed Pods (use --ignore-daemonsets to ignore): %s/%s", p.namespace(), p.name())
+		case len(kind) == 0 && !cmd.force:
+			return fmt.Errorf("error: cannot delete Pods not managed by ReplicationController, ReplicaSet, Job, DaemonSet or StatefulSet (use --force to override): %s/%s", p.namespace(), p.name())
+		}
+		evict = append(evict, p)
+	}
+
+	if !node.bool("spec", "unschedulable") {
+		node.set(true, "spec", "unschedulable")
+		fmt.Fprintf(out, "node/%s cordoned\n", node.name())
+	}
+
+	for _, p := range evict {
+		if pdb, blocked := c.blockingBudget(p); blocked {
+			return fmt.Errorf("error when evicting pods/\"%s\" -n \"%s\" (will retry after 5s): Cannot evict pod as it would violate the pod's disruption budget: %s", p.name(), p.namespace(), pdb)
+		}
+
+		c.delete(p)
+		fmt.Fprintf(out, "pod/%s evicted\n", p.name())
+
+		// let the controllers replace the evicted pod before the next eviction
+		c.reconcile()
+	}
+
+	fmt.Fprintf(out, "node/%s drained\n", node.name())
+	return
+}
+
+// blockingBudget returns the name of the pod disruption budget that does not
+// allow the eviction of the pod
+func (c *Cluster) blockingBudget(p object) (name string, blocked bool) {
+	for _, pdb := range c.list(lookupKind("PodDisruptionBudget"), p.namespace(), nil) {
+		match := toStringMap(pdb.dict("spec", "selector", "matchLabels"))
+		if !matchLabels(p.labels(), match) {
+			continue
+		}
+
+		expected, healthy := 0, 0
+		for _, other := range c.list(lookupKind("Pod"), p.namespace(), nil) {
+			if !matchLabels(other.labels(), match) {
+				continue
+			}
+			expected++
+			if other.str("status", "phase") == "Running" {
+				healthy++
+			}
+		}
+
+		allowed := healthy - pdb.num(0, "spec", "minAvailable")
+		if _, ok := pdb.get("spec", "maxUnavailable").(float64); ok {
+			allowed = pdb.num(0, "spec", "maxUnavailable") - (expected - healthy)
+		}
+
+		if allowed < 1 {
+			return pdb.name(), true
+		}
+	}
+	return
+}
+
+// runTaint adds, modifies or removes the taints of a node
+func (c *Cluster) runTaint(cmd command, out *bytes.Buffer) (err error) {
+	if len(cmd.args) < 3 || lookupKind(cmd.args[0]).kind != "Node" {
+		return fmt.Errorf("error: USAGE: taint nodes NODE KEY_1=VAL_1:TAINT_EFFECT_1 ... KEY_N=VAL_N:TAINT_EFFECT_N")
+	}
+
+	k := lookupKind("node")
+	node, ok := c.objects[c.key(k, "", cmd.args[1])]
+	if !ok {
+		return notFound(k, cmd.args[1])
+	}
+
+	taints := node.list("spec", "taints")
+	removed := false
+	for _, spec := range cmd.args[2:] {
+		if strings.HasSuffix(spec, "-") {
+			taints, err = removeTaint(taints, strings.TrimSuffix(spec, "-"))
+			if err != nil {
+				return
+			}
+			removed = true
+			continue
+		}
+
+		taints, err = addTaint(taints, spec, cmd.overwrite, node.name())
+		if err != nil {
+			return
+		}
+	}
+
+	if len(taints) == 0 {
+		delete(node.dict("spec"), "taints")
+	} else {
+		node.set(taints, "spec", "taints")
+	}
+
+	if removed {
+		fmt.Fprintf(out, "node/%s untainted\n", node.name())
+	} else {
+		fmt.Fprintf(out, "node/%s tainted\n", node.name())
+	}
+
+	c.reconcile()
+	return
+}
+
+// addTaint adds the taint in key=value:effect form to the taints
+func addTaint(taints []interface{}, spec string, overwrite bool, node string) ([]interface{}, error) {
+	kv := strings.SplitN(spec, ":", 2)
+	if len(kv) != 2 || len(kv[1]) == 0 {
+		return nil, fmt.Errorf("error: invalid taint spec: %s", spec)
+	}
+
+	key, value := kv[0], ""
+	if parts := strings.SplitN(kv[0], "=", 2); len(parts) == 2 {
+		key, value = parts[0], parts[1]
+	}
+
+	taint := map[string]interface{}{"key": key, "effect": kv[1]}
+	if len(value) != 0 {
+		taint["value"] = value
+	}
+
+	for i, t := range taints {
+		existing := object(toMap(t))
+		if existing.str("key") == key && existing.str("effect") == kv[1] {
+			if !overwrite {
+				return nil, fmt.Errorf("error: node %s already has %s taint(s) with same effect(s) and --overwrite is false", node, key)
+			}
+			taints[i] = taint
+			return taints, nil
+		}
+	}
+	return append(taints, taint), nil
+}
+
+// removeTaint removes the taints in key:effect or key form from the taints
+func removeTaint(taints []interface{}, spec string) ([]interface{}, error) {
+	kv := strings.SplitN(spec, ":", 2)
+
+	var kept []interface{}
+	for _, t := range taints {
+		existing := object(toMap(t))
+		if existing.str("key") == kv[0] && (len(kv) == 1 || existing.str("effect") == kv[1]) {
+			continue
+		}
+		kept = append(kept, t)
+	}
+
+	if len(kept) == len(taints) {
+		return nil, fmt.Errorf("error: taint %q not found", spec)
+	}
+	return kept, nil
+}
+
+// runLabel adds, modifies or removes the labels of an object
+func (c *Cluster) runLabel(cmd command, out *bytes.Buffer) (err error) {
+	var resource, changes []string
+	for _, a := range cmd.args {
+		if strings.Contains(a, "=") || strings.HasSuffix(a, "-") {
+			changes = append(changes, a)
+		} else {
+			resource = append(resource, a)
+		}
+	}
+
+	k, names, err := resourceArgs(resource)
+	if err != nil {
+		return
+	}
+	if len(names) != 1 || len(changes) == 0 {
+		return fmt.Errorf("error: USAGE: label TYPE NAME KEY_1=VAL_1 ... KEY_N=VAL_N")
+	}
+
+	o, ok := c.objects[c.key(k, cmd.namespace, names[0])]
+	if !ok {
+		return notFound(k, names[0])
+	}
+
+	labels := o.dict("metadata", "labels")
+	if labels == nil {
+		labels = map[string]interface{}{}
+	}
+
+	for _, change := range changes {
+		if strings.HasSuffix(change, "-") {
+			delete(labels, strings.TrimSuffix(change, "-"))
+			continue
+		}
+
+		kv := strings.SplitN(change, "=", 2)
+		if prior, ok := labels[kv[0]]; ok && prior != kv[1] && !cmd.overwrite {
+			return fmt.Errorf("error: '%s' already has a value (%v), and --overwrite is false", kv[0], prior)
+		}
+		labels[kv[0]] = kv[1]
+	}
+
+	o.set(labels, "metadata", "labels")
+	fmt.Fprintf(out, "%s/%s labeled\n", strings.ToLower(k.kind), o.name())
+
+	c.reconcile()
+	return
+}
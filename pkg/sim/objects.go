@@ -0,0 +1,387 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// object is a kubernetes object in its unstructured form i.e. as decoded
+// from its json representation
+type object map[string]interface{}
+
+// kindInfo describes a kind of kubernetes object understood by the
+// simulator
+type kindInfo struct {
+	// kind is the CamelCased singular type e.g. Deployment
+	kind string
+	// resource is the lower cased plural type e.g. deployments
+	resource string
+	// group is the api group of this kind e.g. apps
+	group string
+	// namespaced flags if objects of this kind belong to a namespace
+	namespaced bool
+	// aliases are the other names used in kubectl to refer to this kind
+	aliases []string
+}
+
+// kinds are the kinds understood by the simulator. Any other kind is
+// treated as a namespaced custom kind.
+var kinds = []kindInfo{
+	{kind: "Pod", resource: "pods", namespaced: true, aliases: []string{"po", "pod"}},
+	{kind: "Deployment", resource: "deployments", group: "apps", namespaced: true, aliases: []string{"deploy", "deployment"}},
+	{kind: "StatefulSet", resource: "statefulsets", group: "apps", namespaced: true, aliases: []string{"sts", "statefulset"}},
+	{kind: "DaemonSet", resource: "daemonsets", group: "apps", namespaced: true, aliases: []string{"ds", "daemonset"}},
+	{kind: "Job", resource: "jobs", group: "batch", namespaced: true, aliases: []string{"job"}},
+	{kind: "Service", resource: "services", namespaced: true, aliases: []string{"svc", "service"}},
+	{kind: "PersistentVolumeClaim", resource: "persistentvolumeclaims", namespaced: true, aliases: []string{"pvc", "persistentvolumeclaim"}},
+	{kind: "PersistentVolume", resource: "persistentvolumes", aliases: []string{"pv", "persistentvolume"}},
+	{kind: "StorageClass", resource: "storageclasses", group: "storage.k8s.io", aliases: []string{"sc", "storageclass"}},
+	{kind: "Node", resource: "nodes", aliases: []string{"no", "node"}},
+	{kind: "Namespace", resource: "namespaces", aliases: []string{"ns", "namespace"}},
+	{kind: "ConfigMap", resource: "configmaps", namespaced: true, aliases: []string{"cm", "configmap"}},
+	{kind: "Secret", resource: "secrets", namespaced: true, aliases: []string{"secret"}},
+	{kind: "Event", resource: "events", namespaced: true, aliases: []string{"ev", "event"}},
+	{kind: "PodDisruptionBudget", resource: "poddisruptionbudgets", group: "policy", namespaced: true, aliases: []string{"pdb", "poddisruptionbudget"}},
+	{kind: "ServiceAccount", resource: "serviceaccounts", namespaced: true, aliases: []string{"sa", "serviceaccount"}},
+	{kind: "ClusterRole", resource: "clusterroles", group: "rbac.authorization.k8s.io", aliases: []string{"clusterrole"}},
+	{kind: "ClusterRoleBinding", resource: "clusterrolebindings", group: "rbac.authorization.k8s.io", aliases: []string{"clusterrolebinding"}},
+	{kind: "CustomResourceDefinition", resource: "customresourcedefinitions", group: "apiextensions.k8s.io", aliases: []string{"crd", "crds", "customresourcedefinition"}},
+}
+
+// lookupKind returns the kind info referred to by the provided name. The
+// name may be a kind, a resource or any of the aliases of a kind.
+func lookupKind(name string) kindInfo {
+	// e.g. deployments.apps
+	n := strings.ToLower(strings.SplitN(name, ".", 2)[0])
+	for _, k := range kinds {
+		if n == strings.ToLower(k.kind) || n == k.resource {
+			return k
+		}
+		for _, a := range k.aliases {
+			if n == a {
+				return k
+			}
+		}
+	}
+
+	// unknown kinds are assumed to be namespaced custom kinds
+	resource := n
+	if !strings.HasSuffix(resource, "s") {
+		resource = resource + "s"
+	}
+	return kindInfo{kind: name, resource: resource, namespaced: true}
+}
+
+// qualified returns the resource name qualified with its group as displayed
+// by kubectl e.g. deployment.apps
+func (k kindInfo) qualified() string {
+	singular := strings.ToLower(k.kind)
+	if len(k.group) == 0 {
+		return singular
+	}
+	return singular + "." + k.group
+}
+
+// get returns the value at the provided path
+func (o object) get(path ...string) interface{} {
+	var cur interface{} = map[string]interface{}(o)
+	for _, p := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[p]
+	}
+	return cur
+}
+
+// str returns the string at the provided path
+func (o object) str(path ...string) string {
+	s, _ := o.get(path...).(string)
+	return s
+}
+
+// num returns the number at the provided path or the default if not set
+func (o object) num(def int, path ...string) int {
+	if f, ok := o.get(path...).(float64); ok {
+		return int(f)
+	}
+	return def
+}
+
+// bool returns the boolean at the provided path
+func (o object) bool(path ...string) bool {
+	b, _ := o.get(path...).(bool)
+	return b
+}
+
+// list returns the list at the provided path
+func (o object) list(path ...string) []interface{} {
+	l, _ := o.get(path...).([]interface{})
+	return l
+}
+
+// dict returns the map at the provided path
+func (o object) dict(path ...string) map[string]interface{} {
+	m, _ := o.get(path...).(map[string]interface{})
+	return m
+}
+
+// set sets the value at the provided path creating the intermediate maps if
+// required
+func (o object) set(value interface{}, path ...string) {
+	cur := map[string]interface{}(o)
+	for _, p := range path[:len(path)-1] {
+		next, ok := cur[p].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			cur[p] = next
+		}
+		cur = next
+	}
+	cur[path[len(path)-1]] = value
+}
+
+// labels returns the labels of the object
+func (o object) labels() map[string]string {
+	return toStringMap(o.dict("metadata", "labels"))
+}
+
+// name returns the name of the object
+func (o object) name() string {
+	return o.str("metadata", "name")
+}
+
+// namespace returns the namespace of the object
+func (o object) namespace() string {
+	return o.str("metadata", "namespace")
+}
+
+// kind returns the kind of the object
+func (o object) kind() string {
+	return o.str("kind")
+}
+
+// copy returns a deep copy of the object
+func (o object) copy() object {
+	data, _ := json.Marshal(o)
+	c := object{}
+	json.Unmarshal(data, &c)
+	return c
+}
+
+// owner returns the kind & name of the object's controller
+func (o object) owner() (kind, name string) {
+	for _, ref := range o.list("metadata", "ownerReferences") {
+		r, _ := ref.(map[string]interface{})
+		k, _ := r["kind"].(string)
+		n, _ := r["name"].(string)
+		return k, n
+	}
+	return
+}
+
+// toStringMap converts an unstructured map into a map of strings
+func toStringMap(m map[string]interface{}) map[string]string {
+	s := map[string]string{}
+	for k, v := range m {
+		s[k] = fmt.Sprint(v)
+	}
+	return s
+}
+
+// docSeparator splits a multi document yaml
+var docSeparator = regexp.MustCompile(`(?m)^---.*$`)
+
+// decodeManifest decodes the objects of a multi document yaml or json
+// manifest. Empty documents are skipped.
+func decodeManifest(data []byte) (objs []object, err error) {
+	for i, doc := range docSeparator.Split(string(data), -1) {
+		j, err := yaml.YAMLToJSON([]byte(doc))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode document '%d' of manifest: %s", i+1, err)
+		}
+
+		o := object{}
+		if err := json.Unmarshal(j, &o); err != nil || len(o) == 0 {
+			// an empty document e.g. a document with only comments
+			continue
+		}
+
+		if items := o.list("items"); o.kind() == "List" {
+			for _, item := range items {
+				if m, ok := item.(map[string]interface{}); ok {
+					objs = append(objs, object(m))
+				}
+			}
+			continue
+		}
+
+		if len(o.kind()) == 0 || len(o.name()) == 0 {
+			return nil, fmt.Errorf("failed to decode document '%d' of manifest: kind & metadata.name are required", i+1)
+		}
+		objs = append(objs, o)
+	}
+	return
+}
+
+// selector is a parsed label selector e.g. app=minio,tier!=db,env
+type selector []requirement
+
+// requirement is a single requirement of a label selector
+type requirement struct {
+	key    string
+	op     string
+	values []string
+}
+
+// parseSelector parses the label selector. Equality, inequality, set based
+// & existence requirements are supported.
+func parseSelector(s string) (sel selector, err error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return
+	}
+
+	for _, part := range splitSelector(s) {
+		part = strings.TrimSpace(part)
+		switch {
+		case strings.Contains(part, " notin "):
+			kv := strings.SplitN(part, " notin ", 2)
+			sel = append(sel, requirement{key: strings.TrimSpace(kv[0]), op: "notin", values: setValues(kv[1])})
+		case strings.Contains(part, " in "):
+			kv := strings.SplitN(part, " in ", 2)
+			sel = append(sel, requirement{key: strings.TrimSpace(kv[0]), op: "in", values: setValues(kv[1])})
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			sel = append(sel, requirement{key: kv[0], op: "!=", values: []string{kv[1]}})
+		case strings.Contains(part, "=="):
+			kv := strings.SplitN(part, "==", 2)
+			sel = append(sel, requirement{key: kv[0], op: "=", values: []string{kv[1]}})
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(part, "=", 2)
+			sel = append(sel, requirement{key: kv[0], op: "=", values: []string{kv[1]}})
+		case strings.HasPrefix(part, "!"):
+			sel = append(sel, requirement{key: part[1:], op: "!"})
+		case len(part) != 0:
+			sel = append(sel, requirement{key: part, op: "exists"})
+		default:
+			return nil, fmt.Errorf("invalid label selector '%s'", s)
+		}
+	}
+	return
+}
+
+// splitSelector splits the selector at the commas that are not within
+// parentheses
+func splitSelector(s string) (parts []string) {
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// setValues parses the values of a set based requirement e.g. (a, b)
+func setValues(s string) (values []string) {
+	s = strings.Trim(strings.TrimSpace(s), "()")
+	for _, v := range strings.Split(s, ",") {
+		values = append(values, strings.TrimSpace(v))
+	}
+	return
+}
+
+// matches flags if the labels satisfy all the requirements of the selector
+func (sel selector) matches(labels map[string]string) bool {
+	for _, r := range sel {
+		v, ok := labels[r.key]
+		switch r.op {
+		case "=":
+			if !ok || v != r.values[0] {
+				return false
+			}
+		case "!=":
+			if ok && v == r.values[0] {
+				return false
+			}
+		case "in":
+			if !ok || !containsString(r.values, v) {
+				return false
+			}
+		case "notin":
+			if ok && containsString(r.values, v) {
+				return false
+			}
+		case "exists":
+			if !ok {
+				return false
+			}
+		case "!":
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchLabels flags if the labels contain all the provided labels
+func matchLabels(labels, match map[string]string) bool {
+	for k, v := range match {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// containsString flags if the string is present in the list
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sortObjects sorts the objects by their namespace & name
+func sortObjects(objs []object) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		if objs[i].namespace() != objs[j].namespace() {
+			return objs[i].namespace() < objs[j].namespace()
+		}
+		return objs[i].name() < objs[j].name()
+	})
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sim
+
+import (
+	"fmt"
+)
+
+// JivaProvisionerName is the name of the provisioner of the openebs storage
+// classes
+const JivaProvisionerName = "openebs.io/provisioner-iscsi"
+
+// JivaProvisioner provisions a jiva volume i.e. a controller deployment & a
+// replica deployment whose replicas are placed on unique nodes
+func JivaProvisioner(pvc, pv, sc map[string]interface{}) ([]byte, error) {
+	name := object(pv).name()
+	replicas := object(sc).str("parameters", "openebs.io/jiva-replica-count")
+	if len(replicas) == 0 {
+		replicas = "3"
+	}
+
+	return []byte(fmt.Sprintf(`
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: %[1]s-ctrl
+  labels:
+    openebs/controller: jiva-controller
+    vsm: %[1]s
+spec:
+  replicas: 1
+  template:
+    metadata:
+      labels:
+        openebs/controller: jiva-controller
+        vsm: %[1]s
+    spec:
+      containers:
+      - name: %[1]s-ctrl-con
+        image: openebs/jiva:0.5.3
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: %[1]s-rep
+  labels:
+    openebs/replica: jiva-replica
+    vsm: %[1]s
+spec:
+  replicas: %[2]s
+  template:
+    metadata:
+      labels:
+        openebs/replica: jiva-replica
+        vsm: %[1]s
+    spec:
+      affinity:
+        podAntiAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+          - labelSelector:
+              matchLabels:
+                openebs/replica: jiva-replica
+                vsm: %[1]s
+            topologyKey: kubernetes.io/hostname
+      containers:
+      - name: %[1]s-rep-con
+        image: openebs/jiva:0.5.3
+`, name, replicas)), nil
+}
@@ -0,0 +1,795 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/ghodss/yaml"
+)
+
+// Epoch is the time of the simulated cluster when it is created. The clock
+// of the cluster moves by a second whenever an object is created.
+var Epoch = time.Date(2018, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+// Provisioner provisions the storage of a persistent volume claim. It returns
+// the manifest of the objects that make up the volume e.g. the volume's
+// controller & replica deployments. The returned objects are created in the
+// namespace of the claim.
+type Provisioner func(pvc, pv, sc map[string]interface{}) (manifest []byte, err error)
+
+// Cluster is an in-memory kubernetes cluster. It executes the kubectl
+// commands emitted by litmus against its objects & runs simple controllers
+// that reconcile deployments, statefulsets, jobs, services & persistent
+// volume claims after every change.
+//
+// Cluster is an implementation of following interfaces:
+//
+// 1/ exec.AllExecutor i.e. this can be set as the executor of kubectl
+// 2/ kubectl.KubeFactory
+type Cluster struct {
+	// mutex serializes the commands executed against this cluster
+	mutex sync.Mutex
+	// objects are the objects of this cluster keyed by resource, namespace &
+	// name
+	objects map[string]object
+	// files are the virtual files that are read instead of the actual files
+	// referred to by 'kubectl apply -f' & the like
+	files map[string][]byte
+	// provisioners provision volumes of claims based on the provisioner
+	// of their storage class
+	provisioners map[string]Provisioner
+	// now is the current time of this cluster
+	now time.Time
+	// seq is used to generate unique names, uids & addresses
+	seq int
+	// history is the list of commands executed against this cluster
+	history []string
+	// logs are the logs of the pods keyed by namespace & name
+	logs map[string]string
+	// volumes are the files written inside the pods keyed by the volume &
+	// path; see runExec
+	volumes map[string]string
+}
+
+// NewCluster returns a new in-memory cluster with the provided nodes
+func NewCluster(nodes ...string) *Cluster {
+	c := &Cluster{
+		objects:      map[string]object{},
+		files:        map[string][]byte{},
+		provisioners: map[string]Provisioner{},
+		logs:         map[string]string{},
+		volumes:      map[string]string{},
+		now:          Epoch,
+	}
+
+	for _, n := range nodes {
+		c.AddNode(n, nil)
+	}
+	return c
+}
+
+// AddNode adds a schedulable node with the provided labels to the cluster
+func (c *Cluster) AddNode(name string, labels map[string]string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	l := map[string]interface{}{"kubernetes.io/hostname": name}
+	for k, v := range labels {
+		l[k] = v
+	}
+
+	c.create(object{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata":   map[string]interface{}{"name": name, "labels": l},
+		"spec":       map[string]interface{}{},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+		},
+	})
+	c.reconcile()
+}
+
+// AddFile adds a virtual file to the cluster. The content of this file is
+// used whenever a kubectl command refers to the provided path.
+func (c *Cluster) AddFile(path string, data []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.files[path] = data
+}
+
+// AddProvisioner registers the provisioner that is used for claims whose
+// storage class refers to the provided provisioner name
+func (c *Cluster) AddProvisioner(name string, p Provisioner) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.provisioners[name] = p
+}
+
+// Apply creates or updates the objects of the manifest. Objects that do not
+// specify a namespace are created in the provided namespace.
+func (c *Cluster) Apply(manifest []byte, namespace string) (err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	_, err = c.apply(manifest, namespace)
+	return
+}
+
+// Get returns a copy of the object with the provided kind, namespace & name.
+// The kind may be any of the names understood by kubectl e.g. po, pods, pod.
+func (c *Cluster) Get(kind, namespace, name string) (obj map[string]interface{}, found bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	o, found := c.objects[c.key(lookupKind(kind), namespace, name)]
+	if !found {
+		return
+	}
+	return o.copy(), true
+}
+
+// List returns copies of the objects of the provided kind in the provided
+// namespace that match the label selector. All namespaces are considered if
+// namespace is empty.
+func (c *Cluster) List(kind, namespace, labels string) (objs []map[string]interface{}, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	sel, err := parseSelector(labels)
+	if err != nil {
+		return
+	}
+
+	for _, o := range c.list(lookupKind(kind), namespace, sel) {
+		objs = append(objs, o.copy())
+	}
+	return
+}
+
+// History returns the kubectl commands executed against this cluster
+func (c *Cluster) History() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]string{}, c.history...)
+}
+
+// NewInstance returns a kubectl instance that executes its commands against
+// this cluster
+func (c *Cluster) NewInstance(namespace string) kubectl.KubeAllRunner {
+	return kubectl.New().Namespace(namespace).Executor(c)
+}
+
+// Execute executes the kubectl command against this cluster
+func (c *Cluster) Execute(args []string) (output string, err error) {
+	return c.StdinExecuteContext(context.Background(), args, nil)
+}
+
+// StdinExecute executes the kubectl command with the provided stdin
+// against this cluster
+func (c *Cluster) StdinExecute(args []string, stdin []byte) (output string, err error) {
+	return c.StdinExecuteContext(context.Background(), args, stdin)
+}
+
+// ExecuteContext executes the kubectl command against this cluster
+func (c *Cluster) ExecuteContext(ctx context.Context, args []string) (output string, err error) {
+	return c.StdinExecuteContext(ctx, args, nil)
+}
+
+// StdinExecuteContext executes the kubectl command with the provided stdin
+// against this cluster
+func (c *Cluster) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (output string, err error) {
+	if err = ctx.Err(); err != nil {
+		return "", c.failure(args, "", err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.history = append(c.history, strings.Join(args, " "))
+
+	cmd, err := parseCommand(args)
+	if err != nil {
+		return "", c.failure(args, err.Error(), nil)
+	}
+	cmd.stdin = stdin
+
+	var out bytes.Buffer
+	err = c.run(cmd, &out)
+	if err != nil {
+		return "", c.failure(args, err.Error(), nil)
+	}
+
+	output = strings.TrimSpace(out.String())
+	return
+}
+
+// failure returns the error of a failed kubectl command similar to the ones
+// returned by the shell executor
+func (c *Cluster) failure(args []string, stderr string, err error) error {
+	exitCode := 1
+	if err == nil {
+		err = errors.New("exit status 1")
+	} else {
+		exitCode = exec.UnknownExitCode
+	}
+
+	return &exec.ExecError{
+		Args:     append([]string{kubectl.KubectlPath}, args...),
+		ExitCode: exitCode,
+		Stderr:   stderr,
+		Err:      err,
+	}
+}
+
+// command is a parsed kubectl command
+type command struct {
+	// verb of the command e.g. get, apply
+	verb string
+	// args are the positional arguments that follow the verb
+	args []string
+	// namespace set via --namespace
+	namespace string
+	// allNamespaces is set via --all-namespaces
+	allNamespaces bool
+	// selector set via --selector
+	selector string
+	// files set via --filename
+	files []string
+	// output set via --output
+	output string
+	// all is set via --all
+	all bool
+	// ignoreNotFound is set via --ignore-not-found
+	ignoreNotFound bool
+	// overwrite is set via --overwrite
+	overwrite bool
+	// force is set via --force
+	force bool
+	// ignoreDaemonSets is set via --ignore-daemonsets
+	ignoreDaemonSets bool
+	// podSelector set via --pod-selector
+	podSelector string
+	// fieldSelector set via --field-selector
+	fieldSelector string
+	// previous is set via --previous
+	previous bool
+	// container set via --container
+	container string
+	// command is the command that follows '--' e.g. of kubectl exec
+	command []string
+	// stdin of the command
+	stdin []byte
+}
+
+// flagsWithValue are the flags that are followed by a value when not set in
+// the --flag=value form
+var flagsWithValue = map[string]string{
+	"-n": "namespace", "--namespace": "namespace",
+	"-l": "selector", "--selector": "selector",
+	"-f": "filename", "--filename": "filename",
+	"-o": "output", "--output": "output",
+	"--context": "context", "--kubeconfig": "kubeconfig",
+	"--pod-selector": "pod-selector", "--field-selector": "field-selector",
+	"-c": "container", "--container": "container",
+}
+
+// parseCommand parses the kubectl arguments
+func parseCommand(args []string) (cmd command, err error) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			cmd.command = args[i+1:]
+			break
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			if len(cmd.verb) == 0 {
+				cmd.verb = a
+			} else {
+				cmd.args = append(cmd.args, a)
+			}
+			continue
+		}
+
+		name, value := a, ""
+		hasValue := false
+		if kv := strings.SplitN(a, "=", 2); len(kv) == 2 {
+			name, value, hasValue = kv[0], kv[1], true
+		}
+
+		flag, ok := flagsWithValue[name]
+		if ok && !hasValue {
+			if i+1 >= len(args) {
+				err = fmt.Errorf("error: flag needs an argument: '%s'", name)
+				return
+			}
+			i++
+			value = args[i]
+		}
+
+		switch {
+		case flag == "namespace":
+			cmd.namespace = value
+		case flag == "selector":
+			cmd.selector = value
+		case flag == "filename":
+			cmd.files = append(cmd.files, value)
+		case flag == "output":
+			cmd.output = value
+		case name == "-A" || name == "--all-namespaces":
+			cmd.allNamespaces = true
+		case name == "--all":
+			cmd.all = true
+		case flag == "pod-selector":
+			cmd.podSelector = value
+		case flag == "field-selector":
+			cmd.fieldSelector = value
+		case flag == "container":
+			cmd.container = value
+		case name == "--ignore-not-found":
+			cmd.ignoreNotFound = value != "false"
+		case name == "--overwrite":
+			cmd.overwrite = value != "false"
+		case name == "--force":
+			cmd.force = value != "false"
+		case name == "--ignore-daemonsets":
+			cmd.ignoreDaemonSets = value != "false"
+		case name == "-p" || name == "--previous":
+			cmd.previous = value != "false"
+		}
+	}
+
+	if len(cmd.verb) == 0 {
+		err = fmt.Errorf("error: kubectl command is missing")
+	}
+	if len(cmd.namespace) == 0 {
+		cmd.namespace = "default"
+	}
+	return
+}
+
+// run runs the parsed kubectl command
+func (c *Cluster) run(cmd command, out *bytes.Buffer) (err error) {
+	switch cmd.verb {
+	case "get":
+		return c.runGet(cmd, out)
+	case "apply", "create":
+		return c.runApply(cmd, out)
+	case "delete":
+		return c.runDelete(cmd, out)
+	case "cordon", "uncordon":
+		return c.runCordon(cmd, out)
+	case "drain":
+		return c.runDrain(cmd, out)
+	case "taint":
+		return c.runTaint(cmd, out)
+	case "label":
+		return c.runLabel(cmd, out)
+	case "describe":
+		return c.runDescribe(cmd, out)
+	case "logs":
+		return c.runLogs(cmd, out)
+	case "exec":
+		return c.runExec(cmd, out)
+	default:
+		return fmt.Errorf("error: unknown command \"%s\" for \"kubectl\"", cmd.verb)
+	}
+}
+
+// resourceArgs returns the kind & names referred to by the positional
+// arguments e.g. 'pods a b' or 'pod/a'
+func resourceArgs(args []string) (k kindInfo, names []string, err error) {
+	if len(args) == 0 {
+		err = fmt.Errorf("error: you must specify the type of resource")
+		return
+	}
+
+	if kn := strings.SplitN(args[0], "/", 2); len(kn) == 2 {
+		return lookupKind(kn[0]), append([]string{kn[1]}, args[1:]...), nil
+	}
+	return lookupKind(args[0]), args[1:], nil
+}
+
+// notFound returns the error reported by kubectl for a missing object
+func notFound(k kindInfo, name string) error {
+	resource := k.resource
+	if len(k.group) != 0 {
+		resource = resource + "." + k.group
+	}
+	return fmt.Errorf("Error from server (NotFound): %s \"%s\" not found", resource, name)
+}
+
+func (c *Cluster) runGet(cmd command, out *bytes.Buffer) (err error) {
+	if len(cmd.files) != 0 {
+		return c.getFiles(cmd, out)
+	}
+
+	k, names, err := resourceArgs(cmd.args)
+	if err != nil {
+		return
+	}
+
+	namespace := cmd.namespace
+	if cmd.allNamespaces {
+		namespace = ""
+	}
+
+	var objs []object
+	if len(names) != 0 {
+		for _, n := range names {
+			o, ok := c.objects[c.key(k, namespace, n)]
+			if !ok {
+				return notFound(k, n)
+			}
+			objs = append(objs, o)
+		}
+	} else {
+		sel, err := parseSelector(cmd.selector)
+		if err != nil {
+			return err
+		}
+		fields, err := parseSelector(cmd.fieldSelector)
+		if err != nil {
+			return err
+		}
+		for _, o := range c.list(k, namespace, sel) {
+			if matchFields(o, fields) {
+				objs = append(objs, o)
+			}
+		}
+	}
+
+	switch cmd.output {
+	case "json", "yaml":
+		var v interface{}
+		if len(names) == 1 {
+			v = objs[0]
+		} else {
+			items := []interface{}{}
+			for _, o := range objs {
+				items = append(items, o)
+			}
+			v = map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items, "metadata": map[string]interface{}{}}
+		}
+
+		data, _ := json.MarshalIndent(v, "", "    ")
+		if cmd.output == "yaml" {
+			data, _ = yaml.JSONToYAML(data)
+		}
+		out.Write(data)
+	case "name":
+		for _, o := range objs {
+			fmt.Fprintf(out, "%s/%s\n", k.qualified(), o.name())
+		}
+	default:
+		if len(objs) == 0 {
+			fmt.Fprintln(out, "No resources found.")
+			return
+		}
+		fmt.Fprintln(out, "NAME")
+		for _, o := range objs {
+			fmt.Fprintln(out, o.name())
+		}
+	}
+	return
+}
+
+// read returns the contents of the file. Virtual files take precedence over
+// the actual files & '-' refers to the stdin.
+func (c *Cluster) read(file string, stdin []byte) ([]byte, error) {
+	if file == "-" {
+		return stdin, nil
+	}
+	if data, ok := c.files[file]; ok {
+		return data, nil
+	}
+	return ioutil.ReadFile(file)
+}
+
+func (c *Cluster) runApply(cmd command, out *bytes.Buffer) (err error) {
+	if len(cmd.files) == 0 && cmd.verb == "create" && len(cmd.args) == 2 && lookupKind(cmd.args[0]).kind == "Namespace" {
+		return c.createNamespace(cmd.args[1], out)
+	}
+	if len(cmd.files) == 0 {
+		return fmt.Errorf("error: must specify one of -f and -k")
+	}
+
+	for _, f := range cmd.files {
+		data, err := c.read(f, cmd.stdin)
+		if err != nil {
+			return fmt.Errorf("error: the path \"%s\" cannot be read: %s", f, err)
+		}
+
+		applied, err := c.apply(data, cmd.namespace)
+		for _, line := range applied {
+			fmt.Fprintln(out, line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return
+}
+
+// getFiles prints the names of the objects of the manifests. Only the name
+// output is supported.
+func (c *Cluster) getFiles(cmd command, out *bytes.Buffer) (err error) {
+	if cmd.output != "name" {
+		return fmt.Errorf("error: output '%s' is not supported for manifests", cmd.output)
+	}
+
+	for _, f := range cmd.files {
+		data, err := c.read(f, cmd.stdin)
+		if err != nil {
+			return fmt.Errorf("error: the path \"%s\" cannot be read: %s", f, err)
+		}
+		objs, err := decodeManifest(data)
+		if err != nil {
+			return err
+		}
+
+		for _, o := range objs {
+			k := lookupKind(o.kind())
+			namespace := o.namespace()
+			if len(namespace) == 0 {
+				namespace = cmd.namespace
+			}
+			if _, ok := c.objects[c.key(k, namespace, o.name())]; !ok {
+				if cmd.ignoreNotFound {
+					continue
+				}
+				return notFound(k, o.name())
+			}
+			fmt.Fprintf(out, "%s/%s\n", k.qualified(), o.name())
+		}
+	}
+	return
+}
+
+// createNamespace creates the namespace unless it exists
+func (c *Cluster) createNamespace(name string, out *bytes.Buffer) (err error) {
+	k := lookupKind("namespace")
+	if _, ok := c.objects[c.key(k, "", name)]; ok {
+		return fmt.Errorf("Error from server (AlreadyExists): namespaces \"%s\" already exists", name)
+	}
+
+	c.create(object{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]interface{}{"name": name},
+	})
+	fmt.Fprintf(out, "namespace/%s created\n", name)
+	return
+}
+
+// apply creates or updates the objects of the manifest & returns the kubectl
+// like description of each applied object
+func (c *Cluster) apply(manifest []byte, namespace string) (applied []string, err error) {
+	objs, err := decodeManifest(manifest)
+	if err != nil {
+		return
+	}
+
+	for _, o := range objs {
+		k := lookupKind(o.kind())
+		if k.namespaced {
+			if len(o.namespace()) == 0 {
+				o.set(namespace, "metadata", "namespace")
+			}
+		} else {
+			delete(o.dict("metadata"), "namespace")
+		}
+
+		existing, ok := c.objects[c.key(k, o.namespace(), o.name())]
+		if !ok {
+			c.create(o)
+			applied = append(applied, fmt.Sprintf("%s/%s created", k.qualified(), o.name()))
+			continue
+		}
+
+		// retain the server managed fields
+		for _, f := range []string{"uid", "creationTimestamp", "ownerReferences"} {
+			if v := existing.get("metadata", f); v != nil {
+				o.set(v, "metadata", f)
+			}
+		}
+		if status := existing.get("status"); status != nil {
+			o["status"] = status
+		}
+		if ip := existing.str("spec", "clusterIP"); len(ip) != 0 && k.kind == "Service" {
+			o.set(ip, "spec", "clusterIP")
+		}
+		if vol := existing.str("spec", "volumeName"); len(vol) != 0 && k.kind == "PersistentVolumeClaim" {
+			o.set(vol, "spec", "volumeName")
+		}
+		if node := existing.str("spec", "nodeName"); len(node) != 0 && k.kind == "Pod" {
+			o.set(node, "spec", "nodeName")
+		}
+		c.objects[c.key(k, o.namespace(), o.name())] = o
+		applied = append(applied, fmt.Sprintf("%s/%s configured", k.qualified(), o.name()))
+	}
+
+	c.reconcile()
+	return
+}
+
+func (c *Cluster) runDelete(cmd command, out *bytes.Buffer) (err error) {
+	var targets []object
+	var missing []string
+
+	if len(cmd.files) != 0 {
+		for _, f := range cmd.files {
+			data, err := c.read(f, cmd.stdin)
+			if err != nil {
+				return fmt.Errorf("error: the path \"%s\" cannot be read: %s", f, err)
+			}
+
+			objs, err := decodeManifest(data)
+			if err != nil {
+				return err
+			}
+
+			for _, o := range objs {
+				k := lookupKind(o.kind())
+				ns := o.namespace()
+				if len(ns) == 0 {
+					ns = cmd.namespace
+				}
+
+				existing, ok := c.objects[c.key(k, ns, o.name())]
+				if !ok {
+					missing = append(missing, notFound(k, o.name()).Error())
+					continue
+				}
+				targets = append(targets, existing)
+			}
+		}
+	} else {
+		k, names, err := resourceArgs(cmd.args)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case len(names) != 0:
+			for _, n := range names {
+				o, ok := c.objects[c.key(k, cmd.namespace, n)]
+				if !ok {
+					missing = append(missing, notFound(k, n).Error())
+					continue
+				}
+				targets = append(targets, o)
+			}
+		case len(cmd.selector) != 0 || cmd.all:
+			sel, err := parseSelector(cmd.selector)
+			if err != nil {
+				return err
+			}
+			targets = c.list(k, cmd.namespace, sel)
+		default:
+			return fmt.Errorf("error: resource(s) were provided, but no name, label selector, or --all flag specified")
+		}
+	}
+
+	for _, o := range targets {
+		c.delete(o)
+		fmt.Fprintf(out, "%s \"%s\" deleted\n", lookupKind(o.kind()).qualified(), o.name())
+	}
+	c.reconcile()
+
+	if len(missing) != 0 && !cmd.ignoreNotFound {
+		return fmt.Errorf("%s", strings.Join(missing, "\n"))
+	}
+	return
+}
+
+func (c *Cluster) runCordon(cmd command, out *bytes.Buffer) (err error) {
+	if len(cmd.args) != 1 {
+		return fmt.Errorf("error: USAGE: %s NODE [flags]", cmd.verb)
+	}
+
+	k := lookupKind("node")
+	node, ok := c.objects[c.key(k, "", cmd.args[0])]
+	if !ok {
+		return notFound(k, cmd.args[0])
+	}
+
+	unschedulable := cmd.verb == "cordon"
+	if node.bool("spec", "unschedulable") == unschedulable {
+		fmt.Fprintf(out, "node/%s already %sed\n", node.name(), cmd.verb)
+		return
+	}
+
+	if unschedulable {
+		node.set(true, "spec", "unschedulable")
+	} else {
+		delete(node.dict("spec"), "unschedulable")
+	}
+	fmt.Fprintf(out, "node/%s %sed\n", node.name(), cmd.verb)
+
+	c.reconcile()
+	return
+}
+
+// key returns the key of the object in the store
+func (c *Cluster) key(k kindInfo, namespace, name string) string {
+	if !k.namespaced {
+		namespace = ""
+	}
+	return k.resource + "/" + namespace + "/" + name
+}
+
+// list returns the objects of the kind in the namespace that match the
+// selector. All namespaces are considered if namespace is empty.
+func (c *Cluster) list(k kindInfo, namespace string, sel selector) (objs []object) {
+	for _, o := range c.objects {
+		if lookupKind(o.kind()).resource != k.resource {
+			continue
+		}
+		if k.namespaced && len(namespace) != 0 && o.namespace() != namespace {
+			continue
+		}
+		if !sel.matches(o.labels()) {
+			continue
+		}
+		objs = append(objs, o)
+	}
+	sortObjects(objs)
+	return
+}
+
+// create adds the object to the store after setting its server managed
+// fields
+func (c *Cluster) create(o object) {
+	c.seq++
+	c.now = c.now.Add(time.Second)
+
+	o.set(fmt.Sprintf("%08d-0000-4000-8000-000000000000", c.seq), "metadata", "uid")
+	o.set(c.now.Format(time.RFC3339), "metadata", "creationTimestamp")
+	c.objects[c.key(lookupKind(o.kind()), o.namespace(), o.name())] = o
+}
+
+// delete removes the object from the store
+func (c *Cluster) delete(o object) {
+	delete(c.objects, c.key(lookupKind(o.kind()), o.namespace(), o.name()))
+
+	switch o.kind() {
+	case "Namespace":
+		// delete everything within the namespace
+		for _, other := range c.objects {
+			if lookupKind(other.kind()).namespaced && other.namespace() == o.name() {
+				delete(c.objects, c.key(lookupKind(other.kind()), other.namespace(), other.name()))
+			}
+		}
+	case "PersistentVolumeClaim":
+		c.releaseVolume(o)
+	}
+}
@@ -0,0 +1,1088 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sim
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/verify"
+)
+
+// root is the path to the repository root from this package
+const root = "../.."
+
+// readFile returns the contents of the file relative to the repository root
+func readFile(t *testing.T, path string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		t.Fatalf("failed to read file: expected 'no error': actual '%s'", err)
+	}
+	return data
+}
+
+// installFiles writes the install files embedded in the config maps of the
+// provided manifest into dir & returns them keyed by the config map name
+func installFiles(t *testing.T, dir, manifest string) map[string]meta.InstallFile {
+	objs, err := decodeManifest(readFile(t, manifest))
+	if err != nil {
+		t.Fatalf("failed to decode '%s': expected 'no error': actual '%s'", manifest, err)
+	}
+
+	files := map[string]meta.InstallFile{}
+	for _, o := range objs {
+		if o.kind() != "ConfigMap" {
+			continue
+		}
+
+		path := filepath.Join(dir, o.name()+".yaml")
+		if err := ioutil.WriteFile(path, []byte(o.str("data", "config")), 0644); err != nil {
+			t.Fatalf("failed to write install file: expected 'no error': actual '%s'", err)
+		}
+		files[o.name()] = meta.InstallFile(path)
+	}
+	return files
+}
+
+// newOpenEBSCluster returns a cluster of the provided nodes with openebs
+// operator & its storage classes installed
+func newOpenEBSCluster(t *testing.T, nodes ...string) *Cluster {
+	c := NewCluster(nodes...)
+	c.AddProvisioner(JivaProvisionerName, JivaProvisioner)
+
+	for _, f := range []string{"tests/openebs/openebs-operator-v0.5.3.yaml", "tests/openebs/openebs-storage-classes-v0.5.3.yaml"} {
+		if err := c.Apply(readFile(t, f), "default"); err != nil {
+			t.Fatalf("failed to apply '%s': expected 'no error': actual '%s'", f, err)
+		}
+	}
+	return c
+}
+
+// useCluster makes kubectl execute its commands against the provided cluster
+// till the returned function is invoked; node changes recorded in the default
+// ledger are restored & the tracked objects are deleted before kubectl is
+// reset
+func useCluster(c *Cluster) func() {
+	kubectl.SetDefaultExecutor(c)
+	return func() {
+		kubectl.RestoreNodes(kubectl.New())
+		kubectl.TeardownResources(kubectl.New())
+		kubectl.SetDefaultExecutor(nil)
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := map[string]struct {
+		selector string
+		labels   map[string]string
+		matches  bool
+	}{
+		"parse selector - empty selector matches everything": {
+			selector: "",
+			labels:   map[string]string{"app": "minio"},
+			matches:  true,
+		},
+		"parse selector - equality": {
+			selector: "app=minio,tier==storage",
+			labels:   map[string]string{"app": "minio", "tier": "storage"},
+			matches:  true,
+		},
+		"parse selector - inequality": {
+			selector: "app!=minio",
+			labels:   map[string]string{"app": "minio"},
+			matches:  false,
+		},
+		"parse selector - set based": {
+			selector: "app in (minio, mysql),env notin (prod)",
+			labels:   map[string]string{"app": "mysql", "env": "ci"},
+			matches:  true,
+		},
+		"parse selector - existence": {
+			selector: "app,!env",
+			labels:   map[string]string{"app": "mysql", "env": "ci"},
+			matches:  false,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			sel, err := parseSelector(mock.selector)
+			if err != nil {
+				t.Fatalf("failed to parse selector: expected 'no error': actual '%s'", err)
+			}
+
+			if sel.matches(mock.labels) != mock.matches {
+				t.Fatalf("failed to match selector '%s': expected '%t': actual '%t'", mock.selector, mock.matches, !mock.matches)
+			}
+		})
+	}
+}
+
+func TestClusterCommands(t *testing.T) {
+	c := NewCluster("node-1", "node-2")
+	defer useCluster(c)()
+	k := c.NewInstance("litmus")
+
+	c.AddFile("/etc/e2e/app.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: nginx
+        image: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+`))
+
+	op, err := k.Run([]string{"apply", "-f", "/etc/e2e/app.yaml"})
+	if err != nil || op != "deployment.apps/web created\nservice/web created" {
+		t.Fatalf("failed to apply: expected 'created': actual '%s' '%v'", op, err)
+	}
+
+	pods, err := kubectl.GetRunningPods(kubectl.New().Labels("app=web"))
+	if err != nil || len(pods) != 2 {
+		t.Fatalf("failed to get running pods: expected '2 pods': actual '%v' '%v'", pods, err)
+	}
+
+	nodes, err := kubectl.GetPodNodes(kubectl.New().Labels("app=web"))
+	if err != nil || nodes[0] == nodes[1] {
+		t.Fatalf("failed to spread pods: expected 'unique nodes': actual '%v' '%v'", nodes, err)
+	}
+
+	ip, err := kubectl.GetServiceIP(k, "web")
+	if err != nil || len(ip) == 0 {
+		t.Fatalf("failed to get service ip: expected 'an ip': actual '%s' '%v'", ip, err)
+	}
+
+	_, err = k.Run([]string{"get", "pods", "web-0"})
+	if !kubectl.IsNotFound(err) {
+		t.Fatalf("failed to get missing pod: expected 'not found error': actual '%v'", err)
+	}
+
+	// cordon both the nodes; new pods can not be scheduled
+	kubectl.CordonNodeWithPod(k, pods[0])
+	kubectl.CordonNodeWithPod(k, pods[1])
+	kubectl.DeletePod(k, pods[0])
+
+	yes, err := kubectl.ArePodsRunning(kubectl.New().Labels("app=web"))
+	if yes || err == nil || !strings.Contains(err.Error(), "'Pending' phase") {
+		t.Fatalf("failed to verify pods running: expected 'pending pod': actual '%t' '%v'", yes, err)
+	}
+
+	err = kubectl.RestoreNodes(kubectl.New())
+	if err != nil {
+		t.Fatalf("failed to restore nodes: expected 'no error': actual '%s'", err)
+	}
+
+	yes, err = kubectl.ArePodsRunning(kubectl.New().Labels("app=web"))
+	if !yes || err != nil {
+		t.Fatalf("failed to verify pods running after uncordon: expected 'running': actual '%t' '%v'", yes, err)
+	}
+
+	_, err = k.Run([]string{"delete", "-f", "/etc/e2e/app.yaml"})
+	if err != nil {
+		t.Fatalf("failed to delete: expected 'no error': actual '%s'", err)
+	}
+
+	op, err = k.Run([]string{"get", "pods"})
+	if err != nil || op != "No resources found." {
+		t.Fatalf("failed to delete pods of deployment: expected 'no pods': actual '%s' '%v'", op, err)
+	}
+}
+
+// TestExecInPod writes & reads files inside the pods of the simulated cluster
+// & verifies the files on a claim outlive the pod
+func TestExecInPod(t *testing.T) {
+	c := newOpenEBSCluster(t, "node-1", "node-2")
+	defer useCluster(c)()
+	k := c.NewInstance("litmus")
+
+	c.AddFile("/etc/e2e/app.yaml", []byte(`
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+spec:
+  storageClassName: openebs-standalone
+  accessModes:
+  - ReadWriteOnce
+  resources:
+    requests:
+      storage: 1G
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: nginx
+        image: nginx
+        volumeMounts:
+        - name: data
+          mountPath: /data
+      volumes:
+      - name: data
+        persistentVolumeClaim:
+          claimName: data
+`))
+
+	if _, err := k.Run([]string{"apply", "-f", "/etc/e2e/app.yaml"}); err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+
+	pods, err := kubectl.GetRunningPods(kubectl.New().Labels("app=web"))
+	if err != nil || len(pods) != 1 {
+		t.Fatalf("failed to get running pods: expected '1 pod': actual '%v' '%v'", pods, err)
+	}
+
+	for _, path := range []string{"/data/a.txt", "/tmp/b.txt"} {
+		_, err = kubectl.ExecInPod(k, pods[0], "nginx", []string{"sh", "-c", `cat > "$0"`, path}, []byte("hello"))
+		if err != nil {
+			t.Fatalf("failed to write '%s': expected 'no error': actual '%s'", path, err)
+		}
+		r, err := kubectl.ExecInPod(k, pods[0], "", []string{"cat", path}, nil)
+		if err != nil || r.Stdout != "hello" {
+			t.Fatalf("failed to read '%s': expected 'hello': actual '%s' '%v'", path, r.Stdout, err)
+		}
+	}
+
+	r, err := kubectl.ExecInPod(k, pods[0], "", []string{"ls", "/data"}, nil)
+	if err == nil || r.ExitCode == 0 {
+		t.Fatalf("failed to exec unsupported command: expected 'error': actual '%v'", r)
+	}
+
+	// the replacement pod sees the file on the claim only
+	kubectl.DeletePod(k, pods[0])
+	pods, err = kubectl.GetRunningPods(kubectl.New().Labels("app=web"))
+	if err != nil || len(pods) != 1 {
+		t.Fatalf("failed to get replaced pod: expected '1 pod': actual '%v' '%v'", pods, err)
+	}
+
+	r, err = kubectl.ExecInPod(k, pods[0], "", []string{"cat", "/data/a.txt"}, nil)
+	if err != nil || r.Stdout != "hello" {
+		t.Fatalf("failed to read file on claim: expected 'hello': actual '%s' '%v'", r.Stdout, err)
+	}
+	r, err = kubectl.ExecInPod(k, pods[0], "", []string{"cat", "/tmp/b.txt"}, nil)
+	if err == nil || !strings.Contains(r.Stderr, "No such file or directory") {
+		t.Fatalf("failed to read file of deleted pod: expected 'no such file': actual '%v' '%v'", r, err)
+	}
+}
+
+// TestDrainAndRestoreNodes drains, taints & labels the nodes of the simulated
+// cluster & verifies the ledger puts back only what it changed
+func TestDrainAndRestoreNodes(t *testing.T) {
+	c := NewCluster("node-1", "node-2", "node-3", "node-4")
+	defer useCluster(c)()
+	k := c.NewInstance("litmus")
+	ledger := kubectl.NewNodeLedger()
+
+	budget := `
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+spec:
+  minAvailable: %d
+  selector:
+    matchLabels:
+      app: web
+`
+	c.AddFile("/etc/e2e/app.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: nginx
+        image: nginx
+---`+fmt.Sprintf(budget, 2)))
+
+	if _, err := k.Run([]string{"apply", "-f", "/etc/e2e/app.yaml"}); err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+
+	nodes, err := kubectl.GetPodNodes(kubectl.New().Namespace("litmus").Labels("app=web"))
+	if err != nil || len(nodes) != 2 {
+		t.Fatalf("failed to get pod nodes: expected '2 nodes': actual '%v' '%v'", nodes, err)
+	}
+	drained := nodes[0]
+
+	// a node cordoned by the admin stays cordoned after the restore
+	var admin string
+	for _, n := range []string{"node-1", "node-2", "node-3", "node-4"} {
+		if n != nodes[0] && n != nodes[1] {
+			admin = n
+			break
+		}
+	}
+	if _, err = k.Run([]string{"cordon", admin}); err != nil {
+		t.Fatalf("failed to cordon '%s': expected 'no error': actual '%s'", admin, err)
+	}
+	if err = ledger.Cordon(k, admin); err != nil {
+		t.Fatalf("failed to cordon '%s': expected 'no error': actual '%s'", admin, err)
+	}
+
+	before := map[string]interface{}{}
+	for _, n := range []string{"node-1", "node-2", "node-3", "node-4"} {
+		before[n], _ = c.Get("Node", "", n)
+	}
+
+	// the disruption budget blocks the drain after cordoning the node
+	err = ledger.Drain(k, drained, kubectl.DrainOptions{})
+	if !kubectl.IsDisruptionBudgetViolation(err) {
+		t.Fatalf("failed to drain '%s': expected 'disruption budget violation': actual '%v'", drained, err)
+	}
+	if node, _ := c.Get("Node", "", drained); !object(node).bool("spec", "unschedulable") {
+		t.Fatalf("failed to drain '%s': expected 'cordoned node': actual '%v'", drained, node)
+	}
+
+	c.AddFile("/etc/e2e/budget.yaml", []byte(fmt.Sprintf(budget, 1)))
+	if _, err = k.Run([]string{"apply", "-f", "/etc/e2e/budget.yaml"}); err != nil {
+		t.Fatalf("failed to relax budget: expected 'no error': actual '%s'", err)
+	}
+	if err = ledger.Drain(k, drained, kubectl.DrainOptions{}); err != nil {
+		t.Fatalf("failed to drain '%s': expected 'no error': actual '%s'", drained, err)
+	}
+
+	nodes, err = kubectl.GetPodNodes(kubectl.New().Namespace("litmus").Labels("app=web"))
+	if err != nil || len(nodes) != 2 || containsString(nodes, drained) {
+		t.Fatalf("failed to move pods: expected 'pods off %s': actual '%v' '%v'", drained, nodes, err)
+	}
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"add taint", func() error {
+			return ledger.AddTaint(k, "node-1", kubectl.Taint{Key: "litmus", Value: "chaos", Effect: "NoSchedule"})
+		}},
+		{"add label", func() error { return ledger.AddLabel(k, "node-2", "litmus", "chaos") }},
+		{"change label", func() error { return ledger.AddLabel(k, "node-2", "kubernetes.io/hostname", "chaos") }},
+		{"remove label", func() error { return ledger.RemoveLabel(k, "node-3", "kubernetes.io/hostname") }},
+	}
+	for _, s := range steps {
+		if err = s.run(); err != nil {
+			t.Fatalf("failed to %s: expected 'no error': actual '%s'", s.name, err)
+		}
+	}
+
+	if err = ledger.Restore(k); err != nil {
+		t.Fatalf("failed to restore nodes: expected 'no error': actual '%s'", err)
+	}
+	if changes := ledger.Changes(); len(changes) != 0 {
+		t.Fatalf("failed to restore nodes: expected 'no pending changes': actual '%v'", changes)
+	}
+
+	for _, n := range []string{"node-1", "node-2", "node-3", "node-4"} {
+		after, _ := c.Get("Node", "", n)
+		if n != drained && !reflect.DeepEqual(before[n], after) {
+			t.Fatalf("failed to restore '%s': expected '%v': actual '%v'", n, before[n], after)
+		}
+	}
+	if node, _ := c.Get("Node", "", drained); object(node).bool("spec", "unschedulable") {
+		t.Fatalf("failed to restore '%s': expected 'schedulable node': actual '%v'", drained, node)
+	}
+}
+
+// TestComponentEvents verifies the events of the components are reported by
+// the failed verifications & the warning events condition
+func TestComponentEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sim")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := NewCluster("node-1")
+	defer useCluster(c)()
+	k := c.NewInstance("litmus")
+
+	file := filepath.Join(dir, "install.yaml")
+	err = ioutil.WriteFile(file, []byte(`
+components:
+- kind: pod
+  namespace: litmus
+  labels: app=web
+  alias: web-pod
+- kind: pod
+  namespace: litmus
+  labels: app=web
+  alias: web-pod-pending
+  allowedEventReasons:
+  - FailedScheduling
+`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write install file: expected 'no error': actual '%s'", err)
+	}
+
+	c.AddFile("/etc/e2e/app.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: nginx
+        image: nginx
+`))
+
+	// the pod can not be scheduled on a cordoned node
+	if _, err = k.Run([]string{"cordon", "node-1"}); err != nil {
+		t.Fatalf("failed to cordon: expected 'no error': actual '%s'", err)
+	}
+	if _, err = k.Run([]string{"apply", "-f", "/etc/e2e/app.yaml"}); err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+
+	v, err := verify.NewKubeInstallVerify(meta.InstallFile(file))
+	if err != nil {
+		t.Fatalf("failed to load install file: expected 'no error': actual '%s'", err)
+	}
+
+	// the events are appended only once the verification gives up
+	_, err = v.IsRunning()
+	if err == nil || strings.Contains(err.Error(), "recent events") {
+		t.Fatalf("failed to verify running: expected 'error without events': actual '%v'", err)
+	}
+	err = verify.WithRecentEvents(err)
+	if err == nil || !strings.Contains(err.Error(), "recent events:\n  Warning FailedScheduling pod/web-") {
+		t.Fatalf("failed to verify running: expected 'error with recent events': actual '%v'", err)
+	}
+
+	_, err = v.IsCondition("web-pod", verify.RunningCond)
+	err = verify.WithRecentEvents(err)
+	if err == nil || !strings.Contains(err.Error(), "Warning FailedScheduling pod/web-") {
+		t.Fatalf("failed to verify running condition: expected 'error with recent events': actual '%v'", err)
+	}
+
+	_, err = v.IsCondition("web-pod", verify.NoWarningEventsCond)
+	if err == nil || !strings.Contains(err.Error(), "warning events found for alias 'web-pod'") {
+		t.Fatalf("failed to verify no warning events: expected 'warning events': actual '%v'", err)
+	}
+
+	yes, err := v.IsCondition("web-pod-pending", verify.NoWarningEventsCond)
+	if !yes || err != nil {
+		t.Fatalf("failed to verify no warning events: expected 'allowed warning events': actual '%t' '%v'", yes, err)
+	}
+
+	if _, err = k.Run([]string{"uncordon", "node-1"}); err != nil {
+		t.Fatalf("failed to uncordon: expected 'no error': actual '%s'", err)
+	}
+
+	pods, err := kubectl.GetRunningPods(kubectl.New().Namespace("litmus").Labels("app=web"))
+	if err != nil || len(pods) != 1 {
+		t.Fatalf("failed to get running pods: expected '1 pod': actual '%v' '%v'", pods, err)
+	}
+
+	events, err := kubectl.GetEvents(kubectl.New().Namespace("litmus").Labels("app=web"), pods[0], 0)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("failed to get events: expected '2 events': actual '%v' '%v'", events, err)
+	}
+	if events[0].Reason != "FailedScheduling" || events[1].Reason != "Scheduled" || events[1].Type != kubectl.EventNormal {
+		t.Fatalf("failed to get events: expected 'FailedScheduling then Scheduled': actual '%v'", events)
+	}
+}
+
+// TestComponentExpectations verifies the drift of the components from their
+// expected state is reported by the deploy & run verifications
+func TestComponentExpectations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sim")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := NewCluster("node-1", "node-2")
+	defer useCluster(c)()
+
+	err = c.Apply([]byte(`
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  name: standard
+provisioner: kubernetes.io/no-provisioner
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+spec:
+  storageClassName: standard
+  resources:
+    requests:
+      storage: 1Gi
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.15
+`), "litmus")
+	if err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+
+	tests := map[string]struct {
+		expect      string
+		deployedErr string
+		runningErr  string
+	}{
+		"expectations - +ve test case - as expected": {
+			expect: `
+- namespace: litmus
+  kind: deploy
+  name: web
+  expect:
+    replicas: 2
+    minReady: 2
+    images: [nginx]
+- namespace: litmus
+  kind: pod
+  labels: app=web
+  expect:
+    replicas: 2
+    phase: Running
+    conditions: [{type: Ready}]
+- namespace: litmus
+  kind: pvc
+  name: data
+  expect: {phase: Bound, capacity: 1G, storageClass: standard}
+`,
+		},
+		"expectations - -ve test case - desired state drifts": {
+			expect: `
+- namespace: litmus
+  kind: deploy
+  name: web
+  expect:
+    replicas: 3
+    images: [nginx:1.14, busybox]
+`,
+			deployedErr: "component 'deploy' 'web' has drifted from its expected state:\n" +
+				"  web: replicas: expected '3': actual '2'\n" +
+				"  web: image: expected 'nginx:1.14': actual 'nginx:1.15'\n" +
+				"  web: image: expected 'busybox': actual 'nginx:1.15'",
+		},
+		"expectations - -ve test case - observed state drifts": {
+			expect: `
+- namespace: litmus
+  kind: pod
+  labels: app=web
+  expect:
+    replicas: 3
+    minReady: 3
+    conditions: [{type: Ready, status: "False"}]
+`,
+			runningErr: "component 'pod' 'app=web' has drifted from its expected state:\n" +
+				"  replicas: expected '3': actual '2'\n" +
+				"  minReady: expected '3': actual '2'\n" +
+				"  web-",
+		},
+		"expectations - -ve test case - pvc drifts": {
+			expect: `
+- namespace: litmus
+  kind: pvc
+  name: data
+  expect: {phase: Lost, capacity: 2Gi}
+`,
+			runningErr: "component 'pvc' 'data' has drifted from its expected state:\n" +
+				"  data: phase: expected 'Lost': actual 'Bound'\n" +
+				"  data: capacity: expected 'at least 2Gi': actual '1Gi'",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(dir, "install.yaml")
+			err := ioutil.WriteFile(file, []byte("components:"+mock.expect), 0644)
+			if err != nil {
+				t.Fatalf("failed to write install file: expected 'no error': actual '%s'", err)
+			}
+
+			v, err := verify.NewKubeInstallVerify(meta.InstallFile(file))
+			if err != nil {
+				t.Fatalf("failed to load install file: expected 'no error': actual '%s'", err)
+			}
+
+			_, err = v.IsDeployed()
+			if len(mock.deployedErr) == 0 && err != nil || len(mock.deployedErr) != 0 && (err == nil || !strings.HasPrefix(err.Error(), mock.deployedErr)) {
+				t.Fatalf("failed to verify deployed: expected '%s': actual '%v'", mock.deployedErr, err)
+			}
+			if len(mock.deployedErr) != 0 {
+				return
+			}
+
+			_, err = v.IsRunning()
+			if len(mock.runningErr) == 0 && err != nil || len(mock.runningErr) != 0 && (err == nil || !strings.HasPrefix(err.Error(), mock.runningErr)) {
+				t.Fatalf("failed to verify running: expected '%s': actual '%v'", mock.runningErr, err)
+			}
+		})
+	}
+}
+
+// TestComponentDependencies verifies the components are verified in the order
+// of their dependencies & the components whose dependencies failed are
+// reported as blocked
+func TestComponentDependencies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sim")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := NewCluster("node-1")
+	defer useCluster(c)()
+
+	// the pvc stays pending since its storage class does not exist
+	err = c.Apply([]byte(`
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+spec:
+  storageClassName: missing
+  resources:
+    requests:
+      storage: 1Gi
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`), "litmus")
+	if err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+
+	file := filepath.Join(dir, "install.yaml")
+	err = ioutil.WriteFile(file, []byte(`
+components:
+- kind: pod
+  namespace: litmus
+  labels: app=web
+  alias: app
+  dependsOn: [pvc, svc]
+- kind: pvc
+  namespace: litmus
+  name: data
+  alias: pvc
+  expect:
+    phase: Bound
+    storageClass: standard
+- kind: service
+  namespace: litmus
+  name: web
+  alias: svc
+`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write install file: expected 'no error': actual '%s'", err)
+	}
+
+	v, err := verify.NewKubeInstallVerify(meta.InstallFile(file))
+	if err != nil {
+		t.Fatalf("failed to load install file: expected 'no error': actual '%s'", err)
+	}
+
+	yes, err := v.IsDeployed()
+	expected := "2 components failed verification:\n" +
+		"component 'pvc' 'data' has drifted from its expected state:\n" +
+		"  data: storageClass: expected 'standard': actual 'missing'\n" +
+		"component 'pod' 'app=web' is blocked by 'pvc'"
+	if yes || err == nil || err.Error() != expected {
+		t.Fatalf("failed to verify deployed: expected '%s': actual '%t' '%v'", expected, yes, err)
+	}
+
+	yes, err = v.IsRunning()
+	expected = "2 components failed verification:\n" +
+		"component 'pvc' 'data' has drifted from its expected state:\n" +
+		"  data: storageClass: expected 'standard': actual 'missing'\n" +
+		"  data: phase: expected 'Bound': actual 'Pending'\n" +
+		"component 'pod' 'app=web' is blocked by 'pvc'"
+	if yes || err == nil || err.Error() != expected {
+		t.Fatalf("failed to verify running: expected '%s': actual '%t' '%v'", expected, yes, err)
+	}
+
+	// the blocked pod is never fetched
+	for _, h := range c.History() {
+		if strings.Contains(h, "app=web") {
+			t.Fatalf("failed to block pod: expected 'no pod lookup': actual '%s'", h)
+		}
+	}
+}
+
+// TestTeardownResources verifies the objects created by kubectl applies are
+// deleted at teardown while the pre-existing objects are left as is
+func TestTeardownResources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sim")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := NewCluster("node-1")
+	defer useCluster(c)()
+
+	shared := []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared
+data:
+  key: value
+`)
+	if err = c.Apply(shared, "litmus"); err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+
+	// the manifest is an actual file since the namespaces of the created
+	// objects are read from it
+	app := filepath.Join(dir, "app.yaml")
+	err = ioutil.WriteFile(app, append(shared, []byte(`
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: minio
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: minio-pvc
+  namespace: minio
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: minio
+  namespace: minio
+spec:
+  replicas: 2
+  template:
+    metadata:
+      labels:
+        app: minio
+    spec:
+      containers:
+      - name: minio
+        image: minio/minio
+`)...), 0644)
+	if err != nil {
+		t.Fatalf("failed to write manifest: expected 'no error': actual '%s'", err)
+	}
+
+	if _, err = kubectl.New().Run([]string{"apply", "-f", app}); err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+	err = kubectl.ApplyStdIn([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: minio-client-config
+`))
+	if err != nil {
+		t.Fatalf("failed to apply stdin: expected 'no error': actual '%s'", err)
+	}
+
+	var tracked []string
+	for _, o := range kubectl.DefaultResourceTracker().Objects() {
+		tracked = append(tracked, o.String())
+	}
+	expected := []string{
+		"namespace/minio in namespace 'litmus'",
+		"persistentvolumeclaim/minio-pvc in namespace 'minio'",
+		"deployment.apps/minio in namespace 'minio'",
+		"configmap/minio-client-config in namespace 'litmus'",
+	}
+	if !reflect.DeepEqual(tracked, expected) {
+		t.Fatalf("failed to track applies: expected '%v': actual '%v'", expected, tracked)
+	}
+
+	if err = kubectl.TeardownResources(kubectl.New()); err != nil {
+		t.Fatalf("failed to teardown: expected 'no error': actual '%s'", err)
+	}
+
+	if objs, _ := c.List("Pod", "", ""); len(objs) != 0 {
+		t.Fatalf("failed to teardown: expected 'no pods': actual '%d' pods", len(objs))
+	}
+	for _, o := range []struct{ kind, namespace, name string }{
+		{"Namespace", "", "minio"},
+		{"PersistentVolumeClaim", "minio", "minio-pvc"},
+		{"Deployment", "minio", "minio"},
+		{"ConfigMap", "litmus", "minio-client-config"},
+	} {
+		if _, found := c.Get(o.kind, o.namespace, o.name); found {
+			t.Fatalf("failed to teardown: expected '%s %s' to be deleted: actual 'found'", o.kind, o.name)
+		}
+	}
+	if _, found := c.Get("ConfigMap", "litmus", "shared"); !found {
+		t.Fatalf("failed to teardown: expected 'pre-existing config map': actual 'deleted'")
+	}
+}
+
+func TestEphemeralNamespace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sim")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := NewCluster("node-1")
+	defer useCluster(c)()
+
+	name, err := kubectl.CreateEphemeralNamespace(kubectl.New(), "litmus")
+	if err != nil {
+		t.Fatalf("failed to create ephemeral namespace: expected 'no error': actual '%s'", err)
+	}
+	defer kubectl.DeleteEphemeralNamespace(kubectl.New())
+	meta.SetDefaultNamespace(name, "litmus")
+	defer meta.SetDefaultNamespace("", "")
+
+	if !strings.HasPrefix(name, "litmus-") || kubectl.ResolveNamespace("litmus") != name {
+		t.Fatalf("failed to create ephemeral namespace: expected 'litmus-*': actual '%s'", name)
+	}
+	if _, err = kubectl.CreateEphemeralNamespace(kubectl.New(), "litmus"); err == nil {
+		t.Fatalf("failed to create ephemeral namespace: expected 'namespace in use error': actual 'no error'")
+	}
+
+	// the manifest is an actual file since it is rewritten before the apply
+	app := filepath.Join(dir, "app.yaml")
+	err = ioutil.WriteFile(app, []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+  namespace: litmus
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: minio
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: operator-config
+  namespace: default
+`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write manifest: expected 'no error': actual '%s'", err)
+	}
+	if _, err = kubectl.New().Run([]string{"apply", "-f", app}); err != nil {
+		t.Fatalf("failed to apply: expected 'no error': actual '%s'", err)
+	}
+
+	for _, o := range []struct{ kind, namespace, name string }{
+		{"Namespace", "", name},
+		{"ConfigMap", name, "app-config"},
+		{"Service", name, "minio"},
+		{"ConfigMap", "default", "operator-config"},
+	} {
+		if _, found := c.Get(o.kind, o.namespace, o.name); !found {
+			t.Fatalf("failed to apply: expected '%s %s' in namespace '%s': actual 'not found'", o.kind, o.name, o.namespace)
+		}
+	}
+	if _, found := c.Get("ConfigMap", "litmus", "app-config"); found {
+		t.Fatalf("failed to apply: expected 'no config map in litmus namespace': actual 'found'")
+	}
+	if _, err = kubectl.GetObject(kubectl.New(), "service", "minio"); err != nil {
+		t.Fatalf("failed to get: expected 'service in ephemeral namespace': actual '%s'", err)
+	}
+
+	install := filepath.Join(dir, "install.yaml")
+	err = ioutil.WriteFile(install, []byte(`
+components:
+- kind: service
+  name: minio
+- kind: configmap
+  name: app-config
+  namespace: litmus
+- kind: configmap
+  name: operator-config
+  namespace: default
+`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write install file: expected 'no error': actual '%s'", err)
+	}
+	i, err := meta.Load(meta.InstallFile(install))
+	if err != nil {
+		t.Fatalf("failed to load: expected 'no error': actual '%s'", err)
+	}
+	var namespaces []string
+	for _, comp := range i.Components {
+		namespaces = append(namespaces, comp.Namespace)
+	}
+	if expected := []string{name, name, "default"}; !reflect.DeepEqual(namespaces, expected) {
+		t.Fatalf("failed to default namespaces: expected '%v': actual '%v'", expected, namespaces)
+	}
+
+	if err = kubectl.DeleteEphemeralNamespace(kubectl.New()); err != nil {
+		t.Fatalf("failed to delete ephemeral namespace: expected 'no error': actual '%s'", err)
+	}
+	if _, found := c.Get("Namespace", "", name); found {
+		t.Fatalf("failed to delete ephemeral namespace: expected 'not found': actual 'found'")
+	}
+	if _, found := c.Get("ConfigMap", name, "app-config"); found {
+		t.Fatalf("failed to delete ephemeral namespace: expected 'config map deleted': actual 'found'")
+	}
+	if ns := kubectl.ResolveNamespace("litmus"); ns != "litmus" {
+		t.Fatalf("failed to delete ephemeral namespace: expected 'litmus': actual '%s'", ns)
+	}
+	for _, o := range kubectl.DefaultResourceTracker().Objects() {
+		if o.Kind == "namespace" {
+			t.Fatalf("failed to delete ephemeral namespace: expected 'untracked': actual '%s'", o)
+		}
+	}
+}
+
+// TestMySQLResiliencyWith3Reps runs the mysql resiliency feature against the
+// simulated cluster
+func TestMySQLResiliencyWith3Reps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sim")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := newOpenEBSCluster(t, "node-1", "node-2", "node-3", "node-4")
+	defer useCluster(c)()
+
+	// the volume is expected to have three replicas
+	err = c.Apply([]byte(`
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  name: openebs-percona
+provisioner: openebs.io/provisioner-iscsi
+parameters:
+  openebs.io/jiva-replica-count: "3"
+`), "")
+	if err != nil {
+		t.Fatalf("failed to apply storage class: expected 'no error': actual '%s'", err)
+	}
+
+	files := installFiles(t, dir, "tests/openebs/mysql_resiliency_with_3_reps/test-the-feature.yaml")
+	c.AddFile("/etc/e2e/application-launch/application-launch.yaml", readFile(t, "tests/openebs/mysql_resiliency_with_3_reps/application-launch.yaml"))
+
+	// I launch mysql application on volume
+	_, err = kubectl.New().Run([]string{"apply", "-f", "/etc/e2e/application-launch/application-launch.yaml"})
+	if err != nil {
+		t.Fatalf("failed to launch application: expected 'no error': actual '%s'", err)
+	}
+
+	app, err := verify.NewKubeInstallVerify(files["omrwtr-application-verify"])
+	if err != nil {
+		t.Fatalf("failed to load app verify file: expected 'no error': actual '%s'", err)
+	}
+	vol, err := verify.NewKubeInstallVerify(files["omrwtr-volume-verify"])
+	if err != nil {
+		t.Fatalf("failed to load volume verify file: expected 'no error': actual '%s'", err)
+	}
+
+	replica := kubectl.New().Labels("openebs/replica=jiva-replica")
+	before, _ := kubectl.GetRunningPods(replica)
+	if len(before) == 0 {
+		t.Fatalf("failed to provision volume: expected 'running replicas': actual 'no replicas'")
+	}
+
+	// verify each volume replica gets a unique node
+	if _, err = vol.IsCondition("volume-replica", verify.UniqueNodeCond); err != nil {
+		t.Fatalf("failed to verify unique nodes of replicas: expected 'no error': actual '%s'", err)
+	}
+
+	// verify the volume has three replicas
+	for _, alias := range []string{"volume-deployment", "volume-replica"} {
+		if _, err = vol.IsCondition(alias, verify.ThreeReplicasCond); err != nil {
+			t.Fatalf("failed to verify three replicas of '%s': expected 'no error': actual '%s'", alias, err)
+		}
+	}
+
+	// delete a volume replica & then another
+	for _, action := range []verify.Action{verify.DeleteAnyPodAction, verify.DeleteOldestPodAction} {
+		if _, err = vol.IsAction("volume-replica", action); err != nil {
+			t.Fatalf("failed to '%s': expected 'no error': actual '%s'", action, err)
+		}
+
+		// verify mysql application is running & all volume replicas are running
+		for _, v := range []verify.DeployRunVerifier{app, vol} {
+			if _, err = v.IsDeployed(); err != nil {
+				t.Fatalf("failed to verify deployment after '%s': expected 'no error': actual '%s'", action, err)
+			}
+			if _, err = v.IsRunning(); err != nil {
+				t.Fatalf("failed to verify running after '%s': expected 'no error': actual '%s'", action, err)
+			}
+		}
+
+		if _, err = vol.IsCondition("volume-replica", verify.UniqueNodeCond); err != nil {
+			t.Fatalf("failed to verify unique nodes of replicas after '%s': expected 'no error': actual '%s'", action, err)
+		}
+	}
+
+	after, _ := kubectl.GetRunningPods(replica)
+	if len(after) != len(before) || after[0] == before[0] {
+		t.Fatalf("failed to reschedule replicas: expected '%d' new replicas: actual '%v'", len(before), after)
+	}
+}
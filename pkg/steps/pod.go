@@ -0,0 +1,201 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package steps
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/verify"
+	"github.com/DATA-DOG/godog"
+	"github.com/DATA-DOG/godog/gherkin"
+)
+
+// PodSteps implements the steps that run commands in & copy files to or from
+// the pods of the components of an install file. The components are referred
+// to by their alias.
+type PodSteps struct {
+	// file is the install file whose components are accessed
+	file meta.InstallFile
+	// accessor runs the commands & copies the files
+	accessor verify.PodAccessor
+	// err is the error that occurred while loading the install file
+	err error
+	// last is the result of the last command that was run via these steps
+	last kubectl.ExecResult
+}
+
+// NewPodSteps returns a new instance of PodSteps based on the provided
+// install file
+func NewPodSteps(file meta.InstallFile) *PodSteps {
+	return &PodSteps{
+		file: file,
+	}
+}
+
+// Pod registers the pod steps against the provided suite. The install file is
+// loaded before every feature.
+func Pod(s *godog.Suite, file meta.InstallFile) *PodSteps {
+	p := NewPodSteps(file)
+
+	s.BeforeFeature(func(f *gherkin.Feature) {
+		p.load()
+	})
+
+	s.Step(`^I write "([^"]*)" to file "([^"]*)" in "([^"]*)"$`, p.WriteFile)
+	s.Step(`^verify file "([^"]*)" in "([^"]*)" contains "([^"]*)"$`, p.VerifyFileContains)
+	s.Step(`^verify file "([^"]*)" in "([^"]*)" contains "([^"]*)" within "([^"]*)"$`, p.VerifyFileContainsWithin)
+	s.Step(`^I run "([^"]*)" in "([^"]*)"$`, p.RunCommand)
+	s.Step(`^verify the command output contains "([^"]*)"$`, p.VerifyOutputContains)
+	s.Step(`^verify logs of "([^"]*)" contain "([^"]*)"$`, p.VerifyLogsContain)
+	s.Step(`^I copy "([^"]*)" to "([^"]*)" in "([^"]*)"$`, p.CopyTo)
+	s.Step(`^I copy "([^"]*)" from "([^"]*)" to "([^"]*)"$`, p.CopyFrom)
+
+	return p
+}
+
+// load loads the install file whose components are accessed by these steps
+func (p *PodSteps) load() {
+	v, err := verify.NewKubeInstallVerify(p.file)
+	if err != nil {
+		p.err = err
+		return
+	}
+	p.accessor = v
+	p.err = nil
+}
+
+// getAccessor returns the accessor or the error that occurred while loading
+// the install file
+func (p *PodSteps) getAccessor() (verify.PodAccessor, error) {
+	if p.accessor == nil {
+		return nil, fmt.Errorf("nil pod accessor: possible error '%v'", p.err)
+	}
+
+	return p.accessor, nil
+}
+
+// WriteFile writes the data to the file in the pod of the component that
+// matches the alias
+func (p *PodSteps) WriteFile(data, path, alias string) (err error) {
+	a, err := p.getAccessor()
+	if err != nil {
+		return
+	}
+
+	// the path is passed as an argument to avoid quoting it in the script
+	p.last, err = a.ExecInPod(alias, []string{"sh", "-c", `cat > "$0"`, path}, []byte(data))
+	return
+}
+
+// VerifyFileContains verifies if the file in the pod of the component that
+// matches the alias contains the data
+func (p *PodSteps) VerifyFileContains(path, alias, data string) (err error) {
+	a, err := p.getAccessor()
+	if err != nil {
+		return
+	}
+
+	p.last, err = a.ExecInPod(alias, []string{"cat", path}, nil)
+	if err != nil {
+		return
+	}
+
+	if !strings.Contains(p.last.Stdout, data) {
+		err = fmt.Errorf("file '%s' in pod '%s' does not contain '%s': actual '%s'", path, p.last.Pod, data, p.last.Stdout)
+	}
+	return
+}
+
+// VerifyFileContainsWithin verifies if the file in the pod of the component
+// that matches the alias contains the data within the timeout
+func (p *PodSteps) VerifyFileContainsWithin(path, alias, data, timeout string) (err error) {
+	return verify.EventuallyStep(timeout, func() error {
+		return p.VerifyFileContains(path, alias, data)
+	})
+}
+
+// RunCommand runs the shell command in the pod of the component that matches
+// the alias
+func (p *PodSteps) RunCommand(command, alias string) (err error) {
+	a, err := p.getAccessor()
+	if err != nil {
+		return
+	}
+
+	p.last, err = a.ExecInPod(alias, []string{"sh", "-c", command}, nil)
+	return
+}
+
+// VerifyOutputContains verifies if the output of the last command contains
+// the text
+func (p *PodSteps) VerifyOutputContains(text string) (err error) {
+	if len(p.last.Command) == 0 {
+		err = fmt.Errorf("no command was run")
+		return
+	}
+
+	if !strings.Contains(p.last.Stdout, text) {
+		err = fmt.Errorf("output of '%s' in pod '%s' does not contain '%s': actual '%s'", strings.Join(p.last.Command, " "), p.last.Pod, text, p.last.Stdout)
+	}
+	return
+}
+
+// VerifyLogsContain verifies if the logs of the pod of the component that
+// matches the alias contain the text
+func (p *PodSteps) VerifyLogsContain(alias, text string) (err error) {
+	a, err := p.getAccessor()
+	if err != nil {
+		return
+	}
+
+	logs, err := a.GetPodLogs(alias, 0, false)
+	if err != nil {
+		return
+	}
+
+	if !strings.Contains(logs.Output, text) {
+		err = fmt.Errorf("logs of pod '%s' do not contain '%s'", logs.Pod, text)
+	}
+	return
+}
+
+// CopyTo copies the local src to dst in the pod of the component that matches
+// the alias
+func (p *PodSteps) CopyTo(src, dst, alias string) (err error) {
+	a, err := p.getAccessor()
+	if err != nil {
+		return
+	}
+
+	_, err = a.CopyToPod(alias, src, dst)
+	return
+}
+
+// CopyFrom copies src in the pod of the component that matches the alias to
+// the local dst
+func (p *PodSteps) CopyFrom(src, alias, dst string) (err error) {
+	a, err := p.getAccessor()
+	if err != nil {
+		return
+	}
+
+	_, err = a.CopyFromPod(alias, src, dst)
+	return
+}
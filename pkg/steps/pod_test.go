@@ -0,0 +1,233 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package steps
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/sim"
+)
+
+const minio = `
+apiVersion: apps/v1beta2
+kind: Deployment
+metadata:
+  name: minio
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: minio
+  template:
+    metadata:
+      labels:
+        app: minio
+    spec:
+      containers:
+      - name: minio
+        image: minio/minio:latest
+`
+
+const install = `
+components:
+- kind: pod
+  namespace: litmus
+  labels: app=minio
+  alias: app-pod
+  container: minio
+`
+
+// podRuntime mimics the commands run in & the files copied to or from the
+// pods of the simulated cluster. The files of all the pods are kept in a
+// single map keyed by pod & path.
+type podRuntime struct {
+	*sim.Cluster
+	files map[string]string
+}
+
+func (r *podRuntime) Execute(args []string) (string, error) {
+	return r.StdinExecuteContext(context.Background(), args, nil)
+}
+
+func (r *podRuntime) StdinExecute(args []string, stdin []byte) (string, error) {
+	return r.StdinExecuteContext(context.Background(), args, stdin)
+}
+
+func (r *podRuntime) ExecuteContext(ctx context.Context, args []string) (string, error) {
+	return r.StdinExecuteContext(ctx, args, nil)
+}
+
+func (r *podRuntime) StdinExecuteContext(ctx context.Context, args []string, stdin []byte) (string, error) {
+	switch args[0] {
+	case "exec":
+		return r.exec(args, stdin)
+	case "logs":
+		return "minio server started", nil
+	case "cp":
+		return "", r.copy(args[1], args[2])
+	default:
+		return r.Cluster.StdinExecuteContext(ctx, args, stdin)
+	}
+}
+
+func (r *podRuntime) exec(args []string, stdin []byte) (string, error) {
+	pod := args[1]
+	var cmd []string
+	for i, arg := range args {
+		if arg == "--" {
+			cmd = args[i+1:]
+			break
+		}
+	}
+
+	switch {
+	case len(cmd) == 4 && cmd[2] == `cat > "$0"`:
+		r.files[pod+":"+cmd[3]] = string(stdin)
+		return "", nil
+	case len(cmd) == 2 && cmd[0] == "cat":
+		data, ok := r.files[pod+":"+cmd[1]]
+		if !ok {
+			return "", &exec.ExecError{Args: args, ExitCode: 1, Stderr: "No such file or directory", Err: fmt.Errorf("exit status 1")}
+		}
+		return data, nil
+	case len(cmd) == 3 && strings.HasPrefix(cmd[2], "echo "):
+		return strings.TrimPrefix(cmd[2], "echo "), nil
+	default:
+		return "", &exec.ExecError{Args: args, ExitCode: 127, Stderr: "command not found", Err: fmt.Errorf("exit status 127")}
+	}
+}
+
+func (r *podRuntime) copy(src, dst string) error {
+	if i := strings.Index(dst, ":"); i > 0 {
+		data, err := ioutil.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		r.files[dst] = string(data)
+		return nil
+	}
+
+	data, ok := r.files[src]
+	if !ok {
+		return fmt.Errorf("no such file '%s'", src)
+	}
+	return ioutil.WriteFile(dst, []byte(data), 0644)
+}
+
+func TestPodSteps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "litmus-steps")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "install.yaml")
+	err = ioutil.WriteFile(file, []byte(install), 0644)
+	if err != nil {
+		t.Fatalf("failed to write install file: %v", err)
+	}
+
+	c := sim.NewCluster("node-1")
+	err = c.Apply([]byte(minio), "litmus")
+	if err != nil {
+		t.Fatalf("failed to apply minio: %v", err)
+	}
+
+	r := &podRuntime{Cluster: c, files: map[string]string{}}
+	kubectl.SetDefaultExecutor(r)
+	defer kubectl.SetDefaultExecutor(nil)
+
+	p := NewPodSteps(meta.InstallFile(file))
+	err = p.WriteFile("hello", "/storage/data.txt", "app-pod")
+	if err == nil || !strings.Contains(err.Error(), "nil pod accessor") {
+		t.Fatalf("failed to verify steps before load: expected 'nil pod accessor': actual '%v'", err)
+	}
+	p.load()
+
+	// the steps are run in the order of a scenario
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"write file", func() error {
+			return p.WriteFile("hello", "/storage/data.txt", "app-pod")
+		}},
+		{"verify file contains", func() error {
+			return p.VerifyFileContains("/storage/data.txt", "app-pod", "hello")
+		}},
+		{"verify file contains within", func() error {
+			return p.VerifyFileContainsWithin("/storage/data.txt", "app-pod", "hello", "10s")
+		}},
+		{"run command", func() error {
+			return p.RunCommand("echo ready", "app-pod")
+		}},
+		{"verify command output", func() error {
+			return p.VerifyOutputContains("ready")
+		}},
+		{"verify logs", func() error {
+			return p.VerifyLogsContain("app-pod", "started")
+		}},
+		{"copy from pod", func() error {
+			return p.CopyFrom("/storage/data.txt", "app-pod", filepath.Join(dir, "copied.txt"))
+		}},
+		{"copy to pod", func() error {
+			return p.CopyTo(filepath.Join(dir, "copied.txt"), "/storage/copied.txt", "app-pod")
+		}},
+		{"verify copied file", func() error {
+			return p.VerifyFileContains("/storage/copied.txt", "app-pod", "hello")
+		}},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("failed to run step '%s': expected 'no error': actual '%v'", step.name, err)
+		}
+	}
+
+	failures := map[string]func() error{
+		"missing file": func() error {
+			return p.VerifyFileContains("/storage/missing.txt", "app-pod", "hello")
+		},
+		"unexpected content": func() error {
+			return p.VerifyFileContains("/storage/data.txt", "app-pod", "bye")
+		},
+		"unexpected output": func() error {
+			p.RunCommand("echo ready", "app-pod")
+			return p.VerifyOutputContains("done")
+		},
+		"unknown alias": func() error {
+			return p.RunCommand("echo ready", "db-pod")
+		},
+	}
+
+	for name, step := range failures {
+		t.Run(name, func(t *testing.T) {
+			if err := step(); err == nil {
+				t.Fatalf("failed to run step '%s': expected 'error': actual 'no error'", name)
+			}
+		})
+	}
+}
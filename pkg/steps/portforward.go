@@ -0,0 +1,160 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package steps
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/portforward"
+	"github.com/DATA-DOG/godog"
+	"github.com/DATA-DOG/godog/gherkin"
+)
+
+// PortForwardSteps implements the steps that forward local ports to the
+// service or pod components of an install file. This lets a feature reach
+// these components from wherever the test process runs e.g. a workstation.
+type PortForwardSteps struct {
+	// file is the install file whose components are forwarded
+	file meta.InstallFile
+	// template is the install file that is rendered with the values set in
+	// the scenario
+	template *meta.Template
+	// err is the error that occurred while loading the install file
+	err error
+	// manager starts & stops the port forwards
+	manager *portforward.Manager
+	// mutex guards the addresses
+	mutex sync.Mutex
+	// addresses are the local addresses of the forwarded components keyed by
+	// alias & port
+	addresses map[string]string
+}
+
+// NewPortForwardSteps returns a new instance of PortForwardSteps based on the
+// provided install file & manager
+func NewPortForwardSteps(file meta.InstallFile, manager *portforward.Manager) *PortForwardSteps {
+	return &PortForwardSteps{
+		file:      file,
+		manager:   manager,
+		addresses: map[string]string{},
+	}
+}
+
+// PortForward registers the port forward steps against the provided suite.
+// The install file is loaded before every feature & is rendered once a port
+// is forwarded. The port forwards are stopped at the end of every scenario
+// i.e. a port is forwarded in the scenario that uses it.
+func PortForward(s *godog.Suite, file meta.InstallFile) *PortForwardSteps {
+	p := NewPortForwardSteps(file, portforward.NewShellManager())
+
+	s.BeforeFeature(func(f *gherkin.Feature) {
+		p.load()
+	})
+
+	s.AfterScenario(func(scenario interface{}, err error) {
+		p.Close()
+	})
+
+	s.Step(`^I port forward to "([^"]*)" port "(\d+)" as "([^"]*)"$`, p.ForwardTo)
+
+	return p
+}
+
+// load loads the install file whose components are forwarded by these steps
+func (p *PortForwardSteps) load() {
+	p.template, p.err = meta.NewTemplate(p.file)
+}
+
+// component returns the service or pod component that matches the alias
+func (p *PortForwardSteps) component(alias string) (c meta.Component, err error) {
+	if p.template == nil {
+		err = fmt.Errorf("nil installation: possible error '%v'", p.err)
+		return
+	}
+
+	installation, err := p.template.Installation()
+	if err != nil {
+		return
+	}
+
+	c, err = installation.GetMatchingServiceComponent(alias)
+	if err == nil {
+		return
+	}
+
+	return installation.GetMatchingPodComponent(alias)
+}
+
+// ForwardTo forwards a local port to the port of the service or pod component
+// that matches the alias. The local address is set as the value of the key
+// so that the rest of the scenario can refer to it e.g. as
+// {{ .Values.<key> }} in the rendered files; see meta.SetValue.
+func (p *PortForwardSteps) ForwardTo(alias, port, key string) (err error) {
+	remotePort, err := strconv.Atoi(port)
+	if err != nil {
+		err = fmt.Errorf("failed to forward to '%s': invalid port '%s'", alias, port)
+		return
+	}
+
+	address, err := p.Address(alias, remotePort)
+	if err != nil {
+		return
+	}
+
+	meta.SetValue(key, address)
+	return
+}
+
+// Address returns the local address that reaches the port of the service or
+// pod component that matches the alias. A port forward is started if the
+// component's port is not yet forwarded in the current scenario.
+func (p *PortForwardSteps) Address(alias string, port int) (address string, err error) {
+	key := fmt.Sprintf("%s:%d", alias, port)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	address, ok := p.addresses[key]
+	if ok {
+		return
+	}
+
+	c, err := p.component(alias)
+	if err != nil {
+		return
+	}
+
+	f, err := p.manager.ForwardComponent(c, port)
+	if err != nil {
+		return
+	}
+
+	p.addresses[key] = f.LocalAddress
+	return f.LocalAddress, nil
+}
+
+// Close stops all the port forwards started by these steps
+func (p *PortForwardSteps) Close() error {
+	p.mutex.Lock()
+	p.addresses = map[string]string{}
+	p.mutex.Unlock()
+
+	return p.manager.Close()
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package steps
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/portforward"
+	"github.com/AmitKumarDas/elitmus/pkg/sim"
+)
+
+const forwardInstall = `
+components:
+- kind: service
+  name: minio
+  namespace: litmus
+  alias: app-service
+- kind: pod
+  namespace: litmus
+  labels: app=minio
+  alias: app-pod
+`
+
+// listenProcess mimics a port forward by listening on the forwarded local
+// port
+type listenProcess struct {
+	listener net.Listener
+	done     chan struct{}
+}
+
+func (p *listenProcess) Stop() error {
+	p.listener.Close()
+	close(p.done)
+	return nil
+}
+
+func (p *listenProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// targets records the targets of the port forwards
+var targets []string
+
+func listenStarter(args []string, out io.Writer) (portforward.Process, error) {
+	// the args are of the form port-forward --address host target local:remote
+	targets = append(targets, args[3])
+	local := strings.Split(args[4], ":")[0]
+
+	l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", local))
+	if err != nil {
+		return nil, err
+	}
+	return &listenProcess{listener: l, done: make(chan struct{})}, nil
+}
+
+func TestPortForwardSteps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "litmus-steps")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "install.yaml")
+	err = ioutil.WriteFile(file, []byte(forwardInstall), 0644)
+	if err != nil {
+		t.Fatalf("failed to write install file: %v", err)
+	}
+
+	c := sim.NewCluster("node-1")
+	err = c.Apply([]byte(minio), "litmus")
+	if err != nil {
+		t.Fatalf("failed to apply minio: %v", err)
+	}
+	kubectl.SetDefaultExecutor(c)
+	defer kubectl.SetDefaultExecutor(nil)
+
+	targets = nil
+	p := NewPortForwardSteps(meta.InstallFile(file), portforward.NewManager(listenStarter, time.Second))
+	p.load()
+
+	defer meta.ResetValues()
+	err = p.ForwardTo("app-service", "9000", "minioAddress")
+	if err != nil {
+		t.Fatalf("failed to forward to service: expected 'no error': actual '%v'", err)
+	}
+
+	// the forward is reused within a scenario
+	first, _ := p.Address("app-service", 9000)
+	if actual := meta.CurrentVars().Values["minioAddress"]; actual != first {
+		t.Fatalf("failed to set forwarded address: expected '%s': actual '%s'", first, actual)
+	}
+	second, err := p.Address("app-service", 9000)
+	if err != nil || first != second || len(targets) != 1 {
+		t.Fatalf("failed to reuse forward: expected 'same address': actual '%s' '%s' '%v'", first, second, err)
+	}
+
+	err = p.ForwardTo("app-pod", "9000", "minioPodAddress")
+	if err != nil {
+		t.Fatalf("failed to forward to pod: expected 'no error': actual '%v'", err)
+	}
+
+	if targets[0] != "svc/minio" || !strings.HasPrefix(targets[1], "pod/minio-") {
+		t.Fatalf("failed to forward: expected targets 'svc/minio' & 'pod/minio-*': actual '%v'", targets)
+	}
+
+	// the forwards are stopped at the end of a scenario
+	p.Close()
+	_, err = net.DialTimeout("tcp", first, time.Second)
+	if err == nil {
+		t.Fatalf("failed to close: expected 'closed local port': actual 'port accepts connections'")
+	}
+
+	failures := map[string]struct {
+		alias string
+		port  string
+	}{
+		"invalid port":  {alias: "app-service", port: "http"},
+		"unknown alias": {alias: "db-service", port: "3306"},
+	}
+
+	for name, mock := range failures {
+		t.Run(name, func(t *testing.T) {
+			if err := p.ForwardTo(mock.alias, mock.port, "address"); err == nil {
+				t.Fatalf("failed to forward to '%s': expected 'error': actual 'no error'", mock.alias)
+			}
+		})
+	}
+}
@@ -40,6 +40,66 @@ const (
 
 	// KubectlPathENVK is the ENV key to fetch kubectl executable location
 	KubectlPathENVK ENVKey = "LITMUS_IO_KUBECTL_PATH"
+
+	// KubeBackendENVK is the ENV key to fetch the backend used to talk to
+	// kubernetes i.e. shell or native
+	KubeBackendENVK ENVKey = "LITMUS_IO_KUBE_BACKEND"
+
+	// KubectlTimeoutENVK is the ENV key to fetch the maximum duration a
+	// kubectl command is allowed to run e.g. 5m, 90s, etc
+	KubectlTimeoutENVK ENVKey = "LITMUS_IO_KUBECTL_TIMEOUT"
+
+	// KubectlRetryAttemptsENVK is the ENV key to fetch the maximum number of
+	// times a kubectl command is attempted when it fails with transient errors.
+	// A mutating command is retried only if the error guarantees it made no
+	// change or if it is a create or delete.
+	KubectlRetryAttemptsENVK ENVKey = "LITMUS_IO_KUBECTL_RETRY_ATTEMPTS"
+
+	// KubectlRetryMaxElapsedENVK is the ENV key to fetch the maximum time
+	// spent in retrying a kubectl command e.g. 2m
+	KubectlRetryMaxElapsedENVK ENVKey = "LITMUS_IO_KUBECTL_RETRY_MAX_ELAPSED"
+
+	// DryRunENVK is the ENV key to fetch the dry run mode. Mutating kubectl
+	// commands are printed as a plan instead of getting executed in dry run
+	// mode. Read only kubectl commands are either passed through or stubbed
+	// i.e. true, passthrough or stub
+	DryRunENVK ENVKey = "LITMUS_IO_DRY_RUN"
+
+	// AuditLogENVK is the ENV key to fetch the audit log file path. An audit
+	// record of every kubectl execution is appended to this file.
+	AuditLogENVK ENVKey = "LITMUS_IO_AUDIT_LOG"
+
+	// CassetteENVK is the ENV key to fetch the cassette file path. All
+	// kubectl executions are recorded to or replayed from this file.
+	CassetteENVK ENVKey = "LITMUS_IO_CASSETTE"
+
+	// CassetteModeENVK is the ENV key to fetch the cassette mode
+	// i.e. record, replay or replay-by-args
+	CassetteModeENVK ENVKey = "LITMUS_IO_CASSETTE_MODE"
+
+	// DiagnosticsDirENVK is the ENV key to fetch the artifacts directory
+	// where the diagnostics bundles of failed steps are written
+	DiagnosticsDirENVK ENVKey = "LITMUS_IO_DIAGNOSTICS_DIR"
+
+	// DiagnosticsConfigMapENVK is the ENV key to fetch the name of the config
+	// map where the diagnostics bundles are pushed. Bundles are not pushed if
+	// this is not set.
+	DiagnosticsConfigMapENVK ENVKey = "LITMUS_IO_DIAGNOSTICS_CONFIGMAP"
+
+	// RunIDENVK is the ENV key to fetch the id of this run of litmus. A
+	// unique id is generated if this is not set. The test jobs set it to the
+	// uid of their pod so that litmus gc treats the run as active while this
+	// pod is running.
+	RunIDENVK ENVKey = "LITMUS_IO_RUN_ID"
+
+	// TestNameENVK is the ENV key to fetch the name of the test being run.
+	// The name of the feature being run is used if this is not set.
+	TestNameENVK ENVKey = "LITMUS_IO_TEST_NAME"
+
+	// EphemeralNamespaceENVK is the ENV key to fetch the ephemeral namespace
+	// mode. A unique namespace replaces the litmus namespace during every
+	// feature run if this is set to true.
+	EphemeralNamespaceENVK ENVKey = "LITMUS_IO_EPHEMERAL_NAMESPACE"
 )
 
 // KubectlPathENV gets the kubectl executable location from ENV
@@ -48,6 +108,84 @@ func KubectlPathENV() string {
 	return val
 }
 
+// KubeBackendENV gets the kubernetes backend from ENV
+func KubeBackendENV() string {
+	val := getEnv(KubeBackendENVK)
+	return val
+}
+
+// KubectlTimeoutENV gets the kubectl execution timeout from ENV
+func KubectlTimeoutENV() string {
+	val := getEnv(KubectlTimeoutENVK)
+	return val
+}
+
+// KubectlRetryAttemptsENV gets the kubectl retry attempts from ENV
+func KubectlRetryAttemptsENV() string {
+	val := getEnv(KubectlRetryAttemptsENVK)
+	return val
+}
+
+// KubectlRetryMaxElapsedENV gets the maximum kubectl retry duration from ENV
+func KubectlRetryMaxElapsedENV() string {
+	val := getEnv(KubectlRetryMaxElapsedENVK)
+	return val
+}
+
+// DryRunENV gets the dry run mode from ENV
+func DryRunENV() string {
+	val := getEnv(DryRunENVK)
+	return val
+}
+
+// AuditLogENV gets the audit log file path from ENV
+func AuditLogENV() string {
+	val := getEnv(AuditLogENVK)
+	return val
+}
+
+// CassetteENV gets the cassette file path from ENV
+func CassetteENV() string {
+	val := getEnv(CassetteENVK)
+	return val
+}
+
+// CassetteModeENV gets the cassette mode from ENV
+func CassetteModeENV() string {
+	val := getEnv(CassetteModeENVK)
+	return val
+}
+
+// DiagnosticsDirENV gets the diagnostics artifacts directory from ENV
+func DiagnosticsDirENV() string {
+	val := getEnv(DiagnosticsDirENVK)
+	return val
+}
+
+// DiagnosticsConfigMapENV gets the diagnostics config map name from ENV
+func DiagnosticsConfigMapENV() string {
+	val := getEnv(DiagnosticsConfigMapENVK)
+	return val
+}
+
+// RunIDENV gets the run id from ENV
+func RunIDENV() string {
+	val := getEnv(RunIDENVK)
+	return val
+}
+
+// TestNameENV gets the test name from ENV
+func TestNameENV() string {
+	val := getEnv(TestNameENVK)
+	return val
+}
+
+// EphemeralNamespaceENV gets the ephemeral namespace mode from ENV
+func EphemeralNamespaceENV() string {
+	val := getEnv(EphemeralNamespaceENVK)
+	return val
+}
+
 // KubeNamespaceENV gets the kubernetes namespace from ENV
 func KubeNamespaceENV() string {
 	val := getEnv(KubeNamespaceENVK)
@@ -52,3 +52,54 @@ func IsService(kind string) (yes bool) {
 
 	return
 }
+
+// IsBarePod flags if the provided kind is a kubernetes pod & not a workload
+// that manages pods
+func IsBarePod(kind string) (yes bool) {
+	switch kind {
+	case "po", "pod", "pods":
+		yes = true
+	default:
+		yes = false
+	}
+
+	return
+}
+
+// IsReplicated flags if the provided kind is a kubernetes workload with a
+// replica count
+func IsReplicated(kind string) (yes bool) {
+	switch kind {
+	case "deploy", "deployment", "deployments", "sts", "statefulset", "statefulsets", "rs", "replicaset", "replicasets":
+		yes = true
+	default:
+		yes = false
+	}
+
+	return
+}
+
+// IsPodTemplated flags if the provided kind is a kubernetes workload that
+// creates its pods from a pod template
+func IsPodTemplated(kind string) (yes bool) {
+	switch kind {
+	case "ds", "daemonset", "daemonsets", "job", "jobs":
+		yes = true
+	default:
+		yes = IsReplicated(kind)
+	}
+
+	return
+}
+
+// IsPVC flags if the provided kind is a kubernetes persistent volume claim
+func IsPVC(kind string) (yes bool) {
+	switch kind {
+	case "pvc", "persistentvolumeclaim", "persistentvolumeclaims":
+		yes = true
+	default:
+		yes = false
+	}
+
+	return
+}
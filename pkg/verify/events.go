@@ -0,0 +1,230 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+)
+
+// RecentEventsLimit is the maximum number of the latest events of the
+// components that are appended to the error of a failed verification
+const RecentEventsLimit = 5
+
+// eventsSince is the time since when the warning events of the components
+// are verified. A zero time verifies all the warning events.
+var eventsSince = struct {
+	sync.Mutex
+	t time.Time
+}{}
+
+// SetEventsSince bounds the no-warning-events condition to the events that
+// occurred since the provided time e.g. the start of a scenario. A zero time
+// removes this bound.
+//
+// NOTE:
+//  The time is truncated to the second since the event timestamps do not
+// carry a finer precision.
+func SetEventsSince(t time.Time) {
+	eventsSince.Lock()
+	defer eventsSince.Unlock()
+	eventsSince.t = t.Truncate(time.Second)
+}
+
+// getEventsSince returns the time set via SetEventsSince
+func getEventsSince() time.Time {
+	eventsSince.Lock()
+	defer eventsSince.Unlock()
+	return eventsSince.t
+}
+
+// componentError is the error of a verification that failed against the
+// provided components. The recent events of these components are appended to
+// this error once the verification gives up; see withEvents.
+type componentError struct {
+	err        error
+	components []meta.Component
+}
+
+func (e *componentError) Error() string {
+	return e.err.Error()
+}
+
+// againstComponents returns an error that records the components the provided
+// error is about
+func againstComponents(err error, components ...meta.Component) error {
+	if err == nil || len(components) == 0 {
+		return err
+	}
+	return &componentError{err: err, components: components}
+}
+
+// failedComponents returns the components recorded against the error, if any
+func failedComponents(err error) []meta.Component {
+	if cerr, ok := err.(*componentError); ok {
+		return cerr.components
+	}
+	return nil
+}
+
+// GetComponentObjectNames returns the names of the objects that make up the
+// component
+func GetComponentObjectNames(c meta.Component) (names []string, err error) {
+	if len(strings.TrimSpace(c.Name)) != 0 {
+		names = append(names, c.Name)
+		return
+	}
+
+	if len(strings.TrimSpace(c.Labels)) == 0 {
+		err = fmt.Errorf("unable to fetch component objects: either component name or its labels is required: component '%#v'", c)
+		return
+	}
+
+	objs, err := kubectl.ListObjects(kubectl.New().Namespace(c.Namespace).Labels(c.Labels), c.Kind)
+	if err != nil {
+		return
+	}
+
+	for _, o := range objs {
+		names = append(names, o.Metadata.Name)
+	}
+	return
+}
+
+// getComponentEvents returns the events of the objects that make up the
+// component
+func getComponentEvents(c meta.Component) (events []kubectl.Event, err error) {
+	names, err := GetComponentObjectNames(c)
+	if err != nil || len(names) == 0 {
+		return
+	}
+
+	all, err := kubectl.GetEvents(kubectl.New().Namespace(c.Namespace), "", 0)
+	if err != nil {
+		return
+	}
+
+	for _, e := range all {
+		for _, n := range names {
+			if e.InvolvedObject.Name == n {
+				events = append(events, e)
+				break
+			}
+		}
+	}
+	return
+}
+
+// WithRecentEvents appends the recent events of the components that the
+// verification failed against to its error. This is done by the wait based
+// verifiers once they give up & is meant for the verifications that are not
+// waited upon.
+func WithRecentEvents(err error) error {
+	return withEvents(err, failedComponents(err)...)
+}
+
+// withEvents appends the recent events of the provided components to the
+// error. The error is returned as is if these events can not be fetched.
+func withEvents(err error, components ...meta.Component) error {
+	if err == nil {
+		return nil
+	}
+
+	var events []kubectl.Event
+	for _, c := range components {
+		e, eerr := getComponentEvents(c)
+		if eerr != nil {
+			continue
+		}
+		events = append(events, e...)
+	}
+
+	if len(events) == 0 {
+		return err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time().Before(events[j].Time())
+	})
+	if len(events) > RecentEventsLimit {
+		events = events[len(events)-RecentEventsLimit:]
+	}
+
+	var lines []string
+	for _, e := range events {
+		lines = append(lines, e.String())
+	}
+	return fmt.Errorf("%s: recent events:\n  %s", err, strings.Join(lines, "\n  "))
+}
+
+// getAliasComponents returns the components that match the provided alias
+func (v *KubeInstallVerify) getAliasComponents(alias string) (components []meta.Component) {
+	for _, c := range v.installation.Components {
+		if c.Alias == alias {
+			components = append(components, c)
+		}
+	}
+	return
+}
+
+// hasNoWarningEvents flags if the components with the provided alias have no
+// warning events other than the ones allowed against these components. Only
+// the events since the time set via SetEventsSince are verified.
+func (v *KubeInstallVerify) hasNoWarningEvents(alias string) (yes bool, err error) {
+	components := v.getAliasComponents(alias)
+	if len(components) == 0 {
+		err = fmt.Errorf("unable to verify warning events: no component with alias '%s'", alias)
+		return
+	}
+
+	var warnings []string
+	for _, c := range components {
+		var events []kubectl.Event
+		events, err = getComponentEvents(c)
+		if err != nil {
+			return
+		}
+
+		warnings = append(warnings, disallowedWarnings(events, getEventsSince(), c.AllowedEventReasons...)...)
+	}
+
+	if len(warnings) != 0 {
+		err = fmt.Errorf("warning events found for alias '%s':\n  %s", alias, strings.Join(warnings, "\n  "))
+		return
+	}
+
+	yes = true
+	return
+}
+
+// disallowedWarnings returns the warning events that occurred since the
+// provided time & whose reason is not one of the allowed reasons
+func disallowedWarnings(events []kubectl.Event, since time.Time, allowed ...string) (warnings []string) {
+	for _, e := range kubectl.WarningEvents(events, allowed...) {
+		if !since.IsZero() && e.Time().Before(since) {
+			continue
+		}
+		warnings = append(warnings, e.String())
+	}
+	return
+}
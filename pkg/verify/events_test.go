@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+)
+
+// warning returns a warning event of a pod with the provided reason that
+// last occurred at the provided time
+func warning(reason string, at time.Time) kubectl.Event {
+	return kubectl.Event{
+		InvolvedObject: kubectl.ObjectReference{Kind: "Pod", Name: "minio-1"},
+		Type:           kubectl.EventWarning,
+		Reason:         reason,
+		Message:        "failed",
+		LastTimestamp:  at,
+	}
+}
+
+func TestDisallowedWarnings(t *testing.T) {
+	start := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	events := []kubectl.Event{
+		warning("FailedScheduling", start.Add(-time.Minute)),
+		{Type: "Normal", Reason: "Scheduled", LastTimestamp: start.Add(time.Minute)},
+		warning("FailedAttachVolume", start),
+		warning("BackOff", start.Add(2*time.Minute)),
+	}
+
+	tests := map[string]struct {
+		since    time.Time
+		allowed  []string
+		expected []string
+	}{
+		"disallowed warnings - +ve test case - all warnings": {
+			expected: []string{"FailedScheduling", "FailedAttachVolume", "BackOff"},
+		},
+		"disallowed warnings - +ve test case - allowed reasons are ignored": {
+			allowed:  []string{"FailedScheduling", " BackOff "},
+			expected: []string{"FailedAttachVolume"},
+		},
+		"disallowed warnings - +ve test case - warnings since the start": {
+			since:    start,
+			expected: []string{"FailedAttachVolume", "BackOff"},
+		},
+		"disallowed warnings - +ve test case - allowed warnings since the start": {
+			since:   start,
+			allowed: []string{"FailedAttachVolume", "BackOff"},
+		},
+		"disallowed warnings - +ve test case - no warnings after the start": {
+			since: start.Add(time.Hour),
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			var expected []string
+			for _, r := range mock.expected {
+				expected = append(expected, fmt.Sprintf("Warning %s pod/minio-1: failed", r))
+			}
+
+			warnings := disallowedWarnings(events, mock.since, mock.allowed...)
+			if !reflect.DeepEqual(warnings, expected) {
+				t.Fatalf("failed to filter warnings: expected '%v': actual '%v'", expected, warnings)
+			}
+		})
+	}
+}
+
+func TestSetEventsSince(t *testing.T) {
+	defer SetEventsSince(time.Time{})
+
+	SetEventsSince(time.Date(2018, 6, 1, 10, 0, 0, 900000000, time.UTC))
+	expected := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	if actual := getEventsSince(); !actual.Equal(expected) {
+		t.Fatalf("failed to set events since: expected '%s': actual '%s'", expected, actual)
+	}
+}
+
+func TestFailedComponents(t *testing.T) {
+	pod := meta.Component{Kind: "pod", Alias: "app-pod"}
+	pvc := meta.Component{Kind: "pvc", Alias: "pvc"}
+
+	tests := map[string]struct {
+		err      error
+		expected []meta.Component
+	}{
+		"failed components - +ve test case - no error": {
+			err: againstComponents(nil, pod),
+		},
+		"failed components - +ve test case - error without components": {
+			err: againstComponents(fmt.Errorf("failed")),
+		},
+		"failed components - +ve test case - error against components": {
+			err:      againstComponents(fmt.Errorf("failed"), pod, pvc),
+			expected: []meta.Component{pod, pvc},
+		},
+		"failed components - -ve test case - wrapped error": {
+			err: fmt.Errorf("step failed: %s", againstComponents(fmt.Errorf("failed"), pod)),
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			components := failedComponents(mock.err)
+			if !reflect.DeepEqual(components, mock.expected) {
+				t.Fatalf("failed to get failed components: expected '%v': actual '%v'", mock.expected, components)
+			}
+			if mock.err != nil && mock.err.Error() != "failed" && mock.err.Error() != "step failed: failed" {
+				t.Fatalf("failed to get error: expected 'error message as is': actual '%s'", mock.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+)
+
+// isComponentAsExpected flags if the component is in its expected state. Only
+// the expectations about the desired state of the component e.g. the replica
+// count of a deployment, its images or the storage class of a pvc are
+// verified unless observed is set. The observed state e.g. the ready
+// replicas, phases & conditions is verified as well if observed is set.
+//
+// NOTE:
+//  Any drift from the expected state is reported as an error
+func isComponentAsExpected(component meta.Component, observed bool) (yes bool, err error) {
+	if !component.Expect.IsSet() {
+		yes = true
+		return
+	}
+
+	objs, err := getComponentStates(component)
+	if err != nil {
+		return
+	}
+
+	drifts, err := expectationDrifts(component, objs, observed)
+	if err != nil {
+		return
+	}
+	if len(drifts) != 0 {
+		err = fmt.Errorf("component '%s' '%s' has drifted from its expected state:\n  %s", component.Kind, componentID(component), strings.Join(drifts, "\n  "))
+		return
+	}
+
+	yes = true
+	return
+}
+
+// getComponentStates fetches the objects that make up the component along
+// with their state
+func getComponentStates(c meta.Component) (objs []kubectl.ObjectState, err error) {
+	if len(strings.TrimSpace(c.Name)) != 0 {
+		var obj kubectl.ObjectState
+		obj, err = kubectl.GetObjectState(kubectl.New().Namespace(c.Namespace), c.Kind, c.Name)
+		if err != nil {
+			return
+		}
+		return []kubectl.ObjectState{obj}, nil
+	}
+
+	if len(strings.TrimSpace(c.Labels)) == 0 {
+		err = fmt.Errorf("unable to fetch component objects: either component name or its labels is required: component '%#v'", c)
+		return
+	}
+
+	return kubectl.ListObjectStates(kubectl.New().Namespace(c.Namespace).Labels(c.Labels), c.Kind)
+}
+
+// componentID returns the name of the component if set or else its labels
+func componentID(c meta.Component) string {
+	if len(strings.TrimSpace(c.Name)) != 0 {
+		return c.Name
+	}
+	return c.Labels
+}
+
+// expectationDrifts returns the differences between the expected state of
+// the component & the state of its objects. An error is returned if the
+// expectation itself is invalid.
+func expectationDrifts(c meta.Component, objs []kubectl.ObjectState, observed bool) (drifts []string, err error) {
+	e := c.Expect
+	var expectedCapacity int64
+	if len(e.Capacity) != 0 {
+		expectedCapacity, err = meta.ParseQuantity(e.Capacity)
+		if err != nil {
+			err = fmt.Errorf("failed to verify component '%s' '%s': expect: %s", c.Kind, componentID(c), err)
+			return
+		}
+	}
+
+	drift := func(field string, expected, actual interface{}) {
+		drifts = append(drifts, fmt.Sprintf("%s: expected '%v': actual '%v'", field, expected, actual))
+	}
+
+	// the pods of a pod component are counted since the component is made up
+	// of these pods
+	if util.IsBarePod(c.Kind) && observed {
+		ready := 0
+		for _, o := range objs {
+			if isObjectReady(o) {
+				ready++
+			}
+		}
+		if e.Replicas != nil && len(objs) != *e.Replicas {
+			drift("replicas", *e.Replicas, len(objs))
+		}
+		if e.MinReady != nil && ready < *e.MinReady {
+			drift("minReady", *e.MinReady, ready)
+		}
+	}
+
+	if len(objs) == 0 {
+		if !util.IsBarePod(c.Kind) || e.Replicas == nil || *e.Replicas != 0 {
+			drifts = append(drifts, "no objects found")
+		}
+		return
+	}
+
+	for _, o := range objs {
+		name := o.Metadata.Name
+		drift := func(field string, expected, actual interface{}) {
+			drifts = append(drifts, fmt.Sprintf("%s: %s: expected '%v': actual '%v'", name, field, expected, actual))
+		}
+
+		if util.IsReplicated(c.Kind) {
+			// kubernetes defaults the replicas of a workload to 1
+			replicas := 1
+			if o.Spec.Replicas != nil {
+				replicas = *o.Spec.Replicas
+			}
+			if e.Replicas != nil && replicas != *e.Replicas {
+				drift("replicas", *e.Replicas, replicas)
+			}
+			if e.MinReady != nil && observed && o.Status.ReadyReplicas < *e.MinReady {
+				drift("minReady", *e.MinReady, o.Status.ReadyReplicas)
+			}
+		}
+
+		if len(e.Images) != 0 {
+			containers := o.Spec.Containers
+			if o.Spec.Template != nil {
+				containers = o.Spec.Template.Spec.Containers
+			}
+			var images []string
+			for _, ct := range containers {
+				images = append(images, ct.Image)
+			}
+			for _, image := range e.Images {
+				if !hasImage(images, image) {
+					drift("image", image, strings.Join(images, ", "))
+				}
+			}
+		}
+
+		if len(e.StorageClass) != 0 && o.Spec.StorageClassName != e.StorageClass {
+			drift("storageClass", e.StorageClass, o.Spec.StorageClassName)
+		}
+
+		if !observed {
+			continue
+		}
+
+		if len(e.Phase) != 0 && o.Status.Phase != e.Phase {
+			drift("phase", e.Phase, o.Status.Phase)
+		}
+
+		for _, ec := range e.Conditions {
+			status := ec.Status
+			if len(status) == 0 {
+				status = "True"
+			}
+			actual := "missing"
+			for _, oc := range o.Status.Conditions {
+				if oc.Type == ec.Type {
+					actual = oc.Status
+					break
+				}
+			}
+			if actual != status {
+				drift(fmt.Sprintf("condition '%s'", ec.Type), status, actual)
+			}
+		}
+
+		if len(e.Capacity) != 0 {
+			capacity := o.Status.Capacity["storage"]
+			actual, perr := meta.ParseQuantity(capacity)
+			if perr != nil || actual < expectedCapacity {
+				drift("capacity", "at least "+e.Capacity, capacity)
+			}
+		}
+	}
+	return
+}
+
+// isObjectReady flags if the object reports a Ready condition with True
+// status
+func isObjectReady(o kubectl.ObjectState) bool {
+	for _, c := range o.Status.Conditions {
+		if c.Type == "Ready" {
+			return c.Status == "True"
+		}
+	}
+	return false
+}
+
+// hasImage flags if the expected image is one of the images. An expected
+// image without a tag or digest matches any tag or digest of the image.
+func hasImage(images []string, expected string) bool {
+	for _, image := range images {
+		if image == expected || imageRepository(image) == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// imageRepository returns the image without its tag or digest e.g.
+// openebs/jiva for openebs/jiva:0.5.0
+func imageRepository(image string) string {
+	if idx := strings.Index(image, "@"); idx != -1 {
+		image = image[:idx]
+	}
+	// a colon before the last slash separates the registry host & its port
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		image = image[:idx]
+	}
+	return image
+}
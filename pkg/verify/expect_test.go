@@ -0,0 +1,169 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+)
+
+// states decodes the objects from their json
+func states(t *testing.T, objs ...string) (states []kubectl.ObjectState) {
+	for _, o := range objs {
+		var s kubectl.ObjectState
+		if err := json.Unmarshal([]byte(o), &s); err != nil {
+			t.Fatalf("failed to decode object: expected 'no error': actual '%s'", err)
+		}
+		states = append(states, s)
+	}
+	return
+}
+
+// intPtr returns a pointer to the provided int
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestExpectationDrifts(t *testing.T) {
+	deployment := `{"metadata": {"name": "minio"}, "spec": {"replicas": 3, "template": {"spec": {"containers": [{"image": "minio/minio:RELEASE.2018"}]}}}, "status": {"readyReplicas": 2, "conditions": [{"type": "Available", "status": "True"}]}}`
+	readyPod := `{"metadata": {"name": "minio-1"}, "spec": {"containers": [{"image": "minio/minio"}]}, "status": {"phase": "Running", "conditions": [{"type": "Ready", "status": "True"}]}}`
+	pendingPod := `{"metadata": {"name": "minio-2"}, "status": {"phase": "Pending"}}`
+	pvc := `{"metadata": {"name": "minio-pvc"}, "spec": {"storageClassName": "openebs-standard"}, "status": {"phase": "Bound", "capacity": {"storage": "5Gi"}}}`
+
+	tests := map[string]struct {
+		kind     string
+		expect   meta.Expectation
+		objs     []string
+		observed bool
+		expected []string
+		isErr    bool
+	}{
+		"drifts - +ve test case - desired state of a deployment": {
+			kind:   "deployment",
+			expect: meta.Expectation{Replicas: intPtr(3), MinReady: intPtr(3), Images: []string{"minio/minio"}},
+			objs:   []string{deployment},
+		},
+		"drifts - +ve test case - observed state of a deployment": {
+			kind:     "deployment",
+			expect:   meta.Expectation{Replicas: intPtr(3), MinReady: intPtr(3), Conditions: []meta.ExpectedCondition{{Type: "Available"}, {Type: "Progressing"}}},
+			objs:     []string{deployment},
+			observed: true,
+			expected: []string{
+				"minio: minReady: expected '3': actual '2'",
+				"minio: condition 'Progressing': expected 'True': actual 'missing'",
+			},
+		},
+		"drifts - +ve test case - replicas & image of a deployment": {
+			kind:   "deployment",
+			expect: meta.Expectation{Replicas: intPtr(1), Images: []string{"minio/minio:latest", "minio/mc"}},
+			objs:   []string{deployment},
+			expected: []string{
+				"minio: replicas: expected '1': actual '3'",
+				"minio: image: expected 'minio/minio:latest': actual 'minio/minio:RELEASE.2018'",
+				"minio: image: expected 'minio/mc': actual 'minio/minio:RELEASE.2018'",
+			},
+		},
+		"drifts - +ve test case - pods of a pod component": {
+			kind:     "pod",
+			expect:   meta.Expectation{Replicas: intPtr(3), MinReady: intPtr(2), Phase: "Running"},
+			objs:     []string{readyPod, pendingPod},
+			observed: true,
+			expected: []string{
+				"replicas: expected '3': actual '2'",
+				"minReady: expected '2': actual '1'",
+				"minio-2: phase: expected 'Running': actual 'Pending'",
+			},
+		},
+		"drifts - +ve test case - no pods are expected": {
+			kind:     "pod",
+			expect:   meta.Expectation{Replicas: intPtr(0)},
+			observed: true,
+		},
+		"drifts - +ve test case - no objects found": {
+			kind:     "pvc",
+			expect:   meta.Expectation{Phase: "Bound"},
+			expected: []string{"no objects found"},
+		},
+		"drifts - +ve test case - capacity of a pvc": {
+			kind:     "pvc",
+			expect:   meta.Expectation{Phase: "Bound", Capacity: "5G", StorageClass: "openebs-standard"},
+			objs:     []string{pvc},
+			observed: true,
+		},
+		"drifts - +ve test case - insufficient capacity of a pvc": {
+			kind:     "pvc",
+			expect:   meta.Expectation{Capacity: "6G", StorageClass: "standard"},
+			objs:     []string{pvc},
+			observed: true,
+			expected: []string{
+				"minio-pvc: storageClass: expected 'standard': actual 'openebs-standard'",
+				"minio-pvc: capacity: expected 'at least 6G': actual '5Gi'",
+			},
+		},
+		"drifts - +ve test case - observed state is not verified": {
+			kind:   "pvc",
+			expect: meta.Expectation{Phase: "Pending", Capacity: "6G"},
+			objs:   []string{pvc},
+		},
+		"drifts - -ve test case - invalid capacity": {
+			kind:   "pvc",
+			expect: meta.Expectation{Capacity: "5 gigs"},
+			objs:   []string{pvc},
+			isErr:  true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := meta.Component{Kind: mock.kind, Name: "minio", Expect: &mock.expect}
+			drifts, err := expectationDrifts(c, states(t, mock.objs...), mock.observed)
+			if mock.isErr != (err != nil) {
+				t.Fatalf("failed to get drifts: expected error '%t': actual '%v'", mock.isErr, err)
+			}
+			if !reflect.DeepEqual(drifts, mock.expected) {
+				t.Fatalf("failed to get drifts: expected '%v': actual '%v'", mock.expected, drifts)
+			}
+		})
+	}
+}
+
+func TestHasImage(t *testing.T) {
+	tests := map[string]struct {
+		images   []string
+		expected string
+		isFound  bool
+	}{
+		"has image - +ve test case - same image":           {images: []string{"minio/minio:latest"}, expected: "minio/minio:latest", isFound: true},
+		"has image - +ve test case - any tag":              {images: []string{"minio/mc", "minio/minio:latest"}, expected: "minio/minio", isFound: true},
+		"has image - +ve test case - any digest":           {images: []string{"minio/minio@sha256:1a2b"}, expected: "minio/minio", isFound: true},
+		"has image - +ve test case - registry with a port": {images: []string{"registry:5000/minio/minio:latest"}, expected: "registry:5000/minio/minio", isFound: true},
+		"has image - -ve test case - other tag":            {images: []string{"minio/minio:latest"}, expected: "minio/minio:edge"},
+		"has image - -ve test case - registry port only":   {images: []string{"registry:5000/minio/minio"}, expected: "registry"},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := hasImage(mock.images, mock.expected); actual != mock.isFound {
+				t.Fatalf("failed to find image '%s' in '%v': expected '%t': actual '%t'", mock.expected, mock.images, mock.isFound, actual)
+			}
+		})
+	}
+}
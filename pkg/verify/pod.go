@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+)
+
+// PodAccessor provides contracts to run commands in & copy files to or from
+// the pod of a component
+type PodAccessor interface {
+	// ExecInPod executes the command in the pod of the component that matches
+	// the alias
+	ExecInPod(alias string, cmd []string, stdin []byte) (kubectl.ExecResult, error)
+	// GetPodLogs fetches the logs of the pod of the component that matches the
+	// alias
+	GetPodLogs(alias string, since time.Duration, previous bool) (kubectl.LogsResult, error)
+	// CopyToPod copies the local src to dst in the pod of the component that
+	// matches the alias
+	CopyToPod(alias, src, dst string) (kubectl.CopyResult, error)
+	// CopyFromPod copies src in the pod of the component that matches the
+	// alias to the local dst
+	CopyFromPod(alias, src, dst string) (kubectl.CopyResult, error)
+}
+
+// ExecInPod executes the command in the oldest running pod of the component
+// that matches the alias
+func (v *KubeInstallVerify) ExecInPod(alias string, cmd []string, stdin []byte) (result kubectl.ExecResult, err error) {
+	c, pod, err := v.getRunningPod(alias)
+	if err != nil {
+		return
+	}
+
+	return kubectl.ExecInPod(kubectl.New().Namespace(c.Namespace), pod, c.Container, cmd, stdin)
+}
+
+// GetPodLogs fetches the logs of the oldest running pod of the component that
+// matches the alias
+func (v *KubeInstallVerify) GetPodLogs(alias string, since time.Duration, previous bool) (result kubectl.LogsResult, err error) {
+	c, pod, err := v.getRunningPod(alias)
+	if err != nil {
+		return
+	}
+
+	return kubectl.GetPodLogs(kubectl.New().Namespace(c.Namespace), pod, c.Container, since, previous)
+}
+
+// CopyToPod copies the local src to dst in the oldest running pod of the
+// component that matches the alias
+func (v *KubeInstallVerify) CopyToPod(alias, src, dst string) (result kubectl.CopyResult, err error) {
+	c, pod, err := v.getRunningPod(alias)
+	if err != nil {
+		return
+	}
+
+	return kubectl.CopyToPod(kubectl.New().Namespace(c.Namespace), src, pod, c.Container, dst)
+}
+
+// CopyFromPod copies src in the oldest running pod of the component that
+// matches the alias to the local dst
+func (v *KubeInstallVerify) CopyFromPod(alias, src, dst string) (result kubectl.CopyResult, err error) {
+	c, pod, err := v.getRunningPod(alias)
+	if err != nil {
+		return
+	}
+
+	return kubectl.CopyFromPod(kubectl.New().Namespace(c.Namespace), pod, c.Container, src, dst)
+}
+
+// getRunningPod returns the pod component that matches the alias along with
+// the name of its oldest running pod
+func (v *KubeInstallVerify) getRunningPod(alias string) (c meta.Component, pod string, err error) {
+	if err = v.refresh(); err != nil {
+		return
+	}
+	if v.installation == nil {
+		err = fmt.Errorf("failed to get running pod: installation object is nil")
+		return
+	}
+
+	c, err = v.installation.GetMatchingPodComponent(alias)
+	if err != nil {
+		return
+	}
+
+	pod, err = kubectl.GetOldestRunningPod(kubectl.New().Namespace(c.Namespace).Labels(c.Labels))
+	if err != nil {
+		return
+	}
+
+	if len(pod) == 0 {
+		err = fmt.Errorf("failed to get running pod: no running pod found for alias '%s'", alias)
+	}
+	return
+}
@@ -43,6 +43,11 @@ const (
 	MultiNodeClusterCond Condition = "is-multi-node-k8s-cluster"
 	// JobCompletedCond is a condition to check if job is completed
 	JobCompletedCond Condition = "is-job-completed"
+	// RunningCond is a condition to check if a pod component is running
+	RunningCond Condition = "is-running"
+	// NoWarningEventsCond is a condition to check if a component has no
+	// warning events other than the ones allowed against the component
+	NoWarningEventsCond Condition = "no-warning-events"
 )
 
 // Action type defines a action that can be applied against a component
@@ -132,42 +137,75 @@ type AllVerifier interface {
 // KubeInstallVerify provides methods that handles verification related logic of
 // an installation within kubernetes e.g. application, deployment, operator, etc
 type KubeInstallVerify struct {
+	// template is the install file that is rendered with the values set in
+	// the scenario before each verification
+	template *meta.Template
 	// installation is the set of components that determine the install
 	installation *meta.Installation
 }
 
 // NewKubeInstallVerify provides a new instance of NewKubeInstallVerify based on
-// the provided install file
+// the provided install file. The install file is rendered once it is verified
+// so that it can refer to the values set earlier in the scenario; see
+// meta.Template.
 func NewKubeInstallVerify(file meta.InstallFile) (*KubeInstallVerify, error) {
-	i, err := meta.Load(file)
+	t, err := meta.NewTemplate(file)
 	if err != nil {
 		return nil, err
 	}
 
 	return &KubeInstallVerify{
-		installation: i,
+		template: t,
 	}, nil
 }
 
-// IsDeployed evaluates if all components of the installation are deployed
+// refresh renders the install file with the current values of the scenario
+// if these changed since it was last rendered
+func (v *KubeInstallVerify) refresh() (err error) {
+	if v.template == nil {
+		return
+	}
+
+	i, err := v.template.Installation()
+	if err != nil {
+		return
+	}
+	v.installation = i
+	return
+}
+
+// IsDeployed evaluates if all components of the installation are deployed.
+// The components with expectations are verified to have the expected desired
+// state e.g. replica count & images. The components are verified in the order
+// of their dependencies; see verifyInOrder.
 func (v *KubeInstallVerify) IsDeployed() (yes bool, err error) {
+	if err = v.refresh(); err != nil {
+		return
+	}
 	if v.installation == nil {
 		err = fmt.Errorf("failed to check IsDeployed: installation object is nil")
 		return
 	}
 
-	for _, component := range v.installation.Components {
+	return v.verifyInOrder(func(component meta.Component) (yes bool, err error) {
 		yes, err = isComponentDeployed(component)
-		if err != nil {
-			break
+		if err == nil && !yes {
+			// the components that depend on this component are blocked &
+			// hence the reason is reported
+			err = fmt.Errorf("component '%s' '%s' is not deployed", component.Kind, componentID(component))
 		}
-	}
-
-	return
+		if err == nil {
+			yes, err = isComponentAsExpected(component, false)
+		}
+		return
+	})
 }
 
 // IsDeleted evaluates if all components of the installation are deleted
 func (v *KubeInstallVerify) IsDeleted() (yes bool, err error) {
+	if err = v.refresh(); err != nil {
+		return
+	}
 	if v.installation == nil {
 		err = fmt.Errorf("failed to check IsDeleted: installation object is nil")
 		return
@@ -183,58 +221,153 @@ func (v *KubeInstallVerify) IsDeleted() (yes bool, err error) {
 	return
 }
 
-// IsRunning evaluates if all components of the installation are running
+// IsRunning evaluates if all pod components of the installation are running.
+// All the components with expectations are verified to have the expected
+// desired & observed state e.g. ready replicas, phase & conditions. The
+// components are verified in the order of their dependencies; see
+// verifyInOrder.
 func (v *KubeInstallVerify) IsRunning() (yes bool, err error) {
+	if err = v.refresh(); err != nil {
+		return
+	}
 	if v.installation == nil {
 		err = fmt.Errorf("failed to check IsRunning: installation object is nil")
 		return
 	}
 
-	for _, component := range v.installation.Components {
-		if component.Kind != "pod" {
-			continue
+	return v.verifyInOrder(func(component meta.Component) (yes bool, err error) {
+		if component.Kind != "pod" && !component.Expect.IsSet() {
+			return true, nil
 		}
 
-		yes, err = isPodComponentRunning(component)
-		if err != nil {
-			break
+		yes = true
+		if component.Kind == "pod" {
+			yes, err = isPodComponentRunning(component)
+		}
+		if err == nil {
+			yes, err = isComponentAsExpected(component, true)
+		}
+		return
+	})
+}
+
+// verifyInOrder verifies each component of the installation via the provided
+// check. A component is verified after the components it depends on. If any
+// of these dependencies fail the check, the component is not verified & is
+// reported as blocked by this dependency instead.
+//
+// NOTE:
+//  The errors of all the failed & blocked components are reported together.
+// The failed components are recorded against this error so that their recent
+// events can be appended once the verification gives up.
+func (v *KubeInstallVerify) verifyInOrder(check func(meta.Component) (bool, error)) (yes bool, err error) {
+	components, err := v.installation.Ordered()
+	if err != nil {
+		return
+	}
+
+	var errs []error
+	var checked []meta.Component
+	failed := map[string]bool{}
+	yes = true
+	for _, component := range components {
+		var cerr error
+		ok := false
+		if dep := blockedBy(component, failed); len(dep) != 0 {
+			cerr = fmt.Errorf("component '%s' '%s' is blocked by '%s'", component.Kind, componentID(component), dep)
+		} else {
+			ok, cerr = check(component)
+			if cerr != nil {
+				checked = append(checked, component)
+			}
+		}
+
+		if !ok {
+			yes = false
+			if len(component.Alias) != 0 {
+				failed[component.Alias] = true
+			}
+		}
+		if cerr != nil {
+			errs = append(errs, cerr)
 		}
 	}
 
+	switch {
+	case len(errs) == 1:
+		err = errs[0]
+	case len(errs) > 1:
+		var msgs []string
+		for _, e := range errs {
+			msgs = append(msgs, e.Error())
+		}
+		err = fmt.Errorf("%d components failed verification:\n%s", len(errs), strings.Join(msgs, "\n"))
+	}
+	err = againstComponents(err, checked...)
 	return
 }
 
-// IsCondition evaluates if specific components satisfies the condition
+// blockedBy returns the alias of the first dependency of the component that
+// failed. An empty alias implies none of its dependencies failed.
+func blockedBy(component meta.Component, failed map[string]bool) string {
+	for _, alias := range component.DependsOn {
+		if failed[alias] {
+			return alias
+		}
+	}
+	return ""
+}
+
+// IsCondition evaluates if specific components satisfies the condition. These
+// components are recorded against the error, if any; see WithRecentEvents.
 func (v *KubeInstallVerify) IsCondition(alias string, condition Condition) (yes bool, err error) {
+	if err = v.refresh(); err != nil {
+		return
+	}
 	switch condition {
 	case UniqueNodeCond:
-		return v.isEachComponentOnUniqueNode(alias)
+		yes, err = v.isEachComponentOnUniqueNode(alias)
 	case ThreeReplicasCond:
-		return v.hasComponentThreeReplicas(alias)
+		yes, err = v.hasComponentThreeReplicas(alias)
 	case PVCBoundCond:
-		return v.isPVCBound(alias)
+		yes, err = v.isPVCBound(alias)
 	case PVCUnBoundCond:
-		return v.isPVCUnBound(alias)
+		yes, err = v.isPVCUnBound(alias)
 	case JobCompletedCond:
-		return v.isJobCompleted(alias)
+		yes, err = v.isJobCompleted(alias)
+	case RunningCond:
+		yes, err = v.isPodRunning(alias)
+	case NoWarningEventsCond:
+		// the error lists the offending events
+		return v.hasNoWarningEvents(alias)
 	default:
 		err = fmt.Errorf("condition '%s' is not supported", condition)
+		return
 	}
+
+	err = againstComponents(err, v.getAliasComponents(alias)...)
 	return
 }
 
-// IsAction evaluates if specific components satisfies the action
+// IsAction evaluates if specific components satisfies the action. These
+// components are recorded against the error, if any; see WithRecentEvents.
 func (v *KubeInstallVerify) IsAction(alias string, action Action) (yes bool, err error) {
+	if err = v.refresh(); err != nil {
+		return
+	}
 	switch action {
 	case DeleteAnyPodAction:
-		return v.isDeleteAnyRunningPod(alias)
+		yes, err = v.isDeleteAnyRunningPod(alias)
 	case DeleteOldestPodAction:
-		return v.isDeleteOldestRunningPod(alias)
+		yes, err = v.isDeleteOldestRunningPod(alias)
 	case CordonNodeWithOldestPodAction:
-		return v.isCordonNodeWithOldestPod(alias)
+		yes, err = v.isCordonNodeWithOldestPod(alias)
 	default:
 		err = fmt.Errorf("action '%s' is not supported", action)
+		return
 	}
+
+	err = againstComponents(err, v.getAliasComponents(alias)...)
 	return
 }
 
@@ -248,7 +381,7 @@ func (v *KubeInstallVerify) isDeleteAnyRunningPod(alias string) (yes bool, err e
 	}
 
 	if len(strings.TrimSpace(c.Labels)) == 0 {
-		err = fmt.Errorf("unable to fetch component '%s' '%s': component labels are missing '%s'", c.Kind, alias)
+		err = fmt.Errorf("unable to fetch component '%s' '%s': component labels are missing", c.Kind, alias)
 		return
 	}
 
@@ -351,9 +484,30 @@ func (v *KubeInstallVerify) isCordonNodeWithOldestPod(alias string) (yes bool, e
 	return
 }
 
-// hasComponentThreeReplicas flags if a component has three replicas
+// hasComponentThreeReplicas flags if the components with the provided alias
+// have three ready replicas. A deployment, statefulset or replicaset is
+// expected to have three replicas all of which are ready whereas a pod
+// component is expected to be made up of three ready pods.
 func (v *KubeInstallVerify) hasComponentThreeReplicas(alias string) (yes bool, err error) {
-	err = fmt.Errorf("hasComponentThreeReplicas is not implemented")
+	components := v.getAliasComponents(alias)
+	if len(components) == 0 {
+		err = fmt.Errorf("unable to verify three replicas: no component with alias '%s'", alias)
+		return
+	}
+
+	three := 3
+	for _, c := range components {
+		if !util.IsBarePod(c.Kind) && !util.IsReplicated(c.Kind) {
+			err = fmt.Errorf("unable to verify three replicas: component '%s' '%s' does not have replicas", c.Kind, componentID(c))
+			return
+		}
+
+		c.Expect = &meta.Expectation{Replicas: &three, MinReady: &three}
+		yes, err = isComponentAsExpected(c, true)
+		if err != nil {
+			return
+		}
+	}
 	return
 }
 
@@ -368,6 +522,16 @@ func (v *KubeInstallVerify) isJobCompleted(alias string) (yes bool, err error) {
 	return kubectl.AreJobPodsCompleted(k)
 }
 
+// isPodRunning flags if a pod component is running
+func (v *KubeInstallVerify) isPodRunning(alias string) (yes bool, err error) {
+	c, err := v.installation.GetMatchingPodComponent(alias)
+	if err != nil {
+		return
+	}
+
+	return isPodComponentRunning(c)
+}
+
 // isPVCBound flags if a PVC component is bound
 func (v *KubeInstallVerify) isPVCBound(alias string) (yes bool, err error) {
 	var vol string
@@ -484,10 +648,15 @@ func (v *KubeInstallVerify) getPVCVolume(alias string) (op string, err error) {
 		return
 	}
 
-	op, err = kubectl.New().
+	pvc, err := kubectl.New().
 		Namespace(filtered[0].Namespace).
-		Run([]string{"get", "pvc", filtered[0].Name, "-o", "jsonpath='{.spec.volumeName}'"})
+		Client().
+		GetPVC(filtered[0].Name)
+	if err != nil {
+		return
+	}
 
+	op = pvc.Spec.VolumeName
 	return
 }
 
@@ -514,8 +683,6 @@ func isPodComponentRunning(component meta.Component) (yes bool, err error) {
 
 // isComponentDeployed flags if a particular component is deployed
 func isComponentDeployed(component meta.Component) (yes bool, err error) {
-	var op string
-
 	if len(strings.TrimSpace(component.Kind)) == 0 {
 		err = fmt.Errorf("unable to verify component deploy status: component kind is missing: component '%#v'", component)
 		return
@@ -529,11 +696,10 @@ func isComponentDeployed(component meta.Component) (yes bool, err error) {
 
 	// check via name
 	if len(strings.TrimSpace(component.Name)) != 0 {
-		op, err = kubectl.New().
-			Namespace(component.Namespace).
-			Run([]string{"get", component.Kind, component.Name, "-o", "jsonpath='{.metadata.name}'"})
+		var obj kubectl.Object
+		obj, err = kubectl.GetObject(kubectl.New().Namespace(component.Namespace), component.Kind, component.Name)
 
-		if err == nil && len(strings.TrimSpace(op)) != 0 {
+		if err == nil && len(obj.Metadata.Name) != 0 {
 			// yes, it is deployed
 			yes = true
 		}
@@ -541,12 +707,9 @@ func isComponentDeployed(component meta.Component) (yes bool, err error) {
 	}
 
 	// or check via labels
-	op, err = kubectl.New().
-		Namespace(component.Namespace).
-		Labels(component.Labels).
-		Run([]string{"get", component.Kind, "-o", "jsonpath='{.items[*].metadata.name}'"})
+	objs, err := kubectl.ListObjects(kubectl.New().Namespace(component.Namespace).Labels(component.Labels), component.Kind)
 
-	if err == nil && len(strings.TrimSpace(op)) != 0 {
+	if err == nil && len(objs) != 0 {
 		// yes, it is deployed
 		yes = true
 	}
@@ -579,7 +742,7 @@ func isComponentDeleted(component meta.Component) (yes bool, err error) {
 			return
 		}
 
-		if strings.Contains(err.Error(), "(NotFound)") {
+		if kubectl.IsNotFound(err) {
 			// yes, it is deleted
 			yes = true
 			// We wanted to make sure that this component was deleted.
@@ -590,27 +753,27 @@ func isComponentDeleted(component meta.Component) (yes bool, err error) {
 			return
 		}
 
-		err = fmt.Errorf("unable to verify delete status of component '%#v': output '%s'", component, op)
+		err = fmt.Errorf("unable to verify delete status of component '%#v': %s", component, err)
 		return
 	}
 
 	// or check via labels
-	op, err = kubectl.New().
-		Namespace(component.Namespace).
-		Labels(component.Labels).
-		Run([]string{"get", component.Kind})
-
+	objs, err := kubectl.ListObjects(kubectl.New().Namespace(component.Namespace).Labels(component.Labels), component.Kind)
 	if err != nil {
 		return
 	}
 
-	if len(strings.TrimSpace(op)) == 0 || strings.Contains(op, "No resources found") {
+	if len(objs) == 0 {
 		// yes, it is deleted
 		yes = true
 		return
 	}
 
-	err = fmt.Errorf("unable to verify delete status of component '%#v': output '%s'", component, op)
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.Metadata.Name)
+	}
+	err = fmt.Errorf("unable to verify delete status of component '%#v': objects '%v' still exist", component, names)
 	return
 }
 
@@ -0,0 +1,155 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+)
+
+func TestBlockedBy(t *testing.T) {
+	tests := map[string]struct {
+		dependsOn []string
+		failed    map[string]bool
+		expected  string
+	}{
+		"blocked by - +ve test case - no dependencies": {
+			failed: map[string]bool{"pvc": true},
+		},
+		"blocked by - +ve test case - dependencies did not fail": {
+			dependsOn: []string{"sc", "pvc"},
+			failed:    map[string]bool{"svc": true},
+		},
+		"blocked by - +ve test case - first failed dependency": {
+			dependsOn: []string{"sc", "pvc", "svc"},
+			failed:    map[string]bool{"svc": true, "pvc": true},
+			expected:  "pvc",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := meta.Component{Kind: "pod", Labels: "app=minio", DependsOn: mock.dependsOn}
+			if actual := blockedBy(c, mock.failed); actual != mock.expected {
+				t.Fatalf("failed to get blocking dependency: expected '%s': actual '%s'", mock.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVerifyInOrder(t *testing.T) {
+	// the pod is listed first but depends on the pvc that depends on the sc
+	components := []meta.Component{
+		{Kind: "pod", Labels: "app=minio", Alias: "app", DependsOn: []string{"pvc"}},
+		{Kind: "service", Name: "minio", Alias: "svc"},
+		{Kind: "pvc", Name: "minio-pvc", Alias: "pvc", DependsOn: []string{"sc"}},
+		{Kind: "sc", Name: "openebs-standard", Alias: "sc"},
+	}
+
+	tests := map[string]struct {
+		failing map[string]error
+		checked []string
+		isOK    bool
+		errs    []string
+		failed  []string
+		isCycle bool
+	}{
+		"verify in order - +ve test case - dependencies are checked first": {
+			checked: []string{"svc", "sc", "pvc", "app"},
+			isOK:    true,
+		},
+		"verify in order - +ve test case - dependents are blocked": {
+			failing: map[string]error{"sc": fmt.Errorf("sc 'openebs-standard' is not found")},
+			checked: []string{"svc", "sc"},
+			errs: []string{
+				"sc 'openebs-standard' is not found",
+				"component 'pvc' 'minio-pvc' is blocked by 'sc'",
+				"component 'pod' 'app=minio' is blocked by 'pvc'",
+			},
+			failed: []string{"sc"},
+		},
+		"verify in order - +ve test case - independent failures": {
+			failing: map[string]error{
+				"svc": fmt.Errorf("service 'minio' is not found"),
+				"app": fmt.Errorf("pod(s) are not running"),
+			},
+			checked: []string{"svc", "sc", "pvc", "app"},
+			errs:    []string{"service 'minio' is not found", "pod(s) are not running"},
+			failed:  []string{"svc", "app"},
+		},
+		"verify in order - +ve test case - failure without an error blocks dependents": {
+			failing: map[string]error{"pvc": nil},
+			checked: []string{"svc", "sc", "pvc"},
+			errs:    []string{"component 'pod' 'app=minio' is blocked by 'pvc'"},
+		},
+		"verify in order - -ve test case - dependency cycle": {
+			isCycle: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			installation := &meta.Installation{Components: append([]meta.Component(nil), components...)}
+			if mock.isCycle {
+				installation.Components[3].DependsOn = []string{"app"}
+			}
+			v := &KubeInstallVerify{installation: installation}
+
+			var checked []string
+			yes, err := v.verifyInOrder(func(c meta.Component) (bool, error) {
+				checked = append(checked, c.Alias)
+				if ferr, ok := mock.failing[c.Alias]; ok {
+					return false, ferr
+				}
+				return true, nil
+			})
+
+			if mock.isCycle {
+				if err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+					t.Fatalf("failed to verify in order: expected 'dependency cycle': actual '%v'", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(checked, mock.checked) {
+				t.Fatalf("failed to verify in order: expected checks '%v': actual '%v'", mock.checked, checked)
+			}
+			if yes != mock.isOK {
+				t.Fatalf("failed to verify in order: expected '%t': actual '%t'", mock.isOK, yes)
+			}
+			for _, e := range mock.errs {
+				if err == nil || !strings.Contains(err.Error(), e) {
+					t.Fatalf("failed to verify in order: expected error '%s': actual '%v'", e, err)
+				}
+			}
+			if len(mock.errs) == 0 && err != nil {
+				t.Fatalf("failed to verify in order: expected 'no error': actual '%s'", err)
+			}
+
+			var failed []string
+			for _, c := range failedComponents(err) {
+				failed = append(failed, c.Alias)
+			}
+			if !reflect.DeepEqual(failed, mock.failed) {
+				t.Fatalf("failed to verify in order: expected failed components '%v': actual '%v'", mock.failed, failed)
+			}
+		})
+	}
+}
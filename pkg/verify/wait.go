@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"time"
+
+	"github.com/AmitKumarDas/elitmus/pkg/wait"
+)
+
+// PollInterval is the interval between the successive verifications done by
+// the wait based verifiers
+const PollInterval = 5 * time.Second
+
+// Eventually verifies the check every PollInterval till it succeeds within the
+// provided timeout e.g. "180s", "5m". The recent events of the components that
+// the last check failed against are appended to the error.
+func Eventually(timeout string, check wait.CheckFunc) (err error) {
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return
+	}
+
+	last := &lastError{check: check}
+	err = wait.Eventually(d, PollInterval, last.run)
+	return withEvents(err, failedComponents(last.err)...)
+}
+
+// Consistently verifies the check every PollInterval & expects it to succeed
+// for the entire duration e.g. "60s", "5m". The recent events of the
+// components that the failed check is about are appended to the error.
+func Consistently(duration string, check wait.CheckFunc) (err error) {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return
+	}
+
+	last := &lastError{check: check}
+	err = wait.Consistently(d, PollInterval, last.run)
+	return withEvents(err, failedComponents(last.err)...)
+}
+
+// lastError records the error of the last evaluation of the check. This lets
+// the events be fetched once after the wait gives up rather than on every
+// failed evaluation.
+type lastError struct {
+	check wait.CheckFunc
+	err   error
+}
+
+func (l *lastError) run() (ok bool, err error) {
+	ok, err = l.check()
+	l.err = err
+	return
+}
+
+// EventuallyRunning verifies if the entity is running within the provided
+// timeout
+func EventuallyRunning(v RunVerifier, timeout string) error {
+	return Eventually(timeout, v.IsRunning)
+}
+
+// ConsistentlyRunning verifies if the entity keeps running for the provided
+// duration
+func ConsistentlyRunning(v RunVerifier, duration string) error {
+	return Consistently(duration, v.IsRunning)
+}
+
+// EventuallyCondition verifies if the entities with the provided alias
+// satisfy the condition within the provided timeout
+func EventuallyCondition(v ConditionVerifier, alias string, condition Condition, timeout string) error {
+	return Eventually(timeout, func() (bool, error) {
+		return v.IsCondition(alias, condition)
+	})
+}
+
+// EventuallyStep verifies the step every PollInterval till it succeeds within
+// the provided timeout. A step is any verification that fails with an error.
+func EventuallyStep(timeout string, step func() error) error {
+	return Eventually(timeout, func() (bool, error) {
+		err := step()
+		return err == nil, err
+	})
+}
+
+// ConsistentlyStep verifies the step every PollInterval & expects it to
+// succeed for the entire duration
+func ConsistentlyStep(duration string, step func() error) error {
+	return Consistently(duration, func() (bool, error) {
+		err := step()
+		return err == nil, err
+	})
+}
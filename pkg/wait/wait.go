@@ -0,0 +1,145 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wait
+
+import (
+	"fmt"
+	"time"
+)
+
+// Clock provides the contract(s) to tell the current time & to pause the
+// current goroutine. This enables testing the wait logic without actually
+// waiting.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// Sleep pauses the current goroutine for the provided duration
+	Sleep(d time.Duration)
+}
+
+// realClock is the Clock backed by the system time
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// RealClock is the Clock backed by the system time
+var RealClock Clock = realClock{}
+
+// CheckFunc is a check that is evaluated repeatedly by the wait logic. A
+// check is satisfied when it returns true with no error.
+type CheckFunc func() (bool, error)
+
+// Waiter evaluates checks repeatedly based on its clock
+type Waiter struct {
+	// clock is used to tell time & to pause between the checks
+	clock Clock
+}
+
+// NewWaiter returns a new instance of Waiter based on the provided clock
+func NewWaiter(clock Clock) *Waiter {
+	if clock == nil {
+		clock = RealClock
+	}
+
+	return &Waiter{clock: clock}
+}
+
+// Eventually evaluates the check every interval till it is satisfied. It
+// returns an error if the check is not satisfied within the timeout. The error
+// of the last evaluation is part of the returned error.
+func (w *Waiter) Eventually(timeout, interval time.Duration, check CheckFunc) (err error) {
+	if interval <= 0 {
+		err = fmt.Errorf("failed to wait eventually: invalid interval '%s'", interval)
+		return
+	}
+
+	start := w.clock.Now()
+	for attempt := 1; ; attempt++ {
+		var ok bool
+		ok, err = check()
+		if ok && err == nil {
+			return
+		}
+
+		elapsed := w.clock.Now().Sub(start)
+		if elapsed >= timeout {
+			if err == nil {
+				err = fmt.Errorf("check returned false")
+			}
+			err = fmt.Errorf("failed to satisfy check within '%s' after '%d' attempt(s): %s", timeout, attempt, err)
+			return
+		}
+
+		// do not sleep past the timeout
+		w.clock.Sleep(minDuration(interval, timeout-elapsed))
+	}
+}
+
+// Consistently evaluates the check every interval for the entire duration.
+// It returns an error as soon as the check is not satisfied.
+func (w *Waiter) Consistently(duration, interval time.Duration, check CheckFunc) (err error) {
+	if interval <= 0 {
+		err = fmt.Errorf("failed to wait consistently: invalid interval '%s'", interval)
+		return
+	}
+
+	start := w.clock.Now()
+	for {
+		ok, err := check()
+		elapsed := w.clock.Now().Sub(start)
+		if err != nil {
+			return fmt.Errorf("failed to satisfy check consistently after '%s' of '%s': %s", elapsed, duration, err)
+		}
+
+		if !ok {
+			return fmt.Errorf("failed to satisfy check consistently after '%s' of '%s': check returned false", elapsed, duration)
+		}
+
+		if elapsed >= duration {
+			return nil
+		}
+
+		// do not sleep past the duration
+		w.clock.Sleep(minDuration(interval, duration-elapsed))
+	}
+}
+
+// Eventually evaluates the check every interval till it is satisfied within
+// the timeout. This makes use of the system time.
+func Eventually(timeout, interval time.Duration, check CheckFunc) error {
+	return NewWaiter(RealClock).Eventually(timeout, interval, check)
+}
+
+// Consistently evaluates the check every interval for the entire duration.
+// This makes use of the system time.
+func Consistently(duration, interval time.Duration, check CheckFunc) error {
+	return NewWaiter(RealClock).Consistently(duration, interval, check)
+}
+
+// minDuration returns the smaller of the provided durations
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wait
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time moves only when it sleeps
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+// checkAfter returns a check that is satisfied from the provided attempt
+// onwards & fails with an error before it
+func checkAfter(attempt int, calls *int) CheckFunc {
+	return func() (bool, error) {
+		*calls++
+		if *calls < attempt {
+			return false, fmt.Errorf("attempt '%d' is not yet ready", *calls)
+		}
+		return true, nil
+	}
+}
+
+func TestEventually(t *testing.T) {
+	tests := map[string]struct {
+		timeout  time.Duration
+		interval time.Duration
+		ready    int
+		calls    int
+		sleeps   []time.Duration
+		isErr    bool
+	}{
+		"eventually - positive test case - satisfied at first attempt": {
+			timeout:  time.Minute,
+			interval: 10 * time.Second,
+			ready:    1,
+			calls:    1,
+			sleeps:   nil,
+			isErr:    false,
+		},
+		"eventually - positive test case - satisfied at third attempt": {
+			timeout:  time.Minute,
+			interval: 10 * time.Second,
+			ready:    3,
+			calls:    3,
+			sleeps:   []time.Duration{10 * time.Second, 10 * time.Second},
+			isErr:    false,
+		},
+		"eventually - negative test case - last sleep is capped at timeout": {
+			timeout:  25 * time.Second,
+			interval: 10 * time.Second,
+			ready:    10,
+			calls:    4,
+			sleeps:   []time.Duration{10 * time.Second, 10 * time.Second, 5 * time.Second},
+			isErr:    true,
+		},
+		"eventually - negative test case - invalid interval": {
+			timeout:  time.Minute,
+			interval: 0,
+			ready:    1,
+			calls:    0,
+			sleeps:   nil,
+			isErr:    true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			calls := 0
+
+			err := NewWaiter(clock).Eventually(mock.timeout, mock.interval, checkAfter(mock.ready, &calls))
+
+			if err != nil && !mock.isErr {
+				t.Fatalf("failed to wait eventually: expected 'no error': actual '%s'", err)
+			}
+
+			if err == nil && mock.isErr {
+				t.Fatalf("failed to wait eventually: expected 'error': actual 'no error'")
+			}
+
+			if calls != mock.calls {
+				t.Fatalf("failed to wait eventually: expected '%d' calls: actual '%d' calls", mock.calls, calls)
+			}
+
+			if fmt.Sprint(clock.sleeps) != fmt.Sprint(mock.sleeps) {
+				t.Fatalf("failed to wait eventually: expected sleeps '%v': actual sleeps '%v'", mock.sleeps, clock.sleeps)
+			}
+		})
+	}
+}
+
+func TestConsistently(t *testing.T) {
+	tests := map[string]struct {
+		duration time.Duration
+		interval time.Duration
+		failAt   int
+		calls    int
+		isErr    bool
+	}{
+		"consistently - positive test case - satisfied throughout": {
+			duration: 30 * time.Second,
+			interval: 10 * time.Second,
+			failAt:   0,
+			calls:    4,
+			isErr:    false,
+		},
+		"consistently - positive test case - last sleep is capped at duration": {
+			duration: 25 * time.Second,
+			interval: 10 * time.Second,
+			failAt:   0,
+			calls:    4,
+			isErr:    false,
+		},
+		"consistently - negative test case - fails midway": {
+			duration: 30 * time.Second,
+			interval: 10 * time.Second,
+			failAt:   2,
+			calls:    2,
+			isErr:    true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			calls := 0
+
+			err := NewWaiter(clock).Consistently(mock.duration, mock.interval, func() (bool, error) {
+				calls++
+				return calls != mock.failAt, nil
+			})
+
+			if err != nil && !mock.isErr {
+				t.Fatalf("failed to wait consistently: expected 'no error': actual '%s'", err)
+			}
+
+			if err == nil && mock.isErr {
+				t.Fatalf("failed to wait consistently: expected 'error': actual 'no error'")
+			}
+
+			if calls != mock.calls {
+				t.Fatalf("failed to wait consistently: expected '%d' calls: actual '%d' calls", mock.calls, calls)
+			}
+		})
+	}
+}
@@ -6,14 +6,13 @@ Feature: Test deployment of Minio on Kubernetes PV
   Scenario: launch Minio on PV
     Given I have a kubernetes cluster with volume operator installed
     When I launch minio application on volume
-    Then wait for "180s"
-    And verify minio application is launched successfully on volume
+    Then verify "app-pod" is running within "180s"
+    And verify minio application is launched successfully on volume within "180s"
     And verify PVC is bound
     And verify PV is deployed
 
   Scenario: delete Minio instance
     Given minio application is launched successfully on volume
     When I delete minio instance along with volume
-    Then wait for "60s"
-    And verify minio application is deleted
-    And verify PV is deleted
+    Then verify minio application is deleted within "60s"
+    And verify PV is deleted within "60s"
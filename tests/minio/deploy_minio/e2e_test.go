@@ -19,6 +19,7 @@ package main
 import (
 	"fmt"
 
+	"github.com/AmitKumarDas/elitmus/pkg/hook"
 	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
 	"github.com/AmitKumarDas/elitmus/pkg/meta"
 	"github.com/AmitKumarDas/elitmus/pkg/time"
@@ -107,10 +108,6 @@ func (e2e *MinioLaunch) withVolumeVerifier(f *gherkin.Feature) {
 	e2e.volVerifier = v
 }
 
-func (e2e *MinioLaunch) tearDown(f *gherkin.Feature) {
-	kubectl.New().Run([]string{"delete", "-f", string(ApplicationKF)})
-}
-
 func (e2e *MinioLaunch) iHaveAKubernetesClusterWithVolumeOperatorInstalled() (err error) {
 	kubeVerifier := verify.NewKubernetesVerify()
 	// checks if kubernetes cluster is available & is connected
@@ -141,6 +138,16 @@ func (e2e *MinioLaunch) waitFor(duration string) (err error) {
 	return
 }
 
+func (e2e *MinioLaunch) verifyAliasIsRunningWithin(alias, timeout string) (err error) {
+	if e2e.appVerifier == nil {
+		err = fmt.Errorf("nil application verifier: possible error '%s'", e2e.errors[ApplicationVerifyFileEI])
+		return
+	}
+
+	// is pod component running
+	return verify.EventuallyCondition(e2e.appVerifier, alias, verify.RunningCond, timeout)
+}
+
 func (e2e *MinioLaunch) iLaunchMinioApplicationOnVolume() (err error) {
 	// do a kubectl apply of application yaml
 	_, err = kubectl.New().Run([]string{"apply", "-f", string(ApplicationKF)})
@@ -157,6 +164,10 @@ func (e2e *MinioLaunch) verifyMinioApplicationIsLaunchedSuccessfullyOnVolume() (
 	return e2e.verifyAllVolumeReplicasAreRunning()
 }
 
+func (e2e *MinioLaunch) verifyMinioApplicationIsLaunchedSuccessfullyOnVolumeWithin(timeout string) (err error) {
+	return verify.EventuallyStep(timeout, e2e.verifyMinioApplicationIsLaunchedSuccessfullyOnVolume)
+}
+
 func (e2e *MinioLaunch) verifyApplicationIsRunning() (err error) {
 	if e2e.appVerifier == nil {
 		err = fmt.Errorf("nil application verifier: possible error '%s'", e2e.errors[ApplicationVerifyFileEI])
@@ -240,6 +251,10 @@ func (e2e *MinioLaunch) verifyMinioApplicationIsDeleted() (err error) {
 	return
 }
 
+func (e2e *MinioLaunch) verifyMinioApplicationIsDeletedWithin(timeout string) (err error) {
+	return verify.EventuallyStep(timeout, e2e.verifyMinioApplicationIsDeleted)
+}
+
 func (e2e *MinioLaunch) verifyPVIsDeleted() (err error) {
 	if e2e.volVerifier == nil {
 		err = fmt.Errorf("nil volume verifier: possible error '%s'", e2e.errors[VolumeVerifyFileEI])
@@ -251,25 +266,42 @@ func (e2e *MinioLaunch) verifyPVIsDeleted() (err error) {
 	return
 }
 
+func (e2e *MinioLaunch) verifyPVIsDeletedWithin(timeout string) (err error) {
+	return verify.EventuallyStep(timeout, e2e.verifyPVIsDeleted)
+}
+
 func FeatureContext(s *godog.Suite) {
 	e2e := &MinioLaunch{
 		errors: map[errorIdentity]error{},
 	}
 
+	// track the feature, scenario & step being run
+	hook.Scope(s)
+	// run the feature in its own namespace if set in the environment; this
+	// needs to be registered before the verify files are loaded
+	hook.EphemeralNamespace(s)
+	// gather the diagnostics of a failed step
+	hook.Diagnostics(s)
+
 	s.BeforeFeature(e2e.withOperatorVerifier)
 	s.BeforeFeature(e2e.withApplicationVerifier)
 	s.BeforeFeature(e2e.withVolumeVerifier)
 
-	s.AfterFeature(e2e.tearDown)
+	// delete what was applied & restore the nodes after the feature run
+	hook.Teardown(s)
 
 	s.Step(`^I have a kubernetes cluster with volume operator installed$`, e2e.iHaveAKubernetesClusterWithVolumeOperatorInstalled)
 	s.Step(`^wait for "([^"]*)"$`, e2e.waitFor)
 	s.Step(`^I launch minio application on volume$`, e2e.iLaunchMinioApplicationOnVolume)
+	s.Step(`^verify "([^"]*)" is running within "([^"]*)"$`, e2e.verifyAliasIsRunningWithin)
 	s.Step(`^verify minio application is launched successfully on volume$`, e2e.verifyMinioApplicationIsLaunchedSuccessfullyOnVolume)
+	s.Step(`^verify minio application is launched successfully on volume within "([^"]*)"$`, e2e.verifyMinioApplicationIsLaunchedSuccessfullyOnVolumeWithin)
 	s.Step(`^verify PVC is bound$`, e2e.verifyPVCIsBound)
 	s.Step(`^verify PV is deployed$`, e2e.verifyPVIsDeployed)
 	s.Step(`^I delete minio instance along with volume$`, e2e.iDeleteMinioInstanceAlongWithVolume)
 	s.Step(`^verify minio application is deleted$`, e2e.verifyMinioApplicationIsDeleted)
+	s.Step(`^verify minio application is deleted within "([^"]*)"$`, e2e.verifyMinioApplicationIsDeletedWithin)
 	s.Step(`^verify PV is deleted$`, e2e.verifyPVIsDeleted)
+	s.Step(`^verify PV is deleted within "([^"]*)"$`, e2e.verifyPVIsDeletedWithin)
 	s.Step(`^minio application is launched successfully on volume$`, e2e.minioApplicationIsLaunchedSuccessfullyOnVolume)
 }
@@ -8,8 +8,7 @@ Feature: Test high availability on Minio on Kubernetes PV
     Given I have a kubernetes multi node cluster 
     And this cluster has volume operator installed
     When I launch minio application on volume
-    Then wait for "180s"
-    And verify minio application is launched successfully on volume
+    Then verify minio application is launched successfully on volume within "180s"
     And verify PVC is bound
     And verify PV is deployed
 
@@ -17,12 +16,11 @@ Feature: Test high availability on Minio on Kubernetes PV
     Given minio application is launched successfully on volume
     Then deploy minio client config set with minio server IP
     And launch minio client put job
-    And wait for "60s"
-    Then verify data is put to minio server
+    Then verify data is put to minio server within "60s"
+    And I write "litmus-ha-data" to file "/home/username/litmus-ha.txt" in "app-pod"
     And cordon the node that hosts the minio pod
     And delete this minio pod
-    And wait for "120s"
-    Then verify minio is redeployed successfully
+    Then verify minio is redeployed successfully within "120s"
+    And verify file "/home/username/litmus-ha.txt" in "app-pod" contains "litmus-ha-data" within "60s"
     And launch minio client get job
-    And wait for "60s"
-    And verify data is available at minio server
+    And verify data is available at minio server within "60s"
@@ -17,14 +17,17 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
 	"fmt"
-	"text/template"
+	"io/ioutil"
+	"net"
+	"net/http"
+	gotime "time"
 
-	"github.com/AmitKumarDas/elitmus/pkg/exec"
 	"github.com/AmitKumarDas/elitmus/pkg/fetch"
+	"github.com/AmitKumarDas/elitmus/pkg/hook"
 	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
 	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/steps"
 	"github.com/AmitKumarDas/elitmus/pkg/time"
 	"github.com/AmitKumarDas/elitmus/pkg/verify"
 	"github.com/DATA-DOG/godog"
@@ -49,7 +52,10 @@ const (
 	AppClientConfigVerifyFileEI errorIdentity = "application-client-config-verify-file-err"
 )
 
-const (
+// The files below are mounted from the config maps of the test. These are
+// variables so that the go test of this feature can use local copies; see
+// TestReplay.
+var (
 	// OperatorIF enables litmus to run checks & actions based on this volume
 	// operator verify file
 	OperatorIF meta.InstallFile = "/etc/e2e/operator-verify/operator-verify.yaml"
@@ -67,7 +73,7 @@ const (
 	VolumeIF meta.InstallFile = "/etc/e2e/volume-verify/volume-verify.yaml"
 )
 
-const (
+var (
 	// ApplicationKF is the file to launch the application. This file is applied
 	// via kubectl.
 	ApplicationKF kubectl.KubectlFile = "/etc/e2e/app-launch/app-launch.yaml"
@@ -79,13 +85,26 @@ const (
 	AppClientGetKF kubectl.KubectlFile = "/etc/e2e/app-client-get/app-client-get-job.yaml"
 )
 
-const (
+var (
 	// AppClientConfigTKF is the file to be deployed before launching the application.
 	// This file is templated & needs to be executed via go template & then
 	// applied via kubectl.
 	AppClientConfigTKF kubectl.TemplatedKubectlFile = "/etc/e2e/app-client-configs/app-client-configs.yaml"
 )
 
+const (
+	// MinioServerIPValue is the key of the minio server ip among the values
+	// of the scenario. The templated files refer to this ip as
+	// {{ .Values.minioServerIP }}.
+	MinioServerIPValue string = "minioServerIP"
+	// MinioServerAddressValue is the key of the local address that reaches
+	// the minio server among the values of the scenario. This is set only if
+	// litmus runs outside the cluster.
+	MinioServerAddressValue string = "minioServerAddress"
+	// MinioServerPort is the port of the minio service
+	MinioServerPort string = "9000"
+)
+
 const (
 	// PVCAlias is the alias name given to the application's pvc
 	//
@@ -137,6 +156,9 @@ type HAOnMinio struct {
 	appcJobVerifier verify.AllVerifier
 	// operatorVerifier instance enables verification of volume operator components
 	operatorVerifier verify.DeployRunVerifier
+	// forwards instance enables reaching the application from outside the
+	// cluster
+	forwards *steps.PortForwardSteps
 	// errors hold the previous error(s)
 	errors map[errorIdentity]error
 }
@@ -168,16 +190,6 @@ func (e2e *HAOnMinio) withVolumeVerifier(f *gherkin.Feature) {
 	e2e.volVerifier = v
 }
 
-// tearDown will delete the resources that were applied during the course of
-// test run
-func (e2e *HAOnMinio) tearDown(f *gherkin.Feature) {
-	kubectl.UnCordonAllNodes(true)
-	kubectl.New().Run([]string{"delete", "-f", string(ApplicationKF)})
-	kubectl.New().Run([]string{"delete", "-f", string(AppClientGetKF)})
-	kubectl.New().Run([]string{"delete", "-f", string(AppClientPutKF)})
-	kubectl.New().Run([]string{"delete", "-f", string(AppClientConfigTKF)})
-}
-
 func (e2e *HAOnMinio) iHaveAKubernetesMultiNodeCluster() (err error) {
 	kubeVerifier := verify.NewKubernetesVerify()
 	// checks if kubernetes cluster is available & is connected
@@ -264,6 +276,10 @@ func (e2e *HAOnMinio) verifyMinioApplicationIsLaunchedSuccessfullyOnVolume() (er
 	return e2e.verifyAllVolumeReplicasAreRunning()
 }
 
+func (e2e *HAOnMinio) verifyMinioApplicationIsLaunchedSuccessfullyOnVolumeWithin(timeout string) (err error) {
+	return verify.EventuallyStep(timeout, e2e.verifyMinioApplicationIsLaunchedSuccessfullyOnVolume)
+}
+
 func (e2e *HAOnMinio) verifyPVCIsBound() (err error) {
 	if e2e.appVerifier == nil {
 		err = fmt.Errorf("nil application verifier: possible error '%s'", e2e.errors[ApplicationVerifyFileEI])
@@ -327,6 +343,10 @@ func (e2e *HAOnMinio) verifyMinioIsRedeployedSuccessfully() (err error) {
 	return e2e.verifyApplicationIsRunning()
 }
 
+func (e2e *HAOnMinio) verifyMinioIsRedeployedSuccessfullyWithin(timeout string) (err error) {
+	return verify.EventuallyStep(timeout, e2e.verifyMinioIsRedeployedSuccessfully)
+}
+
 func (e2e *HAOnMinio) launchMinioClientGetJob() (err error) {
 	_, err = kubectl.New().Run([]string{"apply", "-f", string(AppClientGetKF)})
 	return
@@ -338,38 +358,32 @@ func (e2e *HAOnMinio) deployMinioClientConfigSetWithMinioServerIP() (err error)
 		return
 	}
 
-	// fetch service ip
-	data, err := f.Fetch(AppServiceAlias, fetch.ServiceIPProperty)
+	// fetch service ip as the minio server ip of the rendered files; the
+	// minio client jobs run within the cluster & hence reach the minio server
+	// via this ip
+	err = fetch.FetchValue(f, AppServiceAlias, fetch.ServiceIPProperty, MinioServerIPValue)
 	if err != nil {
 		return
 	}
 
-	// service ip
-	ip := data[0]
-
-	templateContent, err := exec.NewShellExec("cat").Execute([]string{string(AppClientConfigTKF)})
+	err = e2e.verifyMinioServerIsReachable()
 	if err != nil {
 		return
 	}
 
-	// parse template file
-	t, err := template.New("AppClientConfigTKF").Parse(templateContent)
+	templateContent, err := ioutil.ReadFile(string(AppClientConfigTKF))
 	if err != nil {
 		return
 	}
 
-	// render template file with values
-	var output bytes.Buffer
-	values := map[string]string{
-		"ip": ip,
-	}
-	err = t.Execute(&output, values)
+	// render template file with the minio server ip
+	output, err := meta.Render(templateContent, meta.CurrentVars())
 	if err != nil {
 		return
 	}
 
 	// kubectl apply this rendered file
-	err = kubectl.ApplyStdIn(output.Bytes())
+	err = kubectl.ApplyStdIn(output)
 	if err != nil {
 		return
 	}
@@ -385,6 +399,41 @@ func (e2e *HAOnMinio) deployMinioClientConfigSetWithMinioServerIP() (err error)
 	return
 }
 
+// verifyMinioServerIsReachable verifies if litmus reaches the minio server.
+// The server is reached via its service ip if litmus runs within the cluster;
+// else via a local port forwarded to the minio service e.g. from a
+// workstation.
+//
+// NOTE:
+//  A port forward can not be dry run, recorded or replayed. Hence the server
+// is not reached if the kubectl commands are intercepted.
+func (e2e *HAOnMinio) verifyMinioServerIsReachable() (err error) {
+	if kubectl.IsIntercepted() {
+		return
+	}
+
+	address := net.JoinHostPort(meta.CurrentVars().Values[MinioServerIPValue], MinioServerPort)
+	if !kubectl.IsInCluster() {
+		err = e2e.forwards.ForwardTo(AppServiceAlias, MinioServerPort, MinioServerAddressValue)
+		if err != nil {
+			return
+		}
+		address = meta.CurrentVars().Values[MinioServerAddressValue]
+	}
+
+	client := &http.Client{Timeout: 10 * gotime.Second}
+	resp, err := client.Get(fmt.Sprintf("http://%s/minio/health/live", address))
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("minio server at '%s' is not live: status '%s'", address, resp.Status)
+	}
+	return
+}
+
 func (e2e *HAOnMinio) verifyDataIsPutToMinioServer() (err error) {
 	// has put job completed successfully ?
 	v, err := verify.NewKubeInstallVerify(AppClientJobIF)
@@ -396,6 +445,10 @@ func (e2e *HAOnMinio) verifyDataIsPutToMinioServer() (err error) {
 	return
 }
 
+func (e2e *HAOnMinio) verifyDataIsPutToMinioServerWithin(timeout string) (err error) {
+	return verify.EventuallyStep(timeout, e2e.verifyDataIsPutToMinioServer)
+}
+
 func (e2e *HAOnMinio) verifyDataIsAvailableAtMinioServer() (err error) {
 	// has get job completed successfully ?
 	v, err := verify.NewKubeInstallVerify(AppClientJobIF)
@@ -407,24 +460,42 @@ func (e2e *HAOnMinio) verifyDataIsAvailableAtMinioServer() (err error) {
 	return
 }
 
+func (e2e *HAOnMinio) verifyDataIsAvailableAtMinioServerWithin(timeout string) (err error) {
+	return verify.EventuallyStep(timeout, e2e.verifyDataIsAvailableAtMinioServer)
+}
+
 func FeatureContext(s *godog.Suite) {
 	e2e := &HAOnMinio{
 		errors: map[errorIdentity]error{},
 	}
 
+	// track the feature, scenario & step being run
+	hook.Scope(s)
+	// run the feature in its own namespace if set in the environment; this
+	// needs to be registered before the verify files are loaded
+	hook.EphemeralNamespace(s)
+	// gather the diagnostics of a failed step
+	hook.Diagnostics(s)
+
 	// before feature run
 	s.BeforeFeature(e2e.withOperatorVerifier)
 	s.BeforeFeature(e2e.withApplicationVerifier)
 	s.BeforeFeature(e2e.withVolumeVerifier)
 
-	// after feature run
-	s.AfterFeature(e2e.tearDown)
+	// after feature run i.e. delete what was applied & restore the nodes
+	hook.Teardown(s)
+
+	// steps that write & read data directly inside the application pod
+	steps.Pod(s, ApplicationIF)
+	// steps that reach the application from the test process
+	e2e.forwards = steps.PortForward(s, ApplicationIF)
 
 	s.Step(`^I have a kubernetes multi node cluster$`, e2e.iHaveAKubernetesMultiNodeCluster)
 	s.Step(`^this cluster has volume operator installed$`, e2e.thisClusterHasVolumeOperatorInstalled)
 	s.Step(`^I launch minio application on volume$`, e2e.iLaunchMinioApplicationOnVolume)
 	s.Step(`^wait for "([^"]*)"$`, e2e.waitFor)
 	s.Step(`^verify minio application is launched successfully on volume$`, e2e.verifyMinioApplicationIsLaunchedSuccessfullyOnVolume)
+	s.Step(`^verify minio application is launched successfully on volume within "([^"]*)"$`, e2e.verifyMinioApplicationIsLaunchedSuccessfullyOnVolumeWithin)
 	s.Step(`^verify PVC is bound$`, e2e.verifyPVCIsBound)
 	s.Step(`^verify PV is deployed$`, e2e.verifyPVIsDeployed)
 	s.Step(`^minio application is launched successfully on volume$`, e2e.minioApplicationIsLaunchedSuccessfullyOnVolume)
@@ -432,8 +503,11 @@ func FeatureContext(s *godog.Suite) {
 	s.Step(`^cordon the node that hosts the minio pod$`, e2e.cordonTheNodeThatHostsTheMinioPod)
 	s.Step(`^delete this minio pod$`, e2e.deleteThisMinioPod)
 	s.Step(`^verify minio is redeployed successfully$`, e2e.verifyMinioIsRedeployedSuccessfully)
+	s.Step(`^verify minio is redeployed successfully within "([^"]*)"$`, e2e.verifyMinioIsRedeployedSuccessfullyWithin)
 	s.Step(`^launch minio client get job$`, e2e.launchMinioClientGetJob)
 	s.Step(`^deploy minio client config set with minio server IP$`, e2e.deployMinioClientConfigSetWithMinioServerIP)
 	s.Step(`^verify data is put to minio server$`, e2e.verifyDataIsPutToMinioServer)
+	s.Step(`^verify data is put to minio server within "([^"]*)"$`, e2e.verifyDataIsPutToMinioServerWithin)
 	s.Step(`^verify data is available at minio server$`, e2e.verifyDataIsAvailableAtMinioServer)
+	s.Step(`^verify data is available at minio server within "([^"]*)"$`, e2e.verifyDataIsAvailableAtMinioServerWithin)
 }
@@ -0,0 +1,171 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AmitKumarDas/elitmus/pkg/exec"
+	"github.com/AmitKumarDas/elitmus/pkg/kubectl"
+	"github.com/AmitKumarDas/elitmus/pkg/meta"
+	"github.com/AmitKumarDas/elitmus/pkg/sim"
+	"github.com/AmitKumarDas/elitmus/pkg/util"
+	"github.com/DATA-DOG/godog"
+	"github.com/ghodss/yaml"
+)
+
+// record flags if the cassette is recorded against the simulated cluster
+// instead of being replayed
+var record = flag.Bool("record", false, "record the cassette of e2e.feature against the simulated cluster")
+
+// cassette is the recorded run of e2e.feature
+//
+// NOTE:
+//  A run against an actual cluster is recorded by the job of this test with
+// LITMUS_IO_CASSETTE & LITMUS_IO_CASSETTE_MODE=record set in its environment
+const cassette = "testdata/ha-on-minio.jsonl"
+
+// TestReplay runs e2e.feature via the steps of FeatureContext against the
+// kubectl executions recorded in the cassette i.e. without any cluster
+func TestReplay(t *testing.T) {
+	// the recorded args depend on these
+	envKeys := []util.ENVKey{util.KubeConfigENVK, util.KubeContextENVK, util.KubeNamespaceENVK, util.KubeBackendENVK, util.DryRunENVK, util.CassetteENVK, util.CassetteModeENVK, util.DiagnosticsConfigMapENVK, util.EphemeralNamespaceENVK}
+	for _, key := range envKeys {
+		if val, ok := os.LookupEnv(string(key)); ok {
+			defer os.Setenv(string(key), val)
+		} else {
+			defer os.Unsetenv(string(key))
+		}
+		os.Unsetenv(string(key))
+	}
+
+	dir, err := ioutil.TempDir("", "ha-on-minio")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: expected 'no error': actual '%s'", err)
+	}
+	defer os.RemoveAll(dir)
+	defer useLocalFiles(t, dir)()
+	defer kubectl.SetDefaultExecutor(nil)
+
+	var replayer *exec.ReplayExec
+	if *record {
+		os.Remove(cassette)
+		kubectl.SetDefaultExecutor(exec.NewRecordExec(newOpenEBSCluster(t), cassette).Substitute(kubectl.CassetteSubstitutions()...))
+	} else {
+		interactions, err := exec.LoadCassette(cassette)
+		if err != nil {
+			t.Fatalf("failed to load cassette: expected 'no error': actual '%s'", err)
+		}
+		replayer = exec.NewReplayExec(interactions, exec.ReplayInOrderMode).Substitute(kubectl.CassetteSubstitutions()...)
+		kubectl.SetDefaultExecutor(replayer)
+	}
+
+	var out bytes.Buffer
+	status := godog.RunWithOptions("ha-on-minio", FeatureContext, godog.Options{
+		Format:        "progress",
+		Paths:         []string{"e2e.feature"},
+		StopOnFailure: true,
+		Strict:        true,
+		NoColors:      true,
+		Output:        &out,
+	})
+	if status != 0 {
+		t.Fatalf("failed to run feature: expected 'status 0': actual 'status %d': %s", status, out.String())
+	}
+
+	if replayer == nil {
+		return
+	}
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Fatalf("failed to replay: expected 'all interactions used': actual '%d' unused starting at '%v'", len(unused), unused[0].Args)
+	}
+}
+
+// useLocalFiles points the files of this feature to their local copies. The
+// install files embedded in the config maps are written to the provided
+// directory. The returned function points the files back to their mounts.
+func useLocalFiles(t *testing.T, dir string) func() {
+	data, err := ioutil.ReadFile("test-this-feature-configs.yaml")
+	if err != nil {
+		t.Fatalf("failed to read config maps: expected 'no error': actual '%s'", err)
+	}
+
+	files := map[string]string{}
+	for _, doc := range strings.Split(string(data), "\n---") {
+		var cm struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Data map[string]string `json:"data"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &cm); err != nil {
+			t.Fatalf("failed to decode config map: expected 'no error': actual '%s'", err)
+		}
+		if len(cm.Metadata.Name) == 0 {
+			continue
+		}
+
+		files[cm.Metadata.Name] = filepath.Join(dir, cm.Metadata.Name+".yaml")
+		if err := ioutil.WriteFile(files[cm.Metadata.Name], []byte(cm.Data["config"]), 0644); err != nil {
+			t.Fatalf("failed to write install file: expected 'no error': actual '%s'", err)
+		}
+	}
+
+	ifs := []meta.InstallFile{OperatorIF, ApplicationIF, AppClientConfigIF, AppClientJobIF, VolumeIF}
+	kfs := []kubectl.KubectlFile{ApplicationKF, AppClientPutKF, AppClientGetKF}
+	tkf := AppClientConfigTKF
+
+	OperatorIF = meta.InstallFile(files["ha-minio-operator-verify"])
+	ApplicationIF = meta.InstallFile(files["ha-minio-app-verify"])
+	AppClientConfigIF = meta.InstallFile(files["ha-minio-app-client-config-verify"])
+	AppClientJobIF = meta.InstallFile(files["ha-minio-app-client-job-verify"])
+	VolumeIF = meta.InstallFile(files["ha-minio-volume-verify"])
+	ApplicationKF = "application-launch.yaml"
+	AppClientPutKF = "application-client-put-job.yaml"
+	AppClientGetKF = "application-client-get-job.yaml"
+	AppClientConfigTKF = "application-client-configs.yaml"
+
+	return func() {
+		OperatorIF, ApplicationIF, AppClientConfigIF, AppClientJobIF, VolumeIF = ifs[0], ifs[1], ifs[2], ifs[3], ifs[4]
+		ApplicationKF, AppClientPutKF, AppClientGetKF = kfs[0], kfs[1], kfs[2]
+		AppClientConfigTKF = tkf
+	}
+}
+
+// newOpenEBSCluster returns a simulated multi node cluster with openebs
+// operator & its storage classes installed
+func newOpenEBSCluster(t *testing.T) *sim.Cluster {
+	c := sim.NewCluster("node-1", "node-2", "node-3", "node-4")
+	c.AddProvisioner(sim.JivaProvisionerName, sim.JivaProvisioner)
+
+	for _, f := range []string{"../../openebs/openebs-operator-v0.5.3.yaml", "../../openebs/openebs-storage-classes-v0.5.3.yaml"} {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			t.Fatalf("failed to read '%s': expected 'no error': actual '%s'", f, err)
+		}
+		if err = c.Apply(data, "default"); err != nil {
+			t.Fatalf("failed to apply '%s': expected 'no error': actual '%s'", f, err)
+		}
+	}
+	return c
+}
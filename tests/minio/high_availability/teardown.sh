@@ -34,4 +34,7 @@ kubectl -n litmus delete configmap ha-minio-app-launch
 # successfully.
 kubectl delete -f ./application-launch.yaml
 
+# objects leaked by interrupted runs, including the released volumes of their
+# claims, can be listed via 'litmus gc --dry-run' & deleted via 'litmus gc'
+
 cd ${CURDIR}